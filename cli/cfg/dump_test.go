@@ -76,6 +76,12 @@ tt:
     log_maxsize: 1024
     log_maxage: 8
     log_maxbackups: 10
+    log_compress: ""
+    log_rotation: ""
+    log_filename_pattern: ""
+    log_sink: ""
+    log_syslog_facility: ""
+    log_tag: ""
     restart_on_failure: false
     wal_dir: %[1]s/var/lib
     memtx_dir: %[1]s/var/lib
@@ -84,6 +90,11 @@ tt:
     inc_dir: %[1]s/test_inc
     instances_enabled: .
     tarantoolctl_layout: false
+    env: {}
+    env_file: ""
+    rlimits: {}
+    stop_timeout: 0
+    crash_dir: %[1]s/var/crash
   ee:
     credential_path: ""
   templates: []
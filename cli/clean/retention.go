@@ -0,0 +1,167 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tarantool/tt/cli/running"
+	"github.com/tarantool/tt/cli/ttlog"
+)
+
+const (
+	// snapExt is an extension of memtx snapshot files.
+	snapExt = ".snap"
+	// xlogExt is an extension of write-ahead log files.
+	xlogExt = ".xlog"
+)
+
+// RetentionOpts describes the retention policy for the instance artifacts.
+type RetentionOpts struct {
+	// KeepSnapshots is the number of the latest snapshots to keep. The
+	// write-ahead logs required to recover from the oldest kept snapshot
+	// are kept too. Zero value disables the snapshots pruning.
+	KeepSnapshots int
+	// KeepLogsDays is the maximum age in days of the rotated log files.
+	// Zero value disables the rotated logs pruning.
+	KeepLogsDays int
+}
+
+// IsSet returns true if any of the retention options is set.
+func (opts RetentionOpts) IsSet() bool {
+	return opts.KeepSnapshots > 0 || opts.KeepLogsDays > 0
+}
+
+// checkpointFile describes a snapshot or a write-ahead log file.
+type checkpointFile struct {
+	// path is a path to the file.
+	path string
+	// signature is a vclock signature encoded in the file name.
+	signature int64
+}
+
+// collectCheckpointFiles collects files with the specified extension from the
+// directory sorted by the vclock signature.
+func collectCheckpointFiles(dir string, ext string) ([]checkpointFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	files := []checkpointFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+			continue
+		}
+		signature, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), ext), 10, 64)
+		if err != nil {
+			// Not a tarantool file, skip it.
+			continue
+		}
+		files = append(files, checkpointFile{
+			path:      filepath.Join(dir, entry.Name()),
+			signature: signature,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].signature < files[j].signature
+	})
+	return files, nil
+}
+
+// collectCheckpointsToRemove returns the list of snapshots and write-ahead logs that
+// are not needed to recover from the latest keepSnapshots snapshots.
+func collectCheckpointsToRemove(memtxDir, walDir string, keepSnapshots int) ([]string, error) {
+	snaps, err := collectCheckpointFiles(memtxDir, snapExt)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) <= keepSnapshots {
+		return nil, nil
+	}
+	xlogs, err := collectCheckpointFiles(walDir, xlogExt)
+	if err != nil {
+		return nil, err
+	}
+
+	removeList := []string{}
+	for _, snap := range snaps[:len(snaps)-keepSnapshots] {
+		removeList = append(removeList, snap.path)
+	}
+
+	// The oldest kept snapshot needs the write-ahead log it was created in
+	// (the one with the greatest signature not exceeding the snapshot
+	// signature) and all the newer ones.
+	oldestKept := snaps[len(snaps)-keepSnapshots].signature
+	firstNeeded := 0
+	for i, xlog := range xlogs {
+		if xlog.signature <= oldestKept {
+			firstNeeded = i
+		}
+	}
+	for _, xlog := range xlogs[:firstNeeded] {
+		removeList = append(removeList, xlog.path)
+	}
+
+	return removeList, nil
+}
+
+// collectLogsToRemove returns the list of rotated log files older than maxAge.
+func collectLogsToRemove(logFile string, pattern string, maxAge time.Duration,
+	now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(logFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	removeList := []string{}
+	cutoff := now.Add(-maxAge)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		rotationTime, isBackup := ttlog.ParseBackupName(logFile, pattern, entry.Name())
+		if isBackup && rotationTime.Before(cutoff) {
+			removeList = append(removeList, filepath.Join(filepath.Dir(logFile), entry.Name()))
+		}
+	}
+
+	return removeList, nil
+}
+
+// CollectRetentionFiles returns the list of instance files that must be removed
+// according to the retention policy.
+func CollectRetentionFiles(run *running.InstanceCtx, opts RetentionOpts,
+	now time.Time) ([]string, error) {
+	removeList := []string{}
+
+	if opts.KeepSnapshots > 0 {
+		checkpoints, err := collectCheckpointsToRemove(run.MemtxDir, run.WalDir,
+			opts.KeepSnapshots)
+		if err != nil {
+			return nil, err
+		}
+		removeList = append(removeList, checkpoints...)
+	}
+
+	if opts.KeepLogsDays > 0 {
+		logs, err := collectLogsToRemove(run.Log, run.LogFilenamePattern,
+			time.Duration(opts.KeepLogsDays)*24*time.Hour, now)
+		if err != nil {
+			return nil, err
+		}
+		removeList = append(removeList, logs...)
+	}
+
+	return removeList, nil
+}
@@ -0,0 +1,131 @@
+package clean
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/running"
+)
+
+func createFiles(t *testing.T, dir string, names ...string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte{}, 0644))
+	}
+}
+
+func TestCollectRetentionFilesCheckpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := running.InstanceCtx{
+		MemtxDir: filepath.Join(tmpDir, "memtx"),
+		WalDir:   filepath.Join(tmpDir, "wal"),
+		Log:      filepath.Join(tmpDir, "log", "inst.log"),
+	}
+	createFiles(t, run.MemtxDir,
+		"00000000000000000000.snap",
+		"00000000000000000010.snap",
+		"00000000000000000025.snap",
+		"00000000000000000040.snap.inprogress",
+	)
+	createFiles(t, run.WalDir,
+		"00000000000000000000.xlog",
+		"00000000000000000005.xlog",
+		"00000000000000000020.xlog",
+		"00000000000000000030.xlog",
+		"not_an_xlog.xlog",
+	)
+
+	removeList, err := CollectRetentionFiles(&run, RetentionOpts{KeepSnapshots: 2},
+		time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(run.MemtxDir, "00000000000000000000.snap"),
+		filepath.Join(run.WalDir, "00000000000000000000.xlog"),
+	}, removeList)
+
+	removeList, err = CollectRetentionFiles(&run, RetentionOpts{KeepSnapshots: 1},
+		time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(run.MemtxDir, "00000000000000000000.snap"),
+		filepath.Join(run.MemtxDir, "00000000000000000010.snap"),
+		filepath.Join(run.WalDir, "00000000000000000000.xlog"),
+		filepath.Join(run.WalDir, "00000000000000000005.xlog"),
+	}, removeList)
+
+	removeList, err = CollectRetentionFiles(&run, RetentionOpts{KeepSnapshots: 3},
+		time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, removeList)
+}
+
+func TestCollectRetentionFilesXlogBoundary(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := running.InstanceCtx{
+		MemtxDir: filepath.Join(tmpDir, "memtx"),
+		WalDir:   filepath.Join(tmpDir, "wal"),
+		Log:      filepath.Join(tmpDir, "log", "inst.log"),
+	}
+	createFiles(t, run.MemtxDir,
+		"00000000000000000000.snap",
+		"00000000000000000010.snap",
+		"00000000000000000020.snap",
+	)
+	createFiles(t, run.WalDir,
+		"00000000000000000000.xlog",
+		"00000000000000000010.xlog",
+		"00000000000000000015.xlog",
+		"00000000000000000020.xlog",
+		"00000000000000000030.xlog",
+	)
+
+	// The write-ahead log started at the signature of the oldest kept
+	// snapshot and the newer ones are kept.
+	removeList, err := CollectRetentionFiles(&run, RetentionOpts{KeepSnapshots: 1},
+		time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(run.MemtxDir, "00000000000000000000.snap"),
+		filepath.Join(run.MemtxDir, "00000000000000000010.snap"),
+		filepath.Join(run.WalDir, "00000000000000000000.xlog"),
+		filepath.Join(run.WalDir, "00000000000000000010.xlog"),
+		filepath.Join(run.WalDir, "00000000000000000015.xlog"),
+	}, removeList)
+
+	removeList, err = CollectRetentionFiles(&run, RetentionOpts{KeepSnapshots: 2},
+		time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(run.MemtxDir, "00000000000000000000.snap"),
+		filepath.Join(run.WalDir, "00000000000000000000.xlog"),
+	}, removeList)
+}
+
+func TestCollectRetentionFilesLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := running.InstanceCtx{
+		MemtxDir: filepath.Join(tmpDir, "memtx"),
+		WalDir:   filepath.Join(tmpDir, "wal"),
+		Log:      filepath.Join(tmpDir, "log", "inst.log"),
+	}
+	now := time.Date(2023, 6, 20, 12, 0, 0, 0, time.Local)
+	createFiles(t, filepath.Dir(run.Log),
+		"inst.log",
+		"inst-2023-06-10T10-00-00.000.log",
+		"inst-2023-06-12T10-00-00.000.log.gz",
+		"inst-2023-06-19T10-00-00.000.log",
+		"other-2023-06-01T10-00-00.000.log",
+		"inst-garbage.log",
+	)
+
+	removeList, err := CollectRetentionFiles(&run, RetentionOpts{KeepLogsDays: 7}, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(tmpDir, "log", "inst-2023-06-10T10-00-00.000.log"),
+		filepath.Join(tmpDir, "log", "inst-2023-06-12T10-00-00.000.log.gz"),
+	}, removeList)
+}
@@ -21,6 +21,8 @@ func NewCheckCmd() *cobra.Command {
 		},
 	}
 
+	addInstanceSelectorFlags(checkCmd)
+
 	return checkCmd
 }
 
@@ -31,7 +33,7 @@ func internalCheckModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	}
 
 	var runningCtx running.RunningCtx
-	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
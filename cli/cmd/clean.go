@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
+	"github.com/tarantool/tt/cli/clean"
 	"github.com/tarantool/tt/cli/cmd/internal"
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/modules"
@@ -15,7 +17,11 @@ import (
 	"github.com/tarantool/tt/cli/util"
 )
 
-var forceRemove bool
+var (
+	forceRemove   bool
+	cleanDryRun   bool
+	retentionOpts clean.RetentionOpts
+)
 
 // NewCleanCmd creates clean command.
 func NewCleanCmd() *cobra.Command {
@@ -39,6 +45,14 @@ func NewCleanCmd() *cobra.Command {
 	}
 
 	cleanCmd.Flags().BoolVarP(&forceRemove, "force", "f", false, "do not ask for confirmation")
+	cleanCmd.Flags().BoolVarP(&cleanDryRun, "dry-run", "", false,
+		"print the files to be deleted and exit")
+	cleanCmd.Flags().IntVarP(&retentionOpts.KeepSnapshots, "keep-snapshots", "", 0,
+		"keep the last N snapshots and the write-ahead logs required to recover from them")
+	cleanCmd.Flags().IntVarP(&retentionOpts.KeepLogsDays, "keep-logs-days", "", 0,
+		"remove only rotated logs older than the specified number of days")
+
+	addInstanceSelectorFlags(cleanCmd)
 
 	return cleanCmd
 }
@@ -62,16 +76,23 @@ func collectFiles(list []string, dirname string) ([]string, error) {
 	return list, nil
 }
 
-func clean(run *running.InstanceCtx) error {
+func cleanInstance(run *running.InstanceCtx) error {
 	removeList := []string{}
 	confirm := false
 	var err error
 
-	for _, dir := range [...]string{run.LogDir, run.WalDir, run.VinylDir, run.MemtxDir} {
-		removeList, err = collectFiles(removeList, dir)
+	if retentionOpts.IsSet() {
+		removeList, err = clean.CollectRetentionFiles(run, retentionOpts, time.Now())
 		if err != nil {
 			return err
 		}
+	} else {
+		for _, dir := range [...]string{run.LogDir, run.WalDir, run.VinylDir, run.MemtxDir} {
+			removeList, err = collectFiles(removeList, dir)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if len(removeList) == 0 {
@@ -84,6 +105,10 @@ func clean(run *running.InstanceCtx) error {
 		log.Infof("%s", file)
 	}
 
+	if cleanDryRun {
+		return nil
+	}
+
 	if !forceRemove {
 		confirm, err = util.AskConfirm(os.Stdin, "\nConfirm")
 		if err != nil {
@@ -112,16 +137,20 @@ func internalCleanModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	}
 
 	var runningCtx running.RunningCtx
-	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
+	if retentionOpts.KeepSnapshots < 0 || retentionOpts.KeepLogsDays < 0 {
+		return util.NewArgError("retention options must not be negative")
+	}
+
 	for _, run := range runningCtx.Instances {
 		status := running.Status(&run)
-		if status.Code == process_utils.ProcessStoppedCode {
+		if status.Code == process_utils.ProcessStoppedCode || cleanDryRun {
 			var statusMsg string
 
-			err := clean(&run)
+			err := cleanInstance(&run)
 			if err != nil {
 				statusMsg = "[ERR] " + err.Error()
 			} else {
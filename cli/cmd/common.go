@@ -6,11 +6,22 @@ import (
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
+	"github.com/tarantool/tt/cli/cmd/internal"
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/running"
 	"github.com/tarantool/tt/cli/util"
 )
 
+var (
+	// selectorTags contains the instance tag selectors in the key=value form.
+	selectorTags []string
+	// selectAllApps selects instances of all applications explicitly.
+	selectAllApps bool
+	// parallel is the maximum number of instances processed simultaneously.
+	parallel int
+)
+
 // handleCmdErr handles an error returned by command implementation.
 // If received error is of an ArgError type, usage help is printed.
 func handleCmdErr(cmd *cobra.Command, err error) {
@@ -34,3 +45,41 @@ var errNoConfig = errors.New(configure.ConfigName +
 func isConfigExist(cmdCtx *cmdcontext.CmdCtx) bool {
 	return cmdCtx.Cli.ConfigPath != ""
 }
+
+// addInstanceSelectorFlags adds the instance selection flags to the command.
+func addInstanceSelectorFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&selectorTags, "tag", nil,
+		"select instances with the tag in the key=value form, can be specified multiple times")
+	cmd.Flags().BoolVar(&selectAllApps, "all-apps", false,
+		"select instances of all applications")
+	cmd.RegisterFlagCompletionFunc("tag", func(cmd *cobra.Command, args []string,
+		toComplete string) ([]string, cobra.ShellCompDirective) {
+		return internal.TagsCompletionFunc(cliOpts, &cmdCtx)
+	})
+}
+
+// fillSelectedCtx fills the running context with the instances selected by the
+// command arguments and the instance selection flags.
+func fillSelectedCtx(cmdCtx *cmdcontext.CmdCtx, runningCtx *running.RunningCtx,
+	args []string) error {
+	if selectAllApps && len(args) > 0 {
+		return util.NewArgError("--all-apps can't be used with an application name")
+	}
+	tags, err := running.ParseTagSelectors(selectorTags)
+	if err != nil {
+		return util.NewArgError(err.Error())
+	}
+	if err = running.FillCtx(cliOpts, cmdCtx, runningCtx, args); err != nil {
+		return err
+	}
+	return running.SelectInstances(runningCtx,
+		running.InstanceSelector{Tags: tags})
+}
+
+// addParallelFlag adds the flag limiting the number of instances processed
+// simultaneously.
+func addParallelFlag(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&parallel, "parallel", 0,
+		"maximum number of instances processed simultaneously "+
+			"(default: start all instances at once, stop them one by one)")
+}
@@ -25,8 +25,10 @@ func TestCheckConfig(t *testing.T) {
 		{"create", internalCreateModule(&cmdcontext.CmdCtx{}, nil)},
 		{"install", internalInstallModule(&cmdcontext.CmdCtx{}, nil)},
 		{"instances", internalInstancesModule(&cmdcontext.CmdCtx{}, nil)},
+		{"log", internalLogModule(&cmdcontext.CmdCtx{}, nil)},
 		{"logrotate", internalLogrotateModule(&cmdcontext.CmdCtx{}, nil)},
 		{"pack", internalPackModule(&cmdcontext.CmdCtx{}, nil)},
+		{"reload", internalReloadModule(&cmdcontext.CmdCtx{}, nil)},
 		{"restart", internalRestartModule(&cmdcontext.CmdCtx{}, nil)},
 		{"run", internalRunModule(&cmdcontext.CmdCtx{}, nil)},
 		{"start", internalStartModule(&cmdcontext.CmdCtx{}, nil)},
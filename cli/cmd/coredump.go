@@ -1,8 +1,26 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/tarantool/tt/cli/coredump"
+	"github.com/tarantool/tt/cli/util"
+)
+
+var (
+	// coredumpExecutable is the tarantool executable that produced the core.
+	coredumpExecutable string
+	// coredumpCompression is the compression of the coredump archive.
+	coredumpCompression util.CompressionOpts
+	// coredumpReport enables the batch crash report instead of an interactive
+	// gdb session.
+	coredumpReport bool
+	// coredumpReportFormat is the crash report format: json or markdown.
+	coredumpReportFormat string
+	// coredumpReportOutput is the file the crash report is written to.
+	coredumpReportOutput string
 )
 
 // NewCoredumpCmd creates coredump command.
@@ -16,16 +34,28 @@ func NewCoredumpCmd() *cobra.Command {
 		Use:   "pack <COREDUMP>",
 		Short: "pack tarantool coredump into tar.gz archive",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runCoredumpCommand(coredump.Pack, args[0]); err != nil {
+			opts := coredump.PackOpts{
+				Executable:          coredumpExecutable,
+				TarantoolExecutable: cmdCtx.Cli.TarantoolExecutable,
+				Compression:         coredumpCompression,
+			}
+			if err := coredump.Pack(args[0], opts); err != nil {
 				handleCmdErr(cmd, err)
 			}
 		},
 		Args: cobra.ExactArgs(1),
 	}
 
+	packCmd.Flags().StringVarP(&coredumpExecutable, "executable", "e", "",
+		"tarantool executable that produced the core")
+	packCmd.Flags().StringVar(&coredumpCompression.Type, "compression", util.CompressionGzip,
+		"archive compression: gzip, xz or zstd")
+	packCmd.Flags().IntVar(&coredumpCompression.Level, "compression-level", 0,
+		"archive compression level, the default one of the compression if not set")
+
 	var unpackCmd = &cobra.Command{
 		Use:   "unpack <ARCHIVE>",
-		Short: "unpack tarantool coredump tar.gz archive",
+		Short: "unpack tarantool coredump tar.gz, tar.xz or tar.zst archive",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := runCoredumpCommand(coredump.Unpack, args[0]); err != nil {
 				handleCmdErr(cmd, err)
@@ -38,13 +68,26 @@ func NewCoredumpCmd() *cobra.Command {
 		Use:   "inspect <FOLDER>",
 		Short: "inspect tarantool coredump folder",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := runCoredumpCommand(coredump.Inspect, args[0]); err != nil {
+			inspect := coredump.Inspect
+			if coredumpReport {
+				inspect = writeCoredumpReport
+			} else if cmd.Flags().Changed("format") || cmd.Flags().Changed("output") {
+				handleCmdErr(cmd, util.NewArgError("--format and --output require --report"))
+			}
+			if err := runCoredumpCommand(inspect, args[0]); err != nil {
 				handleCmdErr(cmd, err)
 			}
 		},
 		Args: cobra.ExactArgs(1),
 	}
 
+	inspectCmd.Flags().BoolVar(&coredumpReport, "report", false,
+		"run gdb in batch mode and print a crash report")
+	inspectCmd.Flags().StringVar(&coredumpReportFormat, "format", coredump.ReportFormatJSON,
+		"crash report format: json or markdown")
+	inspectCmd.Flags().StringVarP(&coredumpReportOutput, "output", "o", "",
+		"file to write the crash report to")
+
 	replicasetsSubCommands := []*cobra.Command{
 		packCmd,
 		unpackCmd,
@@ -67,3 +110,24 @@ func runCoredumpCommand(replicasetsFunc func(args string) error, args string) er
 
 	return nil
 }
+
+// writeCoredumpReport writes the crash report of the unpacked coredump.
+func writeCoredumpReport(coreFolder string) error {
+	if coredumpReportFormat != coredump.ReportFormatJSON &&
+		coredumpReportFormat != coredump.ReportFormatMarkdown {
+		return util.NewArgError(fmt.Sprintf("unknown report format %q, %s or %s is expected",
+			coredumpReportFormat, coredump.ReportFormatJSON, coredump.ReportFormatMarkdown))
+	}
+	report, err := coredump.Report(coreFolder)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if coredumpReportOutput != "" {
+		if out, err = os.Create(coredumpReportOutput); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	return coredump.WriteReport(out, report, coredumpReportFormat)
+}
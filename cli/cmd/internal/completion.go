@@ -38,3 +38,20 @@ func ValidArgsFunction(
 	args = appPicker(runningCtx.Instances)
 	return
 }
+
+// TagsCompletionFunc is the function used for dynamic auto-completion of the
+// instance tag selectors.
+func TagsCompletionFunc(
+	cliOpts *config.CliOpts,
+	cmdCtx *cmdcontext.CmdCtx,
+) (tags []string, directive cobra.ShellCompDirective) {
+	directive = cobra.ShellCompDirectiveNoFileComp
+
+	var runningCtx running.RunningCtx
+	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, []string{}); err != nil {
+		return
+	}
+
+	tags = running.ExtractTags(runningCtx.Instances)
+	return
+}
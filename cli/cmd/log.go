@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/tarantool/tt/cli/cmd/internal"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/modules"
+	"github.com/tarantool/tt/cli/running"
+	"github.com/tarantool/tt/cli/tail"
+	"github.com/tarantool/tt/cli/util"
+)
+
+var (
+	// logFollow enables following of the log files.
+	logFollow bool
+	// logLines is the number of last lines to print.
+	logLines int
+	// logLevel is the maximum level of lines to print.
+	logLevel string
+	// logGrep is a regular expression to filter lines by.
+	logGrep string
+)
+
+// logFollowPeriod is a period of log files polling in follow mode.
+const logFollowPeriod = 200 * time.Millisecond
+
+// logPrefixColors is a set of colors used for instance prefixes.
+var logPrefixColors = []color.Attribute{
+	color.FgCyan,
+	color.FgGreen,
+	color.FgMagenta,
+	color.FgYellow,
+	color.FgBlue,
+	color.FgHiCyan,
+	color.FgHiGreen,
+	color.FgHiMagenta,
+	color.FgHiYellow,
+	color.FgHiBlue,
+}
+
+// NewLogCmd creates log command.
+func NewLogCmd() *cobra.Command {
+	var logCmd = &cobra.Command{
+		Use:   "log [<APP_NAME> | <APP_NAME:INSTANCE_NAME>] [flags]",
+		Short: "Print or follow logs of the tarantool instance(s)",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdCtx.CommandName = cmd.Name()
+			err := modules.RunCmd(&cmdCtx, cmd.CommandPath(), &modulesInfo,
+				internalLogModule, args)
+			handleCmdErr(cmd, err)
+		},
+		ValidArgsFunction: func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string) ([]string, cobra.ShellCompDirective) {
+			return internal.ValidArgsFunction(
+				cliOpts, &cmdCtx, cmd, toComplete,
+				running.ExtractAppNames,
+				running.ExtractInstanceNames)
+		},
+	}
+
+	logCmd.Flags().BoolVarP(&logFollow, "follow", "f", false, "output appended data as the"+
+		" log grows")
+	logCmd.Flags().IntVarP(&logLines, "lines", "n", 10, "number of last lines to print")
+	logCmd.Flags().StringVar(&logLevel, "level", "", "print only lines with the specified"+
+		" or more severe level (fatal, syserror, error, crit, warn, info, verbose, debug)")
+	logCmd.Flags().StringVar(&logGrep, "grep", "", "print only lines matching the regular"+
+		" expression")
+
+	return logCmd
+}
+
+// getLogFilter creates log lines filter from the command flags.
+func getLogFilter() (tail.Filter, error) {
+	filter := tail.Filter{}
+	var err error
+	if logLevel != "" {
+		if filter.Level, err = tail.ParseLevel(logLevel); err != nil {
+			return filter, util.NewArgError(err.Error())
+		}
+	}
+	if logGrep != "" {
+		if filter.Match, err = regexp.Compile(logGrep); err != nil {
+			return filter, util.NewArgError(fmt.Sprintf("invalid regular expression: %s", err))
+		}
+	}
+	return filter, nil
+}
+
+// internalLogModule is a default log module.
+func internalLogModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
+	if !isConfigExist(cmdCtx) {
+		return errNoConfig
+	}
+
+	if logLines < 0 {
+		return util.NewArgError("the number of lines must not be negative")
+	}
+	filter, err := getLogFilter()
+	if err != nil {
+		return err
+	}
+
+	var runningCtx running.RunningCtx
+	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+		return err
+	}
+
+	prefixes := make([]string, len(runningCtx.Instances))
+	for i, run := range runningCtx.Instances {
+		prefix := color.New(logPrefixColors[i%len(logPrefixColors)]).SprintFunc()
+		prefixes[i] = prefix(running.GetAppInstanceName(run) + ":")
+	}
+	printLine := func(index int, line string) {
+		if text, ok := filter.Apply(line); ok {
+			fmt.Println(prefixes[index], text)
+		}
+	}
+
+	if !logFollow {
+		for i, run := range runningCtx.Instances {
+			if logLines == 0 {
+				continue
+			}
+			if _, err := os.Stat(run.Log); os.IsNotExist(err) {
+				continue
+			}
+			lines, err := util.GetLastNLines(run.Log, logLines)
+			if err != nil {
+				return fmt.Errorf("%s: %s", running.GetAppInstanceName(run), err)
+			}
+			for _, line := range lines {
+				printLine(i, line)
+			}
+		}
+		return nil
+	}
+
+	followers := make([]*tail.Follower, 0, len(runningCtx.Instances))
+	defer func() {
+		for _, follower := range followers {
+			follower.Close()
+		}
+	}()
+	for _, run := range runningCtx.Instances {
+		follower, err := tail.NewFollower(run.Log, logLines)
+		if err != nil {
+			return fmt.Errorf("%s: %s", running.GetAppInstanceName(run), err)
+		}
+		followers = append(followers, follower)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return tail.Follow(ctx, followers, logFollowPeriod, printLine)
+}
@@ -31,6 +31,8 @@ func NewLogrotateCmd() *cobra.Command {
 		},
 	}
 
+	addInstanceSelectorFlags(logrotateCmd)
+
 	return logrotateCmd
 }
 
@@ -41,7 +43,7 @@ func internalLogrotateModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	}
 
 	var runningCtx running.RunningCtx
-	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
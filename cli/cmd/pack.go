@@ -9,18 +9,22 @@ import (
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/modules"
 	"github.com/tarantool/tt/cli/pack"
+	"github.com/tarantool/tt/cli/util"
 )
 
 // packCtx contains information for tt pack command.
 var packCtx = &pack.PackCtx{}
 
+// packInspectOpts contains options of tt pack inspect command.
+var packInspectOpts = pack.InspectOpts{}
+
 func NewPackCmd() *cobra.Command {
 	var packCmd = &cobra.Command{Use: "pack TYPE [flags] ..",
 		Short: "Pack application into a distributable bundle",
 		Long: `Pack application into a distributable bundle
 
-The supported types are: tgz, deb, rpm`,
-		ValidArgs: []string{"tgz", "deb", "rpm"},
+The supported types are: tgz, deb, rpm, oci, selfextract`,
+		ValidArgs: []string{"tgz", "deb", "rpm", "oci", "selfextract"},
 		Run: func(cmd *cobra.Command, args []string) {
 			err := cobra.ExactArgs(1)(cmd, args)
 			if err != nil {
@@ -36,6 +40,14 @@ The supported types are: tgz, deb, rpm`,
 				err = fmt.Errorf("cartridge-compat flag can only be used while packing tgz bundle")
 				log.Fatalf(err.Error())
 			}
+			if packCtx.UseDocker && args[0] == pack.Oci {
+				err = fmt.Errorf("use-docker flag can not be used while packing OCI image")
+				log.Fatalf(err.Error())
+			}
+			if packCtx.RpmDeb.SignKey != "" && packCtx.UseDocker {
+				err = fmt.Errorf("sign-key flag can not be used while packing in docker")
+				log.Fatalf(err.Error())
+			}
 			cmdCtx.CommandName = cmd.Name()
 			err = modules.RunCmd(&cmdCtx, cmd.CommandPath(), &modulesInfo, internalPackModule, args)
 			handleCmdErr(cmd, err)
@@ -57,16 +69,59 @@ The supported types are: tgz, deb, rpm`,
 		"Include tarantool and tt binaries to the result package")
 	packCmd.Flags().BoolVar(&packCtx.CartridgeCompat, "cartridge-compat", false,
 		"Pack cartridge cli compatible archive (only for tgz type)")
+	packCmd.Flags().BoolVar(&packCtx.Reproducible, "reproducible", packCtx.Reproducible,
+		"Build a reproducible package: normalize ownership, permissions and timestamps "+
+			"of the packed files. The timestamp is taken from SOURCE_DATE_EPOCH")
+	packCmd.Flags().StringSliceVar(&packCtx.Exclude, "exclude", packCtx.Exclude,
+		"Exclude the application files matching the gitignore-like pattern. "+
+			"Applied after the .packignore file patterns")
+	packCmd.Flags().BoolVar(&packCtx.DryRun, "dry-run", packCtx.DryRun,
+		"List the files that would be packed without creating a package")
+	packCmd.Flags().StringVar(&packCtx.Sbom, "sbom", packCtx.Sbom,
+		"Generate the software bill of materials in the format: cyclonedx, spdx. "+
+			"The SBOM is embedded into the package and written next to it")
+	packCmd.Flags().StringVar(&packCtx.Compression.Type, "compression",
+		util.CompressionGzip,
+		"Compression of the tarball, the RPM payload and the DEB data archive: "+
+			"gzip, xz, zstd")
+	packCmd.Flags().IntVar(&packCtx.Compression.Level, "compression-level",
+		packCtx.Compression.Level,
+		"Compression level: 1-9 for gzip and xz, 1-22 for zstd. "+
+			"The default level of the compression is used if not set")
 
 	// TarGZ flags.
 	packCmd.Flags().BoolVar(&packCtx.Archive.All, "all", packCtx.Archive.All,
 		"Pack all included artifacts")
 
+	// OCI flags.
+	packCmd.Flags().StringVar(&packCtx.Oci.BaseImage, "base-image", packCtx.Oci.BaseImage,
+		"Base image tarball: OCI image layout or docker save archive. Only for OCI packing.")
+	packCmd.Flags().StringSliceVar(&packCtx.Oci.ExposedPorts, "expose", packCtx.Oci.ExposedPorts,
+		"Ports exposed by the image, e.g. 3301 or 3301/tcp. Only for OCI packing.")
+	packCmd.Flags().StringSliceVar(&packCtx.Oci.Env, "image-env", packCtx.Oci.Env,
+		"Environment variables of the image in the KEY=VALUE format. Only for OCI packing.")
+
 	// RPMDeb flags.
 	packCmd.Flags().StringVar(&packCtx.RpmDeb.PreInst, "preinst", packCtx.RpmDeb.PreInst,
 		"preinst file path. Only for for RPM and Deb packing.")
 	packCmd.Flags().StringVar(&packCtx.RpmDeb.PostInst, "postinst", packCtx.RpmDeb.PostInst,
 		"postinst file path. Only for for RPM and Deb packing.")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.PreRm, "prerm", packCtx.RpmDeb.PreRm,
+		"prerm file path. Only for for RPM and Deb packing.")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.PostRm, "postrm", packCtx.RpmDeb.PostRm,
+		"postrm file path. Only for for RPM and Deb packing.")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.SystemUser, "system-user",
+		packCtx.RpmDeb.SystemUser,
+		"System user that owns the data directories and runs the instances. "+
+			"Only for RPM and Deb packing. Default: tarantool")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.SystemGroup, "system-group",
+		packCtx.RpmDeb.SystemGroup,
+		"System group of the system user. Only for RPM and Deb packing. "+
+			"Default: the system user name")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.SystemdUnitParamsFile, "unit-params-file",
+		packCtx.RpmDeb.SystemdUnitParamsFile,
+		"YAML file with the systemd unit parameters and hardening directives. "+
+			"Only for RPM, Deb and self-extracting installer packing.")
 	packCmd.Flags().StringVar(&packCtx.RpmDeb.DepsFile, "deps-file", packCtx.RpmDeb.DepsFile,
 		"Path to the file that contains dependencies for the RPM and DEB packages")
 	packCmd.Flags().BoolVar(&packCtx.RpmDeb.WithTarantoolDeps, "with-tarantool-deps",
@@ -74,13 +129,57 @@ The supported types are: tgz, deb, rpm`,
 		"Add tarantool and tt as dependencies to the result package")
 	packCmd.Flags().StringSliceVar(&packCtx.RpmDeb.Deps, "deps", packCtx.RpmDeb.Deps,
 		"Dependencies for the RPM and DEB packages")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.SignKey, "sign-key", packCtx.RpmDeb.SignKey,
+		"OpenPGP secret key file, or key ID, fingerprint, e-mail or full user ID of the key "+
+			"in the keyring to sign the RPM and DEB packages")
+	packCmd.Flags().StringVar(&packCtx.RpmDeb.SignKeyring, "sign-keyring",
+		packCtx.RpmDeb.SignKeyring,
+		"Keyring file with the signing key. Default: ~/.gnupg/secring.gpg")
 	packCmd.Flags().BoolVar(&packCtx.UseDocker, "use-docker",
 		packCtx.UseDocker,
 		"Use docker for building a package.")
 
+	packCmd.AddCommand(newPackInspectCmd())
+
 	return packCmd
 }
 
+// newPackInspectCmd creates pack inspect command.
+func newPackInspectCmd() *cobra.Command {
+	var inspectCmd = &cobra.Command{
+		Use:   "inspect <FILE>",
+		Short: "Print the content of rpm, deb or tgz package",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPackInspect(args[0]); err != nil {
+				handleCmdErr(cmd, err)
+			}
+		},
+		Args: cobra.ExactArgs(1),
+	}
+
+	inspectCmd.Flags().BoolVar(&packInspectOpts.Verify, "verify", false,
+		"Verify the package digests and signatures")
+	inspectCmd.Flags().StringVar(&packInspectOpts.Keyring, "keyring", "",
+		"Keyring file with the public keys to verify the package signatures")
+
+	return inspectCmd
+}
+
+// runPackInspect prints the package content.
+func runPackInspect(packagePath string) error {
+	info, err := pack.Inspect(packagePath, packInspectOpts)
+	if err != nil {
+		return err
+	}
+	if err = pack.WritePackageInfo(os.Stdout, info); err != nil {
+		return err
+	}
+	if !info.Verified() {
+		return fmt.Errorf("package verification failed")
+	}
+	return nil
+}
+
 // internalPackModule is a default pack module.
 func internalPackModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	if !isConfigExist(cmdCtx) {
@@ -94,13 +193,18 @@ func internalPackModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 
 	checkFlags(packCtx)
 
+	if packCtx.DryRun {
+		return pack.ListBundleFiles(cmdCtx, packCtx, cliOpts, os.Stdout)
+	}
+
 	if packCtx.UseDocker {
 		return pack.PackInDocker(cmdCtx, packCtx, *cliOpts, os.Args)
 	}
 
 	packer := pack.CreatePacker(packCtx)
 	if packer == nil {
-		return fmt.Errorf("incorrect type of package. Available types: rpm, deb, tgz")
+		return fmt.Errorf("incorrect type of package. " +
+			"Available types: rpm, deb, tgz, oci, selfextract")
 	}
 
 	err = packer.Run(cmdCtx, packCtx, cliOpts)
@@ -112,7 +216,7 @@ func internalPackModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 
 func checkFlags(packCtx *pack.PackCtx) {
 	switch pack.PackageType(packCtx.Type) {
-	case pack.Tgz:
+	case pack.Tgz, pack.Oci, pack.Selfextract:
 		if len(packCtx.RpmDeb.Deps) > 0 {
 			log.Warnf("You specified the --deps flag," +
 				" but you are not packaging RPM or DEB. Flag will be ignored")
@@ -125,10 +229,45 @@ func checkFlags(packCtx *pack.PackCtx) {
 			log.Warnf("You specified the --postinst flag," +
 				" but you are not packaging RPM or DEB. Flag will be ignored")
 		}
+		if packCtx.RpmDeb.PreRm != "" {
+			log.Warnf("You specified the --prerm flag," +
+				" but you are not packaging RPM or DEB. Flag will be ignored")
+		}
+		if packCtx.RpmDeb.PostRm != "" {
+			log.Warnf("You specified the --postrm flag," +
+				" but you are not packaging RPM or DEB. Flag will be ignored")
+		}
+		if packCtx.RpmDeb.SystemUser != "" || packCtx.RpmDeb.SystemGroup != "" {
+			log.Warnf("You specified the --system-user or --system-group flag," +
+				" but you are not packaging RPM or DEB. Flag will be ignored")
+		}
+		if packCtx.RpmDeb.SignKey != "" {
+			log.Warnf("You specified the --sign-key flag," +
+				" but you are not packaging RPM or DEB. Flag will be ignored")
+		}
+		if packCtx.Type != pack.Selfextract && packCtx.RpmDeb.SystemdUnitParamsFile != "" {
+			log.Warnf("You specified the --unit-params-file flag," +
+				" but you are not packaging RPM, DEB or self-extracting installer." +
+				" Flag will be ignored")
+		}
+		if packCtx.Type != pack.Tgz && packCtx.Archive.All {
+			log.Warnf("You specified the --all flag," +
+				" but you are not packaging a tarball. Flag will be ignored")
+		}
 	case pack.Rpm, pack.Deb:
 		if packCtx.Archive.All == true {
 			log.Warnf("You specified the --all flag," +
 				" but you are not packaging a tarball. Flag will be ignored")
 		}
 	}
+	if (packCtx.Type == pack.Oci || packCtx.Type == pack.Selfextract) &&
+		(packCtx.Compression.GetType() != util.CompressionGzip ||
+			packCtx.Compression.Level != 0) {
+		log.Warnf("You specified the --compression or --compression-level flag," +
+			" but you are not packaging tgz, RPM or DEB. Flag will be ignored")
+	}
+	if packCtx.Type != pack.Oci && packCtx.Oci.BaseImage != "" {
+		log.Warnf("You specified the --base-image flag," +
+			" but you are not packaging an OCI image. Flag will be ignored")
+	}
 }
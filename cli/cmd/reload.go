@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/spf13/cobra"
+	"github.com/tarantool/tt/cli/cmd/internal"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/modules"
+	"github.com/tarantool/tt/cli/running"
+)
+
+// NewReloadCmd creates reload command.
+func NewReloadCmd() *cobra.Command {
+	var reloadCmd = &cobra.Command{
+		Use:   "reload [<APP_NAME> | <APP_NAME:INSTANCE_NAME>]",
+		Short: "Reload configuration of a started tarantool instance(s)",
+		Long: "Reload configuration of a started tarantool instance(s) without restart.\n" +
+			"Log settings are re-read by the watchdog and applied in place. Changed box.cfg\n" +
+			"options passed with TT_<OPTION> variables of the instance environment are\n" +
+			"applied to the running instance if they can be changed dynamically.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdCtx.CommandName = cmd.Name()
+			err := modules.RunCmd(&cmdCtx, cmd.CommandPath(), &modulesInfo,
+				internalReloadModule, args)
+			handleCmdErr(cmd, err)
+		},
+		ValidArgsFunction: func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string) ([]string, cobra.ShellCompDirective) {
+			return internal.ValidArgsFunction(
+				cliOpts, &cmdCtx, cmd, toComplete,
+				running.ExtractAppNames,
+				running.ExtractInstanceNames)
+		},
+	}
+
+	addInstanceSelectorFlags(reloadCmd)
+
+	return reloadCmd
+}
+
+// internalReloadModule is a default reload module.
+func internalReloadModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
+	if !isConfigExist(cmdCtx) {
+		return errNoConfig
+	}
+
+	var runningCtx running.RunningCtx
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
+		return err
+	}
+
+	for _, run := range runningCtx.Instances {
+		instName := running.GetAppInstanceName(run)
+		res, err := running.Reload(&run)
+		if err != nil {
+			return err
+		}
+		log.Infof("%s: the configuration has been reloaded.", instName)
+		if len(res.Applied) > 0 {
+			log.Infof("%s: applied: %s.", instName, strings.Join(res.Applied, ", "))
+		}
+		if len(res.RestartRequired) > 0 {
+			log.Warnf("%s: restart is required to apply: %s.", instName,
+				strings.Join(res.RestartRequired, ", "))
+		}
+		for _, failure := range res.Failed {
+			log.Errorf("%s: failed to apply %s.", instName, failure)
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
@@ -43,6 +44,9 @@ func NewRestartCmd() *cobra.Command {
 	restartCmd.Flags().BoolVarP(&autoYes, "yes", "y", false,
 		`Automatic yes to confirmation prompt`)
 
+	addInstanceSelectorFlags(restartCmd)
+	addParallelFlag(restartCmd)
+
 	return restartCmd
 }
 
@@ -59,6 +63,10 @@ func internalRestartModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 		} else {
 			instancesToConfirm = fmt.Sprintf("'%s'", args[0])
 		}
+		if len(selectorTags) > 0 {
+			instancesToConfirm += fmt.Sprintf(" with tags %s",
+				strings.Join(selectorTags, ", "))
+		}
 		confirmed, err := util.AskConfirm(os.Stdin, fmt.Sprintf("Confirm restart of %s",
 			instancesToConfirm))
 		if err != nil {
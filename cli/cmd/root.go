@@ -145,6 +145,8 @@ func NewCmdRoot() *cobra.Command {
 		NewStatusCmd(),
 		NewRestartCmd(),
 		NewLogrotateCmd(),
+		NewReloadCmd(),
+		NewLogCmd(),
 		NewCheckCmd(),
 		NewConnectCmd(),
 		NewRocksCmd(),
@@ -24,6 +24,8 @@ var (
 	runVersion bool
 	// runArgs contains command args.
 	runArgs []string
+	// runWatch enables restart of the script on changes in its directory.
+	runWatch bool
 )
 
 func newRunOpts(cmdCtx cmdcontext.CmdCtx) *running.RunOpts {
@@ -91,6 +93,9 @@ is after '--', so passed to script.lua as is.
 	runCmd.Flags().BoolVarP(&runInteractive, "interactive", "i", false,
 		"enter interactive mode after executing 'SCRIPT'")
 	runCmd.Flags().BoolVarP(&runVersion, "version", "v", false, "print used tarantool version")
+	runCmd.Flags().BoolVarP(&runWatch, "watch", "", false,
+		"restart the script on changes in its directory (development mode), files matching "+
+			"patterns from "+running.WatchIgnoreFile+" are ignored")
 
 	return runCmd
 }
@@ -114,6 +119,9 @@ func internalRunModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 		}
 	}
 	runOpts.RunFlags.RunArgs = args[startIndex:]
+	if runWatch {
+		return running.RunWatch(runOpts, scriptPath)
+	}
 	if err := running.Run(runOpts, scriptPath); err != nil {
 		return err
 	}
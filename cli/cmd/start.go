@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/spf13/cobra"
@@ -11,6 +16,7 @@ import (
 	"github.com/tarantool/tt/cli/modules"
 	"github.com/tarantool/tt/cli/process_utils"
 	"github.com/tarantool/tt/cli/running"
+	"github.com/tarantool/tt/cli/util"
 )
 
 var (
@@ -18,8 +24,30 @@ var (
 	// In go, we can't just fork the process (reason - goroutines).
 	// So, for daemonize, we restarts the process with "watchdog" flag.
 	watchdog bool
+	// startWait is a timeout of waiting for the started instances to be ready.
+	// Zero value disables waiting.
+	startWait time.Duration
+	// startForeground enables running of the watchdog in the current process.
+	startForeground bool
+	// startWatch enables restart of the instances on the application changes.
+	startWatch bool
+	// exitNotify is a hidden flag used to notify the parent process about the
+	// instance exits through the exitNotifyFd pipe.
+	exitNotify bool
 )
 
+// defaultStartWait is a timeout of waiting for the instances readiness if
+// --wait flag is used without a value.
+const defaultStartWait = "60s"
+
+// exitNotifyFd is a descriptor of the pipe passed to the watchdog process to
+// notify the parent process about the instance exits.
+const exitNotifyFd = 3
+
+// foregroundReadyTimeout is a timeout of waiting for the instances readiness
+// in foreground mode if --wait is not set.
+const foregroundReadyTimeout = 24 * time.Hour
+
 // NewStartCmd creates start command.
 func NewStartCmd() *cobra.Command {
 	var startCmd = &cobra.Command{
@@ -44,10 +72,255 @@ func NewStartCmd() *cobra.Command {
 
 	startCmd.Flags().BoolVar(&watchdog, "watchdog", false, "")
 	startCmd.Flags().MarkHidden("watchdog")
+	startCmd.Flags().BoolVar(&exitNotify, "exit-notify", false, "")
+	startCmd.Flags().MarkHidden("exit-notify")
+	startCmd.Flags().DurationVar(&startWait, "wait", 0,
+		"wait for the instances to be ready (box.info.status is running), "+
+			"optional value is a timeout, default: "+defaultStartWait)
+	startCmd.Flags().Lookup("wait").NoOptDefVal = defaultStartWait
+	startCmd.Flags().BoolVar(&startForeground, "foreground", false,
+		"run the watchdog in the current process and notify systemd (sd_notify) "+
+			"about the instances state")
+	startCmd.Flags().BoolVar(&startWatch, "watch", false,
+		"restart the instances on changes in the application directory (development mode), "+
+			"files matching patterns from "+running.WatchIgnoreFile+" are ignored")
+
+	addInstanceSelectorFlags(startCmd)
+	addParallelFlag(startCmd)
 
 	return startCmd
 }
 
+// startedWatchdog describes a watchdog process started for an instance.
+type startedWatchdog struct {
+	// run is the instance context.
+	run running.InstanceCtx
+	// cmd is the watchdog process.
+	cmd *exec.Cmd
+	// exited is closed when the watchdog process exits.
+	exited chan struct{}
+	// instanceExited is closed when the instance process or the watchdog
+	// process exits. It is nil if the exits are not tracked.
+	instanceExited chan struct{}
+}
+
+// trackInstanceExits returns a channel that is closed when the watchdog
+// writes to the pipe (the instance has exited) or closes it (the watchdog has
+// exited).
+func trackInstanceExits(pipe *os.File) chan struct{} {
+	instanceExited := make(chan struct{})
+	go func() {
+		defer pipe.Close()
+		buf := make([]byte, 1)
+		_, err := pipe.Read(buf)
+		close(instanceExited)
+		// Drain the pipe to not block the watchdog on the next exits.
+		for err == nil {
+			_, err = pipe.Read(buf)
+		}
+	}()
+	return instanceExited
+}
+
+// startWatchdog starts a watchdog process for the instance if it is not
+// running yet. nil is returned if the instance is already running. If
+// trackExits is set, the instance exits are tracked by the instanceExited
+// channel of the started watchdog.
+func startWatchdog(ttBin string, run running.InstanceCtx,
+	trackExits bool) (*startedWatchdog, error) {
+	appName := running.GetAppInstanceName(run)
+	// If an instance is already running don't try to start it again.
+	// For restarting an instance use tt restart command.
+	procStatus := process_utils.ProcessStatus(run.PIDFile)
+	if procStatus.Code ==
+		process_utils.ProcStateRunning.Code {
+		log.Infof("The instance %s (PID = %d) is already running.",
+			appName, procStatus.PID)
+		return nil, nil
+	}
+
+	log.Infof("Starting an instance [%s]...", appName)
+
+	newArgs := []string{"start", "--watchdog", appName}
+	if startWatch {
+		newArgs = append(newArgs, "--watch")
+	}
+
+	var pipeReader, pipeWriter *os.File
+	if trackExits {
+		var err error
+		if pipeReader, pipeWriter, err = os.Pipe(); err != nil {
+			return nil, err
+		}
+		newArgs = append(newArgs, "--exit-notify")
+	}
+
+	wdCmd := exec.Command(ttBin, newArgs...)
+	if pipeWriter != nil {
+		// The first extra file is the exitNotifyFd descriptor of the child.
+		wdCmd.ExtraFiles = []*os.File{pipeWriter}
+	}
+
+	err := wdCmd.Start()
+	if pipeWriter != nil {
+		pipeWriter.Close()
+	}
+	if err != nil {
+		if pipeReader != nil {
+			pipeReader.Close()
+		}
+		return nil, err
+	}
+
+	wd := startedWatchdog{run: run, cmd: wdCmd, exited: make(chan struct{})}
+	if pipeReader != nil {
+		wd.instanceExited = trackInstanceExits(pipeReader)
+	}
+	go func() {
+		wdCmd.Wait()
+		close(wd.exited)
+	}()
+	return &wd, nil
+}
+
+// waitReady waits for the instance of the started watchdog to be ready.
+func waitReady(wd *startedWatchdog, deadline time.Time) error {
+	exited := wd.exited
+	if wd.instanceExited != nil {
+		exited = wd.instanceExited
+	}
+	return running.WaitReady(&wd.run, deadline, exited)
+}
+
+// startWatchdogs starts watchdog processes for the instances that are not
+// running yet.
+func startWatchdogs(runningCtx running.RunningCtx,
+	trackExits bool) ([]startedWatchdog, error) {
+	ttBin, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	watchdogs := []startedWatchdog{}
+	for _, run := range runningCtx.Instances {
+		wd, err := startWatchdog(ttBin, run, trackExits)
+		if err != nil {
+			return watchdogs, err
+		}
+		if wd != nil {
+			watchdogs = append(watchdogs, *wd)
+		}
+	}
+	return watchdogs, nil
+}
+
+// startInParallel starts at most parallel instances at the same time. If the
+// readiness waiting is enabled, the next instance is started after one of the
+// started instances is ready.
+func startInParallel(runningCtx running.RunningCtx) error {
+	ttBin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	deadline := getReadyDeadline()
+	instances := runningCtx.Instances
+	errs := make([]error, len(instances))
+	running.ForEachInstance(instances, parallel, func(i int) {
+		wd, err := startWatchdog(ttBin, instances[i], startWait != 0)
+		if err != nil || wd == nil || startWait == 0 {
+			errs[i] = err
+			return
+		}
+		if errs[i] = waitReady(wd, deadline); errs[i] == nil {
+			log.Infof("The instance %s is ready.", running.GetAppInstanceName(wd.run))
+		}
+	})
+
+	failures := 0
+	for _, err := range errs {
+		if err != nil {
+			log.Error(err.Error())
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("failed to start %d instance(s)", failures)
+	}
+	return nil
+}
+
+// getReadyDeadline returns the deadline of waiting for the instances readiness.
+func getReadyDeadline() time.Time {
+	if startWait > 0 {
+		return time.Now().Add(startWait)
+	}
+	// Wait infinitely: the start timeout is controlled by systemd.
+	return time.Now().Add(foregroundReadyTimeout)
+}
+
+// superviseWatchdogs runs in foreground: it starts watchdogs of the instances,
+// forwards the stop signals to them and notifies systemd when all the instances
+// are ready. It returns when all the watchdogs exit.
+func superviseWatchdogs(runningCtx running.RunningCtx, notifier *running.SdNotifier) error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	watchdogs, err := startWatchdogs(runningCtx, true)
+	stopWatchdogs := func(sig os.Signal) {
+		for _, wd := range watchdogs {
+			wd.cmd.Process.Signal(sig)
+		}
+	}
+	if err != nil {
+		stopWatchdogs(syscall.SIGTERM)
+		return err
+	}
+
+	allExited := make(chan struct{})
+	firstExited := make(chan struct{})
+	go func() {
+		for i, wd := range watchdogs {
+			<-wd.exited
+			if i == 0 {
+				close(firstExited)
+			}
+		}
+		close(allExited)
+	}()
+	if len(watchdogs) == 0 {
+		return nil
+	}
+
+	readyErr := make(chan error, 1)
+	go func() {
+		deadline := getReadyDeadline()
+		for i := range watchdogs {
+			if err := waitReady(&watchdogs[i], deadline); err != nil {
+				readyErr <- err
+				return
+			}
+		}
+		notifier.Notify(fmt.Sprintf("READY=1\nSTATUS=%d instance(s) running", len(watchdogs)))
+		log.Infof("All instances are ready.")
+		notifier.KeepAlive(firstExited)
+	}()
+
+	var resultErr error
+	for {
+		select {
+		case sig := <-sigChan:
+			notifier.Notify("STOPPING=1")
+			stopWatchdogs(sig)
+		case err := <-readyErr:
+			notifier.Notify("STATUS=" + strings.SplitN(err.Error(), "\n", 2)[0])
+			stopWatchdogs(syscall.SIGTERM)
+			resultErr = err
+		case <-allExited:
+			return resultErr
+		}
+	}
+}
+
 // internalStartModule is a default start module.
 func internalStartModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	if !isConfigExist(cmdCtx) {
@@ -55,43 +328,52 @@ func internalStartModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	}
 
 	var runningCtx running.RunningCtx
-	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
-	if !watchdog {
-		ttBin, err := os.Executable()
-		if err != nil {
-			return err
+	if parallel < 0 {
+		return util.NewArgError("--parallel must not be negative")
+	}
+
+	if watchdog {
+		opts := running.StartOpts{Watch: startWatch}
+		if exitNotify {
+			// The descriptor must not be inherited by the instance process.
+			syscall.CloseOnExec(exitNotifyFd)
+			opts.ExitNotify = os.NewFile(exitNotifyFd, "exit-notify")
 		}
-		for _, run := range runningCtx.Instances {
-			appName := running.GetAppInstanceName(run)
-			// If an instance is already running don't try to start it again.
-			// For restarting an instance use tt restart command.
-			procStatus := process_utils.ProcessStatus(run.PIDFile)
-			if procStatus.Code ==
-				process_utils.ProcStateRunning.Code {
-				log.Infof("The instance %s (PID = %d) is already running.",
-					appName, procStatus.PID)
-				continue
-			}
+		return running.Start(cmdCtx, &runningCtx.Instances[0], opts)
+	}
 
-			log.Infof("Starting an instance [%s]...", appName)
+	if startForeground {
+		notifier := running.NewSdNotifier()
+		if len(runningCtx.Instances) == 1 {
+			// The watchdog of a single instance runs in the current process.
+			return running.Start(cmdCtx, &runningCtx.Instances[0],
+				running.StartOpts{Notifier: notifier, Watch: startWatch})
+		}
+		return superviseWatchdogs(runningCtx, notifier)
+	}
 
-			newArgs := []string{"start", "--watchdog", appName}
+	if parallel > 0 {
+		return startInParallel(runningCtx)
+	}
 
-			wdCmd := exec.Command(ttBin, newArgs...)
+	watchdogs, err := startWatchdogs(runningCtx, startWait > 0)
+	if err != nil {
+		return err
+	}
 
-			if err := wdCmd.Start(); err != nil {
+	if startWait > 0 {
+		deadline := getReadyDeadline()
+		for i, wd := range watchdogs {
+			if err := waitReady(&watchdogs[i], deadline); err != nil {
 				return err
 			}
+			log.Infof("The instance %s is ready.", running.GetAppInstanceName(wd.run))
 		}
-
-		return nil
 	}
 
-	if err := running.Start(cmdCtx, &runningCtx.Instances[0]); err != nil {
-		return err
-	}
 	return nil
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackInstanceExits(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+	defer writer.Close()
+
+	instanceExited := trackInstanceExits(reader)
+	select {
+	case <-instanceExited:
+		t.Fatal("the exit is reported before the notification")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = writer.Write([]byte{0})
+	require.NoError(t, err)
+	select {
+	case <-instanceExited:
+	case <-time.After(time.Second):
+		t.Fatal("the instance exit is not reported")
+	}
+
+	// Next notifications do not block the writer.
+	for i := 0; i < 100000; i++ {
+		_, err = writer.Write([]byte{0})
+		require.NoError(t, err)
+	}
+}
+
+func TestTrackInstanceExitsPipeClosed(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	instanceExited := trackInstanceExits(reader)
+	writer.Close()
+	select {
+	case <-instanceExited:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the watchdog exit is not reported")
+	}
+}
@@ -9,6 +9,11 @@ import (
 	"github.com/tarantool/tt/cli/status"
 )
 
+var (
+	// statusDetails enables printing of the instance environment and limits.
+	statusDetails bool
+)
+
 // NewStatusCmd creates status command.
 func NewStatusCmd() *cobra.Command {
 	var statusCmd = &cobra.Command{
@@ -31,6 +36,11 @@ func NewStatusCmd() *cobra.Command {
 		},
 	}
 
+	statusCmd.Flags().BoolVarP(&statusDetails, "details", "", false,
+		"print environment variables and resource limits of the instances")
+
+	addInstanceSelectorFlags(statusCmd)
+
 	return statusCmd
 }
 
@@ -41,10 +51,10 @@ func internalStatusModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 	}
 
 	var runningCtx running.RunningCtx
-	if err := running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
-	err := status.Status(runningCtx)
+	err := status.Status(runningCtx, statusDetails)
 	return err
 }
@@ -1,12 +1,15 @@
 package cmd
 
 import (
-	"github.com/apex/log"
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/tarantool/tt/cli/cmd/internal"
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/modules"
 	"github.com/tarantool/tt/cli/running"
+	"github.com/tarantool/tt/cli/util"
 )
 
 // NewStopCmd creates stop command.
@@ -31,6 +34,9 @@ func NewStopCmd() *cobra.Command {
 		},
 	}
 
+	addInstanceSelectorFlags(stopCmd)
+	addParallelFlag(stopCmd)
+
 	return stopCmd
 }
 
@@ -40,17 +46,32 @@ func internalStopModule(cmdCtx *cmdcontext.CmdCtx, args []string) error {
 		return errNoConfig
 	}
 
+	if parallel < 0 {
+		return util.NewArgError("--parallel must not be negative")
+	}
+
 	var runningCtx running.RunningCtx
-	var err error
-	if err = running.FillCtx(cliOpts, cmdCtx, &runningCtx, args); err != nil {
+	if err := fillSelectedCtx(cmdCtx, &runningCtx, args); err != nil {
 		return err
 	}
 
-	for _, run := range runningCtx.Instances {
-		if err = running.Stop(&run); err != nil {
-			log.Infof(err.Error())
-		}
+	// Instances are stopped one by one by default.
+	stopParallel := parallel
+	if stopParallel < 1 {
+		stopParallel = 1
 	}
+	instances := runningCtx.Instances
+	progress := running.NewStopProgress(os.Stdout, instances)
+	results := make([]running.StopResult, len(instances))
+	running.ForEachInstance(instances, stopParallel, func(i int) {
+		results[i] = running.Stop(&instances[i], func(phase running.StopPhase) {
+			progress.SetPhase(i, phase)
+		})
+		progress.Finish(i, results[i])
+	})
 
+	if failures := progress.PrintSummary(results); failures > 0 {
+		return fmt.Errorf("failed to stop %d instance(s)", failures)
+	}
 	return nil
 }
@@ -20,10 +20,17 @@ type Config struct {
 //     log_maxsize: num (MB)
 //     log_maxage: num (Days)
 //     log_maxbackups: num
+//     log_compress: gzip | zstd
+//     log_rotation: hourly | daily
+//     log_filename_pattern: pattern
+//     log_sink: file | syslog | journald
+//     log_syslog_facility: facility
+//     log_tag: tag
 //     restart_on_failure: bool
 //     bin_dir: path
 //     inc_dir: path
 //     tarantoolctl_layout: false
+//     crash_dir: path
 //   repo:
 //     rocks: path
 //     distfiles: path
@@ -63,6 +70,22 @@ type AppOpts struct {
 	// The default is to retain all old log files (though LogMaxAge may
 	// still cause them to get deleted).
 	LogMaxBackups int `mapstructure:"log_maxbackups" yaml:"log_maxbackups"`
+	// LogCompress is a compression of rotated log files: gzip or zstd.
+	// Rotated log files are not compressed by default.
+	LogCompress string `mapstructure:"log_compress" yaml:"log_compress"`
+	// LogRotation enables time-based log rotation: hourly or daily.
+	LogRotation string `mapstructure:"log_rotation" yaml:"log_rotation"`
+	// LogFilenamePattern is a pattern of rotated log file names. {name}, {ext}
+	// and {time} are replaced with the log file name without extension, its
+	// extension and the rotation time.
+	LogFilenamePattern string `mapstructure:"log_filename_pattern" yaml:"log_filename_pattern"`
+	// LogSink is a destination of instance logs: file, syslog or journald.
+	// The file is used by default.
+	LogSink string `mapstructure:"log_sink" yaml:"log_sink"`
+	// LogSyslogFacility is a syslog facility for syslog and journald sinks.
+	LogSyslogFacility string `mapstructure:"log_syslog_facility" yaml:"log_syslog_facility"`
+	// LogTag is a syslog tag for syslog and journald sinks.
+	LogTag string `mapstructure:"log_tag" yaml:"log_tag"`
 	// If the instance is started under the watchdog it should
 	// restart on if it crashes.
 	Restartable bool `mapstructure:"restart_on_failure" yaml:"restart_on_failure"`
@@ -84,6 +107,20 @@ type AppOpts struct {
 	// application sub-directories are not created for runtime artifacts like
 	// control socket, pid files and logs.
 	TarantoolctlLayout bool `mapstructure:"tarantoolctl_layout" yaml:"tarantoolctl_layout"`
+	// Env contains environment variables passed to all instances.
+	Env map[string]interface{} `mapstructure:"env" yaml:"env"`
+	// EnvFile is a path to the file with environment variables passed to
+	// all instances.
+	EnvFile string `mapstructure:"env_file" yaml:"env_file"`
+	// Rlimits contains resource limits of the instance processes: nofile,
+	// core, memlock and as.
+	Rlimits map[string]interface{} `mapstructure:"rlimits" yaml:"rlimits"`
+	// StopTimeout is the time in seconds given to an instance to stop
+	// gracefully before it is killed.
+	StopTimeout int `mapstructure:"stop_timeout" yaml:"stop_timeout"`
+	// CrashDir is a directory where the crash artifacts of the instances
+	// are stored.
+	CrashDir string `mapstructure:"crash_dir" yaml:"crash_dir"`
 }
 
 // TemplateOpts contains configuration for applications templates.
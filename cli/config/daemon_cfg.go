@@ -17,6 +17,12 @@ type DaemonCfg struct {
 //	log_maxsize: num (MB)
 //	log_maxage: num (Days)
 //	log_maxbackups: num
+//	log_compress: gzip | zstd
+//	log_rotation: hourly | daily
+//	log_filename_pattern: pattern
+//	log_sink: file | syslog | journald
+//	log_syslog_facility: facility
+//	log_tag: tag
 //	log_file: string (file name)
 //	listen_interface: string
 //	port: num
@@ -43,6 +49,22 @@ type DaemonOpts struct {
 	// The default is to retain all old log files (though LogMaxAge may
 	// still cause them to get deleted).
 	LogMaxBackups int `mapstructure:"log_maxbackups"`
+	// LogCompress is a compression of rotated log files: gzip or zstd.
+	// Rotated log files are not compressed by default.
+	LogCompress string `mapstructure:"log_compress"`
+	// LogRotation enables time-based log rotation: hourly or daily.
+	LogRotation string `mapstructure:"log_rotation"`
+	// LogFilenamePattern is a pattern of rotated log file names. {name}, {ext}
+	// and {time} are replaced with the log file name without extension, its
+	// extension and the rotation time.
+	LogFilenamePattern string `mapstructure:"log_filename_pattern"`
+	// LogSink is a destination of daemon logs: file, syslog or journald.
+	// The file is used by default.
+	LogSink string `mapstructure:"log_sink"`
+	// LogSyslogFacility is a syslog facility for syslog and journald sinks.
+	LogSyslogFacility string `mapstructure:"log_syslog_facility"`
+	// LogTag is a syslog tag for syslog and journald sinks.
+	LogTag string `mapstructure:"log_tag"`
 	// ListenInterface is a network interface the IP address
 	// should be found on to bind http server socket.
 	ListenInterface string `mapstructure:"listen_interface"`
@@ -14,6 +14,7 @@ import (
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/config"
 	"github.com/tarantool/tt/cli/modules"
+	"github.com/tarantool/tt/cli/ttlog"
 	"github.com/tarantool/tt/cli/util"
 )
 
@@ -48,6 +49,7 @@ const (
 	SnapPath      = "snap"
 	VinylPath     = "vinyl"
 	WalPath       = "wal"
+	CrashPath     = "crash"
 	logMaxSize    = 100
 	logMaxAge     = 8
 	logMaxBackups = 10
@@ -60,6 +62,7 @@ var (
 	VarVinylPath = filepath.Join(VarPath, VinylPath)
 	VarLogPath   = filepath.Join(VarPath, LogPath)
 	VarRunPath   = filepath.Join(VarPath, RunPath)
+	VarCrashPath = filepath.Join(VarPath, CrashPath)
 )
 
 var (
@@ -81,6 +84,7 @@ func getDefaultAppOpts() *config.AppOpts {
 		WalDir:             VarDataPath,
 		VinylDir:           VarDataPath,
 		MemtxDir:           VarDataPath,
+		CrashDir:           VarCrashPath,
 		BinDir:             BinPath,
 		IncludeDir:         IncludePath,
 		TarantoolctlLayout: false,
@@ -187,8 +191,10 @@ func updateCliOpts(cliOpts *config.CliOpts, configDir string) error {
 		{&cliOpts.App.WalDir, VarDataPath},
 		{&cliOpts.App.VinylDir, VarDataPath},
 		{&cliOpts.App.MemtxDir, VarDataPath},
+		{&cliOpts.App.CrashDir, VarCrashPath},
 		{&cliOpts.App.BinDir, BinPath},
 		{&cliOpts.App.IncludeDir, IncludePath},
+		{&cliOpts.App.EnvFile, ""},
 		{&cliOpts.Repo.Install, DistfilesPath},
 		{&cliOpts.Repo.Rocks, ""},
 	} {
@@ -221,6 +227,14 @@ func updateCliOpts(cliOpts *config.CliOpts, configDir string) error {
 	if cliOpts.App.LogMaxBackups == 0 {
 		cliOpts.App.LogMaxBackups = logMaxBackups
 	}
+	if err = ttlog.ValidateRotationOpts(cliOpts.App.LogCompress, cliOpts.App.LogRotation,
+		cliOpts.App.LogFilenamePattern); err != nil {
+		return err
+	}
+	if err = ttlog.ValidateSinkOpts(cliOpts.App.LogSink,
+		cliOpts.App.LogSyslogFacility); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -322,6 +336,15 @@ func GetDaemonOpts(configurePath string) (*config.DaemonOpts, error) {
 			VarLogPath)
 	}
 
+	if err = ttlog.ValidateRotationOpts(cfg.DaemonConfig.LogCompress,
+		cfg.DaemonConfig.LogRotation, cfg.DaemonConfig.LogFilenamePattern); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon configuration: %s", err)
+	}
+	if err = ttlog.ValidateSinkOpts(cfg.DaemonConfig.LogSink,
+		cfg.DaemonConfig.LogSyslogFacility); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon configuration: %s", err)
+	}
+
 	return cfg.DaemonConfig, nil
 }
 
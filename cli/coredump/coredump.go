@@ -26,40 +26,6 @@ func findFile(fileName string) (string, error) {
 	return filePath, err
 }
 
-// Pack packs coredump into a tar.gz archive.
-func Pack(coreName string) error {
-	corePath, err := findFile(coreName)
-	if err != nil {
-		return fmt.Errorf("there was some problem packing archive. "+
-			"Error: '%v'", err)
-	}
-	content, err := util.ReadEmbedFile(corescripts, "scripts/tarabart.sh")
-	if err != nil {
-		return fmt.Errorf("there was some problem packing archive. "+
-			"Error: '%v'", err)
-	}
-	cmd := exec.Command("bash", "-s")
-	StdinPipe, err := cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("there was some problem packing archive. "+
-			"Error: '%v'", err)
-	}
-	cmd.Env = append(cmd.Env, "COREFILE_ENV="+corePath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Start()
-	StdinPipe.Write([]byte(content))
-	StdinPipe.Close()
-	err = cmd.Wait()
-	if err == nil {
-		log.Infof("Core was successfully packed.")
-	} else {
-		err = fmt.Errorf("there was some problem packing archive. "+
-			"Error: '%v'", err)
-	}
-	return err
-}
-
 // Unpack unpacks a tar.gz archive.
 func Unpack(tarName string) error {
 	tarPath, err := findFile(tarName)
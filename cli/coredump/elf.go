@@ -0,0 +1,318 @@
+package coredump
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// ntFile is the type of the core note with the mapped files.
+	ntFile = 0x46494c45
+	// ntGnuBuildID is the type of the ELF note with the build ID.
+	ntGnuBuildID = 3
+	// prpsinfoPidOffset is the offset of pr_pid in the 64-bit NT_PRPSINFO note.
+	prpsinfoPidOffset = 24
+	// prpsinfoFnameOffset is the offset of pr_fname in the 64-bit NT_PRPSINFO note.
+	prpsinfoFnameOffset = 40
+	// prpsinfoFnameSize is the size of pr_fname in the NT_PRPSINFO note.
+	prpsinfoFnameSize = 16
+	// maxNoteSize limits the size of the notes read from the memory of the
+	// core.
+	maxNoteSize = 1 << 16
+)
+
+// elfNote is an ELF note.
+type elfNote struct {
+	// name is the note owner name.
+	name string
+	// noteType is the note type.
+	noteType uint32
+	// desc is the note descriptor.
+	desc []byte
+}
+
+// fileMapping is a file mapped into the memory of the crashed process.
+type fileMapping struct {
+	// start is the start address of the mapping.
+	start uint64
+	// end is the end address of the mapping.
+	end uint64
+	// offset is the file offset of the mapping in pages.
+	offset uint64
+	// path is the mapped file path.
+	path string
+}
+
+// coreInfo describes the core file.
+type coreInfo struct {
+	// pid is the PID of the crashed process.
+	pid int
+	// signal is the signal the process was terminated by.
+	signal int
+	// command is the command name of the process, up to 15 characters.
+	command string
+	// mappings contains the files mapped into the process memory.
+	mappings []fileMapping
+	// buildIDs contains the build IDs of the mapped ELF files found in the
+	// process memory by the file path.
+	buildIDs map[string]string
+}
+
+// parseNotes parses the ELF notes from the data.
+func parseNotes(data []byte, order binary.ByteOrder) []elfNote {
+	// The sizes are aligned in uint64: the uint32 sum wraps around for sizes
+	// close to the maximum value.
+	align := func(size uint64) uint64 { return (size + 3) &^ 3 }
+	notes := []elfNote{}
+	for len(data) >= 12 {
+		nameSize := uint64(order.Uint32(data[0:4]))
+		descSize := uint64(order.Uint32(data[4:8]))
+		noteType := order.Uint32(data[8:12])
+		data = data[12:]
+		if align(nameSize)+align(descSize) > uint64(len(data)) {
+			break
+		}
+		name := strings.TrimRight(string(data[:nameSize]), "\x00")
+		data = data[align(nameSize):]
+		notes = append(notes, elfNote{name: name, noteType: noteType,
+			desc: data[:descSize]})
+		data = data[align(descSize):]
+	}
+	return notes
+}
+
+// readWord reads the word of the ELF class size.
+func readWord(data []byte, class elf.Class, order binary.ByteOrder) uint64 {
+	if class == elf.ELFCLASS64 {
+		return order.Uint64(data)
+	}
+	return uint64(order.Uint32(data))
+}
+
+// parseFileNote parses the NT_FILE core note with the mapped files.
+func parseFileNote(desc []byte, class elf.Class, order binary.ByteOrder) ([]fileMapping, error) {
+	wordSize := 4
+	if class == elf.ELFCLASS64 {
+		wordSize = 8
+	}
+	if len(desc) < 2*wordSize {
+		return nil, fmt.Errorf("NT_FILE note is truncated")
+	}
+	count := readWord(desc, class, order)
+	desc = desc[2*wordSize:]
+	if count > uint64(len(desc)/(3*wordSize)) {
+		return nil, fmt.Errorf("NT_FILE note is truncated")
+	}
+	mappings := make([]fileMapping, count)
+	for i := range mappings {
+		mappings[i].start = readWord(desc, class, order)
+		mappings[i].end = readWord(desc[wordSize:], class, order)
+		mappings[i].offset = readWord(desc[2*wordSize:], class, order)
+		desc = desc[3*wordSize:]
+	}
+	paths := strings.Split(string(desc), "\x00")
+	if len(paths) < len(mappings) {
+		return nil, fmt.Errorf("NT_FILE note is truncated")
+	}
+	for i := range mappings {
+		mappings[i].path = paths[i]
+	}
+	return mappings, nil
+}
+
+// findBuildID returns the GNU build ID from the notes.
+func findBuildID(notes []elfNote) string {
+	for _, note := range notes {
+		if note.name == "GNU" && note.noteType == ntGnuBuildID {
+			return hex.EncodeToString(note.desc)
+		}
+	}
+	return ""
+}
+
+// coreMemory reads the memory of the crashed process dumped to the core.
+type coreMemory struct {
+	// file is the core ELF file.
+	file *elf.File
+}
+
+// ReadAt reads the memory at the address. Only dumped memory can be read.
+func (memory coreMemory) ReadAt(buf []byte, addr int64) (int, error) {
+	for _, prog := range memory.file.Progs {
+		if prog.Type != elf.PT_LOAD || uint64(addr) < prog.Vaddr ||
+			uint64(addr) >= prog.Vaddr+prog.Filesz {
+			continue
+		}
+		offset := uint64(addr) - prog.Vaddr
+		size := uint64(len(buf))
+		if offset+size > prog.Filesz {
+			size = prog.Filesz - offset
+		}
+		n, err := prog.ReadAt(buf[:size], int64(offset))
+		if err == nil && n < len(buf) {
+			err = io.EOF
+		}
+		return n, err
+	}
+	return 0, fmt.Errorf("address %#x is not dumped", addr)
+}
+
+// readMappedBuildID reads the build ID of the ELF file mapped at the address
+// from the process memory. The ELF header and notes are usually in the first
+// page of the file that is dumped by default. Section headers are not mapped,
+// so the program headers are parsed here.
+func readMappedBuildID(memory coreMemory, start uint64) string {
+	header := make([]byte, 64)
+	if _, err := memory.ReadAt(header, int64(start)); err != nil ||
+		string(header[:len(elf.ELFMAG)]) != elf.ELFMAG {
+		return ""
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if elf.Data(header[elf.EI_DATA]) == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+	class := elf.Class(header[elf.EI_CLASS])
+	// Offsets of e_phoff, e_phentsize and e_phnum, and offsets of p_offset
+	// and p_filesz in the program header.
+	phoffPos, phentsizePos, phnumPos, offPos, fileszPos := 28, 42, 44, 4, 16
+	wordSize := uint64(4)
+	if class == elf.ELFCLASS64 {
+		phoffPos, phentsizePos, phnumPos, offPos, fileszPos = 32, 54, 56, 8, 32
+		wordSize = 8
+	}
+	phoff := readWord(header[phoffPos:], class, order)
+	phentsize := uint64(order.Uint16(header[phentsizePos:]))
+	phnum := uint64(order.Uint16(header[phnumPos:]))
+	// The program header must contain p_offset and p_filesz.
+	if phentsize < uint64(fileszPos)+wordSize || phnum*phentsize > maxNoteSize {
+		return ""
+	}
+	progs := make([]byte, phnum*phentsize)
+	if _, err := memory.ReadAt(progs, int64(start+phoff)); err != nil {
+		return ""
+	}
+	for i := uint64(0); i < phnum; i++ {
+		prog := progs[i*phentsize : (i+1)*phentsize]
+		if elf.ProgType(order.Uint32(prog)) != elf.PT_NOTE {
+			continue
+		}
+		offset := readWord(prog[offPos:], class, order)
+		size := readWord(prog[fileszPos:], class, order)
+		if size > maxNoteSize {
+			continue
+		}
+		// The file offset of the note equals the offset from the mapping start.
+		data := make([]byte, size)
+		if _, err := memory.ReadAt(data, int64(start+offset)); err != nil {
+			continue
+		}
+		if buildID := findBuildID(parseNotes(data, order)); buildID != "" {
+			return buildID
+		}
+	}
+	return ""
+}
+
+// parseCore parses the core file notes.
+func parseCore(corePath string) (coreInfo, error) {
+	info := coreInfo{buildIDs: map[string]string{}}
+	file, err := elf.Open(corePath)
+	if err != nil {
+		return info, fmt.Errorf("%s is not an ELF file: %s", corePath, err)
+	}
+	defer file.Close()
+	if file.Type != elf.ET_CORE {
+		return info, fmt.Errorf("%s is not a core file", corePath)
+	}
+
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return info, err
+		}
+		for _, note := range parseNotes(data, file.ByteOrder) {
+			if note.name != "CORE" {
+				continue
+			}
+			switch note.noteType {
+			case uint32(elf.NT_PRSTATUS):
+				if info.signal == 0 && len(note.desc) >= 4 {
+					info.signal = int(int32(file.ByteOrder.Uint32(note.desc)))
+				}
+			case uint32(elf.NT_PRPSINFO):
+				if file.Class == elf.ELFCLASS64 &&
+					len(note.desc) >= prpsinfoFnameOffset+prpsinfoFnameSize {
+					info.pid = int(int32(file.ByteOrder.Uint32(
+						note.desc[prpsinfoPidOffset:])))
+					fname := note.desc[prpsinfoFnameOffset : prpsinfoFnameOffset+
+						prpsinfoFnameSize]
+					info.command = string(bytes.TrimRight(fname, "\x00"))
+				}
+			case ntFile:
+				if info.mappings, err = parseFileNote(note.desc, file.Class,
+					file.ByteOrder); err != nil {
+					return info, err
+				}
+			}
+		}
+	}
+
+	memory := coreMemory{file: file}
+	for _, mapping := range info.mappings {
+		if mapping.offset != 0 {
+			continue
+		}
+		if _, found := info.buildIDs[mapping.path]; found {
+			continue
+		}
+		if buildID := readMappedBuildID(memory, mapping.start); buildID != "" {
+			info.buildIDs[mapping.path] = buildID
+		}
+	}
+	return info, nil
+}
+
+// getBuildID returns the GNU build ID of the ELF file.
+func getBuildID(path string) (string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_NOTE {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return "", err
+		}
+		if buildID := findBuildID(parseNotes(data, file.ByteOrder)); buildID != "" {
+			return buildID, nil
+		}
+	}
+	return "", nil
+}
+
+// isELF checks if the file is an ELF file.
+func isELF(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return string(magic) == elf.ELFMAG
+}
@@ -0,0 +1,373 @@
+package coredump
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/tarantool/tt/cli/util"
+	"github.com/tarantool/tt/cli/version"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// manifestFile is the name of the archive manifest.
+	manifestFile = "manifest.yml"
+	// osReleaseFile contains the operating system identification.
+	osReleaseFile = "/etc/os-release"
+	// deletedSuffix is added to the mapped file path if the file is removed.
+	deletedSuffix = " (deleted)"
+)
+
+// File kinds in the manifest.
+const (
+	fileKindCore       = "core"
+	fileKindExecutable = "executable"
+	fileKindLibrary    = "library"
+	fileKindInfo       = "info"
+)
+
+// PackOpts contains options of the coredump packing.
+type PackOpts struct {
+	// Executable is a path to the tarantool executable that produced the core.
+	// It is searched using the core notes if not set.
+	Executable string
+	// TarantoolExecutable is the tarantool executable used by tt. It is
+	// checked if the executable from the core is not found.
+	TarantoolExecutable string
+	// Compression is the compression of the archive, gzip by default.
+	Compression util.CompressionOpts
+}
+
+// ManifestFile describes a file of the coredump archive.
+type ManifestFile struct {
+	// Path is the file path in the archive.
+	Path string `yaml:"path"`
+	// Source is the original file path.
+	Source string `yaml:"source,omitempty"`
+	// Kind is the file kind: core, executable, library or info.
+	Kind string `yaml:"kind"`
+	// Size is the file size.
+	Size int64 `yaml:"size"`
+	// SHA256 is the file checksum.
+	SHA256 string `yaml:"sha256"`
+	// BuildID is the GNU build ID of the ELF file.
+	BuildID string `yaml:"build_id,omitempty"`
+	// CoreBuildID is the build ID of the file mapped into the crashed process.
+	CoreBuildID string `yaml:"core_build_id,omitempty"`
+}
+
+// Manifest describes the coredump archive.
+type Manifest struct {
+	// Created is the archive creation time.
+	Created time.Time `yaml:"created"`
+	// CoreTime is the core file modification time.
+	CoreTime time.Time `yaml:"core_time"`
+	// Hostname is the name of the host the core is packed on.
+	Hostname string `yaml:"hostname"`
+	// PID is the PID of the crashed process.
+	PID int `yaml:"pid,omitempty"`
+	// Signal is the signal the process was terminated by.
+	Signal int `yaml:"signal,omitempty"`
+	// Command is the command name of the crashed process.
+	Command string `yaml:"command,omitempty"`
+	// TarantoolVersion is the output of tarantool --version.
+	TarantoolVersion string `yaml:"tarantool_version"`
+	// TtVersion is the version of tt that packed the core.
+	TtVersion string `yaml:"tt_version"`
+	// Files contains the archive files.
+	Files []ManifestFile `yaml:"files"`
+}
+
+// packedFile is a file to be packed.
+type packedFile struct {
+	// source is the path to the file on the host, empty for generated files.
+	source string
+	// content is the content of the generated file.
+	content []byte
+	// entry is the manifest entry of the file.
+	entry ManifestFile
+}
+
+// trimDeleted removes the deleted file mark from the mapped file path.
+func trimDeleted(path string) string {
+	return strings.TrimSuffix(path, deletedSuffix)
+}
+
+// getMappedExecutable returns the path of the executable mapped into the
+// crashed process memory.
+func getMappedExecutable(info coreInfo) string {
+	for _, mapping := range info.mappings {
+		if info.command != "" && strings.HasPrefix(filepath.Base(mapping.path), info.command) {
+			return mapping.path
+		}
+	}
+	if len(info.mappings) > 0 {
+		return info.mappings[0].path
+	}
+	return ""
+}
+
+// findExecutable finds the tarantool executable that produced the core. The
+// explicitly passed executable is used as is. Otherwise, the executable mapped
+// into the process and the tarantool executable used by tt are checked, the
+// one with the build ID recorded in the core is selected.
+func findExecutable(info coreInfo, opts PackOpts) (string, error) {
+	mapped := getMappedExecutable(info)
+	coreBuildID := info.buildIDs[mapped]
+	if opts.Executable != "" {
+		if !isELF(opts.Executable) {
+			return "", fmt.Errorf("%s is not an ELF file", opts.Executable)
+		}
+		if buildID, _ := getBuildID(opts.Executable); coreBuildID != "" &&
+			buildID != coreBuildID {
+			log.Warnf("The build ID of %s does not match the core: %s != %s.",
+				opts.Executable, buildID, coreBuildID)
+		}
+		return opts.Executable, nil
+	}
+
+	for _, candidate := range []string{trimDeleted(mapped), opts.TarantoolExecutable} {
+		if candidate == "" || !isELF(candidate) {
+			continue
+		}
+		if coreBuildID == "" {
+			return candidate, nil
+		}
+		if buildID, _ := getBuildID(candidate); buildID == coreBuildID {
+			return candidate, nil
+		}
+	}
+	if coreBuildID != "" {
+		return "", fmt.Errorf("the tarantool executable with build ID %s is not found, "+
+			"specify it with --executable", coreBuildID)
+	}
+	return "", fmt.Errorf("the tarantool executable is not found, " +
+		"specify it with --executable")
+}
+
+// collectLibraries returns the shared libraries mapped into the crashed
+// process memory, except the executable. libthread_db is added if it is
+// found next to the libraries, it is needed to debug threads.
+func collectLibraries(info coreInfo, executable string) []packedFile {
+	libs := []packedFile{}
+	added := map[string]bool{executable: true, getMappedExecutable(info): true}
+	add := func(path string, coreBuildID string) bool {
+		if added[path] || !isELF(path) {
+			return false
+		}
+		added[path] = true
+		buildID, _ := getBuildID(path)
+		if coreBuildID != "" && buildID != coreBuildID {
+			log.Warnf("The build ID of %s does not match the core: %s != %s.", path,
+				buildID, coreBuildID)
+		}
+		libs = append(libs, packedFile{source: path, entry: ManifestFile{
+			Path: strings.TrimPrefix(path, "/"), Kind: fileKindLibrary,
+			BuildID: buildID, CoreBuildID: coreBuildID,
+		}})
+		return true
+	}
+
+	libDirs := []string{}
+	for _, mapping := range info.mappings {
+		path := mapping.path
+		if !filepath.IsAbs(path) || strings.HasSuffix(path, deletedSuffix) ||
+			strings.HasPrefix(path, "/dev/") || strings.HasPrefix(path, "/memfd:") {
+			continue
+		}
+		if !added[path] {
+			libDirs = append(libDirs, filepath.Dir(path))
+		}
+		add(path, info.buildIDs[path])
+	}
+	for _, dir := range libDirs {
+		for _, name := range []string{"libthread_db.so.1", "libthread_db.so"} {
+			if add(filepath.Join(dir, name), "") {
+				break
+			}
+		}
+	}
+	return libs
+}
+
+// writeFile writes the file to the archive computing its checksum.
+func writeFile(writer *tar.Writer, prefix string, file *packedFile) error {
+	var reader io.Reader
+	var modTime time.Time
+	if file.source != "" {
+		source, err := os.Open(file.source)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+		stat, err := source.Stat()
+		if err != nil {
+			return err
+		}
+		reader, file.entry.Size, modTime = source, stat.Size(), stat.ModTime()
+	} else {
+		reader, file.entry.Size, modTime = bytes.NewReader(file.content),
+			int64(len(file.content)), time.Now()
+	}
+
+	header := tar.Header{
+		Name:    filepath.Join(prefix, file.entry.Path),
+		Mode:    0644,
+		Size:    file.entry.Size,
+		ModTime: modTime,
+	}
+	if file.entry.Kind == fileKindExecutable {
+		header.Mode = 0755
+	}
+	if err := writer.WriteHeader(&header); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.CopyN(writer, io.TeeReader(reader, hash), file.entry.Size); err != nil {
+		return fmt.Errorf("can't pack %s: %s", file.source, err)
+	}
+	file.entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// writeArchive streams the files and the manifest to the compressed tar archive.
+func writeArchive(archivePath string, prefix string, files []packedFile,
+	manifest *Manifest, compression util.CompressionOpts) error {
+	archive, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	compressWriter, err := util.NewCompressWriter(archive, compression)
+	if err != nil {
+		return err
+	}
+	tarWriter := tar.NewWriter(compressWriter)
+
+	for i := range files {
+		if err = writeFile(tarWriter, prefix, &files[i]); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, files[i].entry)
+	}
+	content, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	// The manifest is written last, because it contains the checksums.
+	if err = writeFile(tarWriter, prefix, &packedFile{content: content,
+		entry: ManifestFile{Path: manifestFile, Kind: fileKindInfo}}); err != nil {
+		return err
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	if err = compressWriter.Close(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Pack packs the coredump, the tarantool executable and the shared libraries
+// mapped into the crashed process into a tar.gz, tar.xz or tar.zst archive in
+// the current directory. The archive layout is expected by tt coredump inspect.
+func Pack(coreName string, opts PackOpts) error {
+	if err := util.ValidateCompression(opts.Compression); err != nil {
+		return err
+	}
+	corePath, err := findFile(coreName)
+	if err != nil {
+		return fmt.Errorf("there was some problem packing archive. "+
+			"Error: '%v'", err)
+	}
+	coreStat, err := os.Stat(corePath)
+	if err != nil {
+		return err
+	}
+	info, err := parseCore(corePath)
+	if err != nil {
+		return err
+	}
+	executable, err := findExecutable(info, opts)
+	if err != nil {
+		return err
+	}
+	versionOutput, err := exec.Command(executable, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("can't get the version of %s: %s", executable, err)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "hostname"
+	}
+	hostname, _, _ = strings.Cut(hostname, ".")
+	pid := "N"
+	if info.pid != 0 {
+		pid = fmt.Sprint(info.pid)
+	}
+	archiveName := fmt.Sprintf("tarantool-core-%s-%s-%s", pid,
+		coreStat.ModTime().Format("200601021504"), hostname)
+
+	exeBuildID, _ := getBuildID(executable)
+	files := []packedFile{
+		{source: corePath, entry: ManifestFile{Path: "coredump", Kind: fileKindCore}},
+		{source: executable, entry: ManifestFile{Path: "tarantool",
+			Kind: fileKindExecutable, BuildID: exeBuildID,
+			CoreBuildID: info.buildIDs[getMappedExecutable(info)]}},
+		{content: versionOutput, entry: ManifestFile{Path: "version", Kind: fileKindInfo}},
+	}
+	if _, err := os.Stat(osReleaseFile); err == nil {
+		files = append(files, packedFile{source: osReleaseFile, entry: ManifestFile{
+			Path: strings.TrimPrefix(osReleaseFile, "/"), Kind: fileKindInfo}})
+	}
+	files = append(files, collectLibraries(info, executable)...)
+	for i := range files {
+		if files[i].source != "" {
+			files[i].entry.Source = files[i].source
+		}
+	}
+
+	checklist := strings.Builder{}
+	for _, file := range files {
+		if file.source != "" {
+			checklist.WriteString(file.source + "\n")
+		}
+	}
+	files = append(files, packedFile{content: []byte(checklist.String()),
+		entry: ManifestFile{Path: "checklist", Kind: fileKindInfo}})
+
+	versionLines := strings.SplitN(string(versionOutput), "\n", 2)
+	manifest := Manifest{
+		Created:          time.Now(),
+		CoreTime:         coreStat.ModTime(),
+		Hostname:         hostname,
+		PID:              info.pid,
+		Signal:           info.signal,
+		Command:          info.command,
+		TarantoolVersion: strings.TrimSpace(versionLines[0]),
+		TtVersion:        version.GetVersion(true, false),
+	}
+
+	archivePath := archiveName + ".tar" + opts.Compression.Ext()
+	if err = writeArchive(archivePath, archiveName, files, &manifest,
+		opts.Compression); err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("there was some problem packing archive. "+
+			"Error: '%v'", err)
+	}
+	log.Infof("Core was successfully packed.")
+	log.Infof("The resulting archive is located here: %s", archivePath)
+	return nil
+}
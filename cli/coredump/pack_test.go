@@ -0,0 +1,210 @@
+package coredump
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// makeNote builds an ELF note.
+func makeNote(name string, noteType uint32, desc []byte) []byte {
+	pad := func(data []byte) []byte {
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+		return data
+	}
+	note := make([]byte, 12)
+	binary.LittleEndian.PutUint32(note, uint32(len(name)+1))
+	binary.LittleEndian.PutUint32(note[4:], uint32(len(desc)))
+	binary.LittleEndian.PutUint32(note[8:], noteType)
+	note = append(note, pad(append([]byte(name), 0))...)
+	return append(note, pad(desc)...)
+}
+
+func TestParseFileNote(t *testing.T) {
+	words := []uint64{2, 4096, 0x1000, 0x2000, 0, 0x3000, 0x4000, 1}
+	desc := []byte{}
+	for _, word := range words {
+		desc = binary.LittleEndian.AppendUint64(desc, word)
+	}
+	desc = append(desc, []byte("/usr/bin/tarantool\x00/lib/libc.so.6\x00")...)
+	data := append(makeNote("GNU", ntGnuBuildID, []byte{0xab, 0xcd}),
+		makeNote("CORE", ntFile, desc)...)
+
+	notes := parseNotes(data, binary.LittleEndian)
+	require.Len(t, notes, 2)
+	assert.Equal(t, "abcd", findBuildID(notes))
+	assert.Equal(t, "CORE", notes[1].name)
+
+	mappings, err := parseFileNote(notes[1].desc, elf.ELFCLASS64, binary.LittleEndian)
+	require.NoError(t, err)
+	assert.Equal(t, []fileMapping{
+		{start: 0x1000, end: 0x2000, offset: 0, path: "/usr/bin/tarantool"},
+		{start: 0x3000, end: 0x4000, offset: 1, path: "/lib/libc.so.6"},
+	}, mappings)
+
+	_, err = parseFileNote(desc[:40], elf.ELFCLASS64, binary.LittleEndian)
+	assert.Error(t, err)
+}
+
+func TestParseNotesCrafted(t *testing.T) {
+	valid := makeNote("GNU", ntGnuBuildID, []byte{0xab, 0xcd})
+	for _, sizes := range [][2]uint32{
+		// The aligned name size wraps around to zero in uint32.
+		{0xfffffffe, 0},
+		{0xffffffff, 0},
+		{4, 0xfffffffd},
+		{0xfffffffc, 0xfffffffc},
+	} {
+		note := make([]byte, 16)
+		binary.LittleEndian.PutUint32(note, sizes[0])
+		binary.LittleEndian.PutUint32(note[4:], sizes[1])
+		notes := parseNotes(append(append([]byte{}, valid...), note...), binary.LittleEndian)
+		// The valid note is parsed, the crafted one is skipped.
+		require.Len(t, notes, 1, sizes)
+		assert.Equal(t, "abcd", findBuildID(notes))
+	}
+}
+
+func TestReadMappedBuildIDCrafted(t *testing.T) {
+	const loadOffset, loadAddr, loadSize = 120, 0x1000, 64
+	// ELF64 core header with a single PT_LOAD program header.
+	core := make([]byte, loadOffset+loadSize)
+	copy(core, elf.ELFMAG)
+	core[elf.EI_CLASS], core[elf.EI_DATA], core[elf.EI_VERSION] = 2, 1, 1
+	binary.LittleEndian.PutUint16(core[16:], uint16(elf.ET_CORE))
+	binary.LittleEndian.PutUint16(core[18:], uint16(elf.EM_X86_64))
+	binary.LittleEndian.PutUint32(core[20:], 1)
+	binary.LittleEndian.PutUint64(core[32:], 64)
+	binary.LittleEndian.PutUint16(core[52:], 64)
+	binary.LittleEndian.PutUint16(core[54:], 56)
+	binary.LittleEndian.PutUint16(core[56:], 1)
+	prog := core[64:]
+	binary.LittleEndian.PutUint32(prog, uint32(elf.PT_LOAD))
+	binary.LittleEndian.PutUint64(prog[8:], loadOffset)
+	binary.LittleEndian.PutUint64(prog[16:], loadAddr)
+	binary.LittleEndian.PutUint64(prog[32:], loadSize)
+	binary.LittleEndian.PutUint64(prog[40:], loadSize)
+
+	// The mapped ELF header declares a program header too short to contain
+	// the note offset and size.
+	mapped := core[loadOffset:]
+	copy(mapped, elf.ELFMAG)
+	mapped[elf.EI_CLASS], mapped[elf.EI_DATA] = 2, 1
+	binary.LittleEndian.PutUint16(mapped[54:], 1)
+	binary.LittleEndian.PutUint16(mapped[56:], 1)
+
+	file, err := elf.NewFile(bytes.NewReader(core))
+	require.NoError(t, err)
+	assert.Equal(t, "", readMappedBuildID(coreMemory{file: file}, loadAddr))
+}
+
+// dumpCore kills a sleep process with SIGSEGV and returns the dumped core
+// path. The test is skipped if the core is not dumped to the working directory.
+func dumpCore(t *testing.T, dir string) string {
+	cmd := exec.Command("sh", "-c", "ulimit -c unlimited && exec sleep 10")
+	cmd.Dir = dir
+	require.NoError(t, cmd.Start())
+	time.Sleep(200 * time.Millisecond)
+	require.NoError(t, cmd.Process.Signal(syscall.SIGSEGV))
+	cmd.Wait()
+
+	cores, _ := filepath.Glob(filepath.Join(dir, "core*"))
+	if len(cores) == 0 {
+		t.Skip("the core is not dumped to the working directory")
+	}
+	return cores[0]
+}
+
+func TestPack(t *testing.T) {
+	dir := t.TempDir()
+	corePath := dumpCore(t, dir)
+	sleepPath, err := exec.LookPath("sleep")
+	require.NoError(t, err)
+	sleepPath, err = filepath.EvalSymlinks(sleepPath)
+	require.NoError(t, err)
+
+	info, err := parseCore(corePath)
+	require.NoError(t, err)
+	assert.Equal(t, int(syscall.SIGSEGV), info.signal)
+	assert.Equal(t, "sleep", info.command)
+	assert.NotZero(t, info.pid)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	require.NoError(t, Pack(corePath, PackOpts{}))
+	archives, err := filepath.Glob(filepath.Join(dir, "tarantool-core-*.tar.gz"))
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+
+	archive, err := os.Open(archives[0])
+	require.NoError(t, err)
+	defer archive.Close()
+	gzipReader, err := gzip.NewReader(archive)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+
+	prefix := strings.TrimSuffix(filepath.Base(archives[0]), ".tar.gz")
+	checksums := map[string]string{}
+	manifest := Manifest{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(header.Name, prefix+"/"))
+		name := strings.TrimPrefix(header.Name, prefix+"/")
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		if name == manifestFile {
+			require.NoError(t, yaml.Unmarshal(content, &manifest))
+			continue
+		}
+		hash := sha256.Sum256(content)
+		checksums[name] = hex.EncodeToString(hash[:])
+	}
+
+	assert.Equal(t, info.pid, manifest.PID)
+	assert.Equal(t, int(syscall.SIGSEGV), manifest.Signal)
+	assert.NotEmpty(t, manifest.TarantoolVersion)
+	kinds := map[string]string{}
+	for _, file := range manifest.Files {
+		kinds[file.Path] = file.Kind
+		assert.Equal(t, checksums[file.Path], file.SHA256, file.Path)
+	}
+	assert.Len(t, checksums, len(manifest.Files))
+	assert.Equal(t, fileKindCore, kinds["coredump"])
+	assert.Equal(t, fileKindExecutable, kinds["tarantool"])
+	assert.Equal(t, fileKindInfo, kinds["version"])
+	assert.Equal(t, fileKindInfo, kinds["checklist"])
+	for _, file := range manifest.Files {
+		if file.Kind == fileKindExecutable {
+			assert.Equal(t, sleepPath, file.Source)
+			assert.Equal(t, file.CoreBuildID, file.BuildID)
+		}
+		if file.Kind == fileKindLibrary && file.CoreBuildID != "" {
+			assert.Equal(t, file.CoreBuildID, file.BuildID, file.Path)
+		}
+	}
+}
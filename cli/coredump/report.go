@@ -0,0 +1,403 @@
+package coredump
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sectionMarker prefixes the gdb output sections.
+	sectionMarker = "==tt-report-section=="
+	// signatureFramesCount is the number of frames in the crash signature.
+	signatureFramesCount = 3
+	// signalHandlerFrame is the gdb frame of the signal handler call.
+	signalHandlerFrame = "<signal handler called>"
+)
+
+// Report output formats.
+const (
+	ReportFormatJSON     = "json"
+	ReportFormatMarkdown = "markdown"
+)
+
+// Sections of the gdb output.
+const (
+	sectionCore      = "core"
+	sectionThreads   = "threads"
+	sectionBacktrace = "backtrace"
+	sectionRegisters = "registers"
+	sectionFiber     = "fiber"
+)
+
+var (
+	// signalRe matches the gdb message about the core signal.
+	signalRe = regexp.MustCompile(`Program terminated with signal (\w+)`)
+	// threadRe matches the info threads line.
+	threadRe = regexp.MustCompile(`^(\*)?\s*(\d+)\s+.*?\(LWP (\d+)\)(?:\s+"([^"]*)")?`)
+	// threadHeaderRe matches the thread backtrace header.
+	threadHeaderRe = regexp.MustCompile(`^Thread (\d+) \(.*\):$`)
+	// frameRe matches the backtrace frame.
+	frameRe = regexp.MustCompile(`^#(\d+)\s+(?:(0x[0-9a-fA-F]+) in )?(.*)$`)
+	// fiberRe matches the current fiber line.
+	fiberRe = regexp.MustCompile(`^fiber (\d+) (.*)$`)
+	// crashMachineryRe matches the functions of the signal and abort machinery
+	// that are skipped in the crash signature.
+	crashMachineryRe = regexp.MustCompile(`^(__GI_)?(raise|abort|gsignal|` +
+		`(__)?pthread_kill.*|__restore_rt|__assert_fail.*|sig_fatal_cb|` +
+		`crash_signal_cb|print_backtrace)$`)
+)
+
+// Frame is a backtrace frame.
+type Frame struct {
+	// Level is the frame number.
+	Level int `json:"level"`
+	// Address is the frame program counter.
+	Address string `json:"address,omitempty"`
+	// Function is the function name.
+	Function string `json:"function"`
+	// Location is the source file and line of the frame.
+	Location string `json:"location,omitempty"`
+	// Library is the shared library of the frame function.
+	Library string `json:"library,omitempty"`
+}
+
+// Thread is a thread of the crashed process.
+type Thread struct {
+	// ID is the gdb thread number.
+	ID int `json:"id"`
+	// LWP is the thread ID in the system.
+	LWP int `json:"lwp,omitempty"`
+	// Name is the thread name.
+	Name string `json:"name,omitempty"`
+	// Crashed is set for the thread that received the signal.
+	Crashed bool `json:"crashed"`
+	// Backtrace contains the thread frames.
+	Backtrace []Frame `json:"backtrace"`
+}
+
+// Register is a register value of the crashed thread.
+type Register struct {
+	// Name is the register name.
+	Name string `json:"name"`
+	// Value is the raw register value.
+	Value string `json:"value"`
+}
+
+// Fiber describes the fiber running at the moment of the crash.
+type Fiber struct {
+	// ID is the fiber ID.
+	ID int `json:"id"`
+	// Name is the fiber name.
+	Name string `json:"name"`
+}
+
+// CrashReport is a report of the crash built from the coredump.
+type CrashReport struct {
+	// Version is the tarantool version.
+	Version string `json:"version,omitempty"`
+	// Signal is the signal the process was terminated by.
+	Signal string `json:"signal,omitempty"`
+	// Signature identifies the crash, the crashes with the same signal and
+	// the crashing frames have the same signature.
+	Signature string `json:"signature"`
+	// SignatureFrames contains the crashing frames used for the signature.
+	SignatureFrames []string `json:"signature_frames"`
+	// Fiber is the fiber running at the moment of the crash.
+	Fiber *Fiber `json:"fiber,omitempty"`
+	// Threads contains the threads of the process.
+	Threads []Thread `json:"threads"`
+	// Registers contains the registers of the crashed thread.
+	Registers []Register `json:"registers"`
+}
+
+// splitSections splits the gdb output by the section markers.
+func splitSections(output string) map[string][]string {
+	sections := map[string][]string{}
+	section := sectionCore
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if strings.HasPrefix(line, sectionMarker) {
+			section = strings.TrimPrefix(line, sectionMarker)
+			continue
+		}
+		sections[section] = append(sections[section], line)
+	}
+	return sections
+}
+
+// parseFrame parses the backtrace frame line.
+func parseFrame(line string) (Frame, bool) {
+	match := frameRe.FindStringSubmatch(line)
+	if match == nil {
+		return Frame{}, false
+	}
+	frame := Frame{Address: match[2]}
+	frame.Level, _ = strconv.Atoi(match[1])
+	rest := match[3]
+	if index := strings.LastIndex(rest, " at "); index != -1 &&
+		!strings.Contains(rest[index:], ")") {
+		frame.Location = rest[index+len(" at "):]
+		rest = rest[:index]
+	} else if index := strings.LastIndex(rest, " from "); index != -1 &&
+		!strings.Contains(rest[index:], ")") {
+		frame.Library = rest[index+len(" from "):]
+		rest = rest[:index]
+	}
+	// The arguments are cut off.
+	if index := strings.Index(rest, " ("); index != -1 && rest != signalHandlerFrame {
+		rest = rest[:index]
+	}
+	frame.Function = strings.TrimSpace(rest)
+	return frame, true
+}
+
+// parseBacktraces parses the output of thread apply all bt.
+func parseBacktraces(lines []string) []Thread {
+	threads := []Thread{}
+	for _, line := range lines {
+		if match := threadHeaderRe.FindStringSubmatch(line); match != nil {
+			thread := Thread{Backtrace: []Frame{}}
+			thread.ID, _ = strconv.Atoi(match[1])
+			threads = append(threads, thread)
+			continue
+		}
+		if len(threads) == 0 {
+			continue
+		}
+		if frame, ok := parseFrame(line); ok {
+			last := &threads[len(threads)-1]
+			last.Backtrace = append(last.Backtrace, frame)
+		}
+	}
+	return threads
+}
+
+// applyThreadsInfo fills the thread names and marks the crashed thread using
+// the info threads output.
+func applyThreadsInfo(threads []Thread, lines []string) {
+	for _, line := range lines {
+		match := threadRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(match[2])
+		for i := range threads {
+			if threads[i].ID != id {
+				continue
+			}
+			threads[i].Crashed = match[1] == "*"
+			threads[i].LWP, _ = strconv.Atoi(match[3])
+			threads[i].Name = match[4]
+		}
+	}
+}
+
+// parseRegisters parses the info registers output.
+func parseRegisters(lines []string) []Register {
+	registers := []Register{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "0x") {
+			continue
+		}
+		registers = append(registers, Register{Name: fields[0], Value: fields[1]})
+	}
+	return registers
+}
+
+// getSignatureFrames returns the crashing frames of the backtrace. The frames
+// of the signal handler and the abort machinery are skipped.
+func getSignatureFrames(backtrace []Frame) []string {
+	start := 0
+	for i, frame := range backtrace {
+		if frame.Function == signalHandlerFrame {
+			start = i + 1
+		}
+	}
+	frames := []string{}
+	for _, frame := range backtrace[start:] {
+		if len(frames) == 0 && crashMachineryRe.MatchString(frame.Function) {
+			continue
+		}
+		function := frame.Function
+		if function == "??" && frame.Library != "" {
+			function = "??@" + filepath.Base(frame.Library)
+		}
+		frames = append(frames, function)
+		if len(frames) == signatureFramesCount {
+			break
+		}
+	}
+	return frames
+}
+
+// getSignature returns the crash signature: a hash of the signal and the
+// crashing frames.
+func getSignature(signal string, frames []string) string {
+	hash := sha256.Sum256([]byte(signal + "|" + strings.Join(frames, "|")))
+	return hex.EncodeToString(hash[:8])
+}
+
+// buildReport builds the crash report from the gdb output.
+func buildReport(output string) CrashReport {
+	sections := splitSections(output)
+	report := CrashReport{Registers: parseRegisters(sections[sectionRegisters])}
+	for _, line := range sections[sectionCore] {
+		if match := signalRe.FindStringSubmatch(line); match != nil {
+			report.Signal = match[1]
+		}
+	}
+	report.Threads = parseBacktraces(sections[sectionBacktrace])
+	applyThreadsInfo(report.Threads, sections[sectionThreads])
+	for _, line := range sections[sectionFiber] {
+		if match := fiberRe.FindStringSubmatch(line); match != nil {
+			id, _ := strconv.Atoi(match[1])
+			report.Fiber = &Fiber{ID: id, Name: match[2]}
+		}
+	}
+
+	report.SignatureFrames = []string{}
+	for _, thread := range report.Threads {
+		if thread.Crashed {
+			report.SignatureFrames = getSignatureFrames(thread.Backtrace)
+		}
+	}
+	report.Signature = getSignature(report.Signal, report.SignatureFrames)
+	return report
+}
+
+// getGdbArgs returns the gdb arguments to collect the report data from the
+// unpacked coredump.
+func getGdbArgs(coreFolder string) []string {
+	args := []string{"-batch", "-n", filepath.Join(coreFolder, "tarantool")}
+	for _, command := range []string{
+		"set width 0",
+		"set height 0",
+		"set pagination off",
+		"set sysroot " + coreFolder,
+		"add-auto-load-safe-path " + coreFolder,
+		"set auto-load libthread-db on",
+		"core " + filepath.Join(coreFolder, "coredump"),
+		`echo ` + sectionMarker + sectionThreads + `\n`,
+		"info threads",
+		`echo ` + sectionMarker + sectionBacktrace + `\n`,
+		"thread apply all bt",
+		`echo ` + sectionMarker + sectionRegisters + `\n`,
+		"info registers",
+		`echo ` + sectionMarker + sectionFiber + `\n`,
+		`printf "fiber %d %s\n", cord_ptr->fiber->fid, cord_ptr->fiber->name`,
+	} {
+		args = append(args, "-ex", command)
+	}
+	return args
+}
+
+// writeMarkdown writes the crash report in the Markdown format.
+func writeMarkdown(out io.Writer, report CrashReport) {
+	fmt.Fprintf(out, "# Crash report\n\n")
+	if report.Version != "" {
+		fmt.Fprintf(out, "- Version: %s\n", report.Version)
+	}
+	if report.Signal != "" {
+		fmt.Fprintf(out, "- Signal: %s\n", report.Signal)
+	}
+	fmt.Fprintf(out, "- Signature: `%s`\n", report.Signature)
+	if report.Fiber != nil {
+		fmt.Fprintf(out, "- Fiber: %s (%d)\n", report.Fiber.Name, report.Fiber.ID)
+	}
+	if len(report.SignatureFrames) > 0 {
+		fmt.Fprintf(out, "- Crashing frames: `%s`\n",
+			strings.Join(report.SignatureFrames, "` < `"))
+	}
+
+	for _, thread := range report.Threads {
+		title := fmt.Sprintf("Thread %d", thread.ID)
+		if thread.Name != "" {
+			title += fmt.Sprintf(" \"%s\"", thread.Name)
+		}
+		if thread.LWP != 0 {
+			title += fmt.Sprintf(" (LWP %d)", thread.LWP)
+		}
+		if thread.Crashed {
+			title += " - crashed"
+		}
+		fmt.Fprintf(out, "\n## %s\n\n```\n", title)
+		for _, frame := range thread.Backtrace {
+			fmt.Fprintf(out, "#%-3d %s", frame.Level, frame.Function)
+			if frame.Location != "" {
+				fmt.Fprintf(out, " at %s", frame.Location)
+			} else if frame.Library != "" {
+				fmt.Fprintf(out, " from %s", frame.Library)
+			}
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "```\n")
+	}
+
+	if len(report.Registers) > 0 {
+		fmt.Fprintf(out, "\n## Registers\n\n| Register | Value |\n| --- | --- |\n")
+		for _, register := range report.Registers {
+			fmt.Fprintf(out, "| %s | %s |\n", register.Name, register.Value)
+		}
+	}
+}
+
+// WriteReport writes the crash report in the format: json or markdown.
+func WriteReport(out io.Writer, report CrashReport, format string) error {
+	switch format {
+	case ReportFormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case ReportFormatMarkdown:
+		writeMarkdown(out, report)
+		return nil
+	}
+	return fmt.Errorf("unknown report format %q, %s or %s is expected", format,
+		ReportFormatJSON, ReportFormatMarkdown)
+}
+
+// Report runs gdb in the batch mode against the unpacked coredump and builds
+// a report with the backtraces of all threads, the registers of the crashed
+// thread, the current fiber and the crash signature.
+func Report(coreFolder string) (CrashReport, error) {
+	folder, err := findFile(coreFolder)
+	if err != nil {
+		return CrashReport{}, fmt.Errorf("there was some problem inspecting coredump. "+
+			"Error: '%v'", err)
+	}
+	version, err := os.ReadFile(filepath.Join(folder, "version"))
+	if err != nil {
+		return CrashReport{}, fmt.Errorf("%s is not an unpacked coredump archive: %s",
+			folder, err)
+	}
+	if _, err = exec.LookPath("gdb"); err != nil {
+		return CrashReport{}, fmt.Errorf("gdb is not installed or not found in the PATH")
+	}
+
+	// gdb exits with an error if the last command fails, for example, the fiber
+	// command without the debug info. The report is built from the sections
+	// printed before that.
+	output, err := exec.Command("gdb", getGdbArgs(folder)...).CombinedOutput()
+	report := buildReport(string(output))
+	if len(report.Threads) == 0 {
+		if err != nil {
+			return report, fmt.Errorf("gdb failed: %s\n%s", err, output)
+		}
+		return report, fmt.Errorf("gdb has not produced any backtraces:\n%s", output)
+	}
+	report.Version, _, _ = strings.Cut(strings.TrimSpace(string(version)), "\n")
+	return report, nil
+}
@@ -0,0 +1,156 @@
+package coredump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gdbTestOutput is a gdb output for the report test. The tarantool crash
+// handler is called on SIGSEGV and aborts the process.
+const gdbTestOutput = `[New LWP 3357]
+[New LWP 3356]
+Core was generated by ` + "`tarantool init.lua'" + `.
+Program terminated with signal SIGABRT, Aborted.
+#0  __pthread_kill_implementation (no_tid=0, signo=6) at ./nptl/pthread_kill.c:44
+44	./nptl/pthread_kill.c: No such file or directory.
+[Current thread is 1 (Thread 0x7f7e (LWP 3356))]
+==tt-report-section==threads
+  Id   Target Id                          Frame
+* 1    Thread 0x7f7e (LWP 3356) "tarantool" __pthread_kill_implementation () at x.c:44
+  2    Thread 0x7f7d (LWP 3357) "coio"      0x00007f7e in epoll_wait () from /lib/libc.so.6
+==tt-report-section==backtrace
+
+Thread 2 (Thread 0x7f7d (LWP 3357)):
+#0  0x00007f7e in epoll_wait () from /lib/libc.so.6
+#1  0x000055d5 in epoll_poll (loop=0x55d5, timeout=59.9) at ev_epoll.c:155
+
+Thread 1 (Thread 0x7f7e (LWP 3356)):
+#0  __pthread_kill_implementation (no_tid=0, signo=6) at ./nptl/pthread_kill.c:44
+#1  0x00007f7e in __GI_raise (sig=sig@entry=6) at ../sysdeps/posix/raise.c:26
+#2  0x00007f7e in __GI_abort () at ./stdlib/abort.c:79
+#3  0x000055d5 in crash_signal_cb (signo=11, siginfo=0x0, context=0x0) at crash.c:10
+#4  <signal handler called>
+#5  0x000055d5 in space_execute_dml (space=0x0, txn=0x1, request=0x2) at space.c:617
+#6  0x000055d5 in box_process1 (request=0x2, result=0x0) at box.cc:2346
+#7  0x000055d5 in ?? () from /usr/lib/libluajit.so
+#8  0x000055d5 in lj_BC_FUNCC ()
+==tt-report-section==registers
+rax            0x0                 0
+rip            0x7f7e8c5a9e2c      0x7f7e8c5a9e2c <__pthread_kill_implementation+284>
+eflags         0x246               [ PF ZF IF ]
+==tt-report-section==fiber
+fiber 103 console/unix/:
+`
+
+func TestBuildReport(t *testing.T) {
+	report := buildReport(gdbTestOutput)
+	assert.Equal(t, "SIGABRT", report.Signal)
+	require.Len(t, report.Threads, 2)
+
+	coio := report.Threads[0]
+	assert.Equal(t, 2, coio.ID)
+	assert.Equal(t, 3357, coio.LWP)
+	assert.Equal(t, "coio", coio.Name)
+	assert.False(t, coio.Crashed)
+	assert.Equal(t, []Frame{
+		{Level: 0, Address: "0x00007f7e", Function: "epoll_wait", Library: "/lib/libc.so.6"},
+		{Level: 1, Address: "0x000055d5", Function: "epoll_poll", Location: "ev_epoll.c:155"},
+	}, coio.Backtrace)
+
+	main := report.Threads[1]
+	assert.Equal(t, 1, main.ID)
+	assert.Equal(t, "tarantool", main.Name)
+	assert.True(t, main.Crashed)
+	require.Len(t, main.Backtrace, 9)
+	assert.Equal(t, Frame{Level: 0, Function: "__pthread_kill_implementation",
+		Location: "./nptl/pthread_kill.c:44"}, main.Backtrace[0])
+	assert.Equal(t, signalHandlerFrame, main.Backtrace[4].Function)
+	assert.Equal(t, "lj_BC_FUNCC", main.Backtrace[8].Function)
+
+	assert.Equal(t, []string{"space_execute_dml", "box_process1", "??@libluajit.so"},
+		report.SignatureFrames)
+	assert.Equal(t, []Register{{"rax", "0x0"}, {"rip", "0x7f7e8c5a9e2c"},
+		{"eflags", "0x246"}}, report.Registers)
+	assert.Equal(t, &Fiber{ID: 103, Name: "console/unix/:"}, report.Fiber)
+	assert.Len(t, report.Signature, 16)
+}
+
+// installFakeGdb installs a gdb script printing the output and exiting with
+// the code to the PATH.
+func installFakeGdb(t *testing.T, output string, exitCode int) {
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "gdb.out"), []byte(output), 0644))
+	script := fmt.Sprintf("#!/bin/sh\ncat %s\nexit %d\n",
+		filepath.Join(binDir, "gdb.out"), exitCode)
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "gdb"), []byte(script), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestReportGdbFailure(t *testing.T) {
+	coreFolder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(coreFolder, "version"),
+		[]byte("Tarantool 2.11.1-0-g96877bd35\n"), 0644))
+
+	// The fiber command fails without the debug info, gdb exits with an error.
+	output, _, _ := strings.Cut(gdbTestOutput, sectionMarker+sectionFiber)
+	installFakeGdb(t, output+sectionMarker+sectionFiber+
+		"\nNo symbol table is loaded.  Use the \"file\" command.\n", 1)
+	report, err := Report(coreFolder)
+	require.NoError(t, err)
+	assert.Equal(t, "Tarantool 2.11.1-0-g96877bd35", report.Version)
+	assert.Len(t, report.Threads, 2)
+	assert.Len(t, report.Registers, 3)
+	assert.Nil(t, report.Fiber)
+
+	// Nothing is parsed.
+	installFakeGdb(t, "gdb: unrecognized option\n", 1)
+	_, err = Report(coreFolder)
+	assert.ErrorContains(t, err, "gdb failed")
+}
+
+func TestGetSignatureFrames(t *testing.T) {
+	// Abort machinery is skipped without the signal handler frame.
+	backtrace := []Frame{{Function: "__GI_raise"}, {Function: "__GI_abort"},
+		{Function: "__assert_fail_base"}, {Function: "__assert_fail"},
+		{Function: "txn_commit"}, {Function: "box_process1"}}
+	assert.Equal(t, []string{"txn_commit", "box_process1"}, getSignatureFrames(backtrace))
+
+	// The signature does not depend on addresses and arguments, only on the
+	// signal and the crashing functions.
+	assert.Equal(t, getSignature("SIGSEGV", []string{"a", "b"}),
+		getSignature("SIGSEGV", []string{"a", "b"}))
+	assert.NotEqual(t, getSignature("SIGSEGV", []string{"a", "b"}),
+		getSignature("SIGABRT", []string{"a", "b"}))
+	assert.NotEqual(t, getSignature("SIGSEGV", []string{"a", "b"}),
+		getSignature("SIGSEGV", []string{"a", "c"}))
+}
+
+func TestWriteReport(t *testing.T) {
+	report := buildReport(gdbTestOutput)
+	report.Version = "Tarantool 2.11.0-0-g247a9a4"
+
+	buf := bytes.Buffer{}
+	require.NoError(t, WriteReport(&buf, report, ReportFormatJSON))
+	decoded := CrashReport{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	buf.Reset()
+	require.NoError(t, WriteReport(&buf, report, ReportFormatMarkdown))
+	markdown := buf.String()
+	assert.Contains(t, markdown, "- Signal: SIGABRT\n")
+	assert.Contains(t, markdown, "- Signature: `"+report.Signature+"`\n")
+	assert.Contains(t, markdown, "## Thread 1 \"tarantool\" (LWP 3356) - crashed\n")
+	assert.Contains(t, markdown, "#5   space_execute_dml at space.c:617\n")
+	assert.Contains(t, markdown, "| rip | 0x7f7e8c5a9e2c |\n")
+
+	assert.Error(t, WriteReport(&buf, report, "xml"))
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/tarantool/tt/cli/ttlog"
 )
 
+// defaultDaemonLogTag is a syslog tag of the daemon logs.
+const defaultDaemonLogTag = "tt-daemon"
+
 // DaemonCtx contains information for running an daemon instance.
 type DaemonCtx struct {
 	// Port is a port number to be used for daemon http server.
@@ -31,6 +34,18 @@ type DaemonCtx struct {
 	// calendar days due to daylight savings, leap seconds, etc. The
 	// default is not to remove old log files based on age.
 	LogMaxAge int
+	// LogCompress is a compression of rotated log files.
+	LogCompress string
+	// LogRotation is a time-based log rotation period.
+	LogRotation string
+	// LogFilenamePattern is a pattern of rotated log file names.
+	LogFilenamePattern string
+	// LogSink is a destination of logs: file, syslog or journald.
+	LogSink string
+	// LogSyslogFacility is a syslog facility for syslog and journald sinks.
+	LogSyslogFacility string
+	// LogTag is a syslog tag for syslog and journald sinks.
+	LogTag string
 	// ListenInterface is a network interface the IP address
 	// should be found on to bind http server socket.
 	ListenInterface string
@@ -39,22 +54,38 @@ type DaemonCtx struct {
 // NewDaemonCtx creates the DaemonCtx context.
 func NewDaemonCtx(opts *config.DaemonOpts) *DaemonCtx {
 	return &DaemonCtx{
-		PIDFile:       filepath.Join(opts.RunDir, opts.PIDFile),
-		Port:          opts.Port,
-		LogPath:       filepath.Join(opts.LogDir, opts.LogFile),
-		LogMaxAge:     opts.LogMaxAge,
-		LogMaxBackups: opts.LogMaxBackups,
-		LogMaxSize:    opts.LogMaxSize,
+		PIDFile:            filepath.Join(opts.RunDir, opts.PIDFile),
+		Port:               opts.Port,
+		LogPath:            filepath.Join(opts.LogDir, opts.LogFile),
+		LogMaxAge:          opts.LogMaxAge,
+		LogMaxBackups:      opts.LogMaxBackups,
+		LogMaxSize:         opts.LogMaxSize,
+		LogCompress:        opts.LogCompress,
+		LogRotation:        opts.LogRotation,
+		LogFilenamePattern: opts.LogFilenamePattern,
+		LogSink:            opts.LogSink,
+		LogSyslogFacility:  opts.LogSyslogFacility,
+		LogTag:             opts.LogTag,
 	}
 }
 
 // RunHTTPServerOnBackground starts http daemon process.
 func RunHTTPServerOnBackground(daemonCtx *DaemonCtx) error {
 	logOpts := ttlog.LoggerOpts{
-		Filename:   daemonCtx.LogPath,
-		MaxSize:    daemonCtx.LogMaxSize,
-		MaxBackups: daemonCtx.LogMaxBackups,
-		MaxAge:     daemonCtx.LogMaxAge,
+		Filename:        daemonCtx.LogPath,
+		MaxSize:         daemonCtx.LogMaxSize,
+		MaxBackups:      daemonCtx.LogMaxBackups,
+		MaxAge:          daemonCtx.LogMaxAge,
+		Compress:        daemonCtx.LogCompress,
+		RotationPeriod:  daemonCtx.LogRotation,
+		FilenamePattern: daemonCtx.LogFilenamePattern,
+		Sink:            daemonCtx.LogSink,
+		SyslogFacility:  daemonCtx.LogSyslogFacility,
+		Tag:             daemonCtx.LogTag,
+		Source:          "Daemon",
+	}
+	if logOpts.Tag == "" {
+		logOpts.Tag = defaultDaemonLogTag
 	}
 
 	args := []string{"daemon", "start"}
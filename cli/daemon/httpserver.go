@@ -139,7 +139,7 @@ func (httpServer *HTTPServer) Stop() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), httpServer.timeout)
 	if err = httpServer.srv.Shutdown(ctx); err != nil {
-		httpServer.logger.Printf(`HTTP server shutdown error: "%v"`, err)
+		httpServer.logger.Errorf(`HTTP server shutdown error: "%v"`, err)
 	}
 	cancel()
 
@@ -36,6 +36,8 @@ type Process struct {
 	logger *ttlog.Logger
 	// pidFileName is a path to the process pid file.
 	pidFileName string
+	// pidFile is the open pid file holding the lock while the process is running.
+	pidFile *os.File
 	// cmdPath is a path to the command the process should perform.
 	cmdPath string
 	// cmdArgs are arguments to the command the process should perform.
@@ -96,11 +98,13 @@ func (process *Process) Start() error {
 	if process.IsChild() {
 		process.logger = ttlog.NewLogger(&process.logOpts)
 
-		if err := process_utils.CreatePIDFile(process.pidFileName); err != nil {
+		pidFile, err := process_utils.CreatePIDFile(process.pidFileName)
+		if err != nil {
 			return err
 		}
+		process.pidFile = pidFile
 
-		process.logger.Println(startDaemonMsg)
+		process.logger.Infof(startDaemonMsg)
 		process.worker.SetLogger(process.logger)
 
 		go process.worker.Start(process.cmdPath)
@@ -124,19 +128,22 @@ func (process *Process) Start() error {
 
 // Stop stops the process.
 func (process *Process) Stop() {
-	process.logger.Println(stopDaemonMsg)
+	process.logger.Infof(stopDaemonMsg)
 	done := make(chan error, 1)
 	go func() {
 		done <- process.worker.Stop()
 	}()
 
-	os.Remove(process.pidFileName)
+	process_utils.RemovePIDFile(process.pidFileName)
+	if process.pidFile != nil {
+		process.pidFile.Close()
+	}
 	_ = os.Unsetenv(process.DaemonTag)
 
 	err := <-done
 
 	if err != nil {
-		process.logger.Println(err.Error())
+		process.logger.Errorf("%v", err)
 		os.Exit(1)
 	}
 	os.Exit(0)
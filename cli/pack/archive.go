@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/apex/log"
@@ -34,7 +35,7 @@ func (packer *archivePacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 
 	log.Debugf("The package structure is created in: %s", bundlePath)
 
-	tgzSuffix, err := getTgzSuffix()
+	tgzSuffix, err := getTgzSuffix(packCtx.Compression)
 	if err != nil {
 		return err
 	}
@@ -55,6 +56,12 @@ func (packer *archivePacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 		}
 	}
 
+	if packCtx.Reproducible {
+		if err = normalizeTree(bundlePath, packCtx.SourceDate); err != nil {
+			return err
+		}
+	}
+
 	log.Infof("Creating tarball.")
 
 	currentDir, err := os.Getwd()
@@ -63,7 +70,8 @@ func (packer *archivePacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	}
 	tarName = filepath.Join(currentDir, tarName)
 
-	err = WriteTgzArchive(bundlePath, tarName)
+	err = WriteCompressedTarArchive(bundlePath, tarName, packCtx.Compression,
+		packCtx.Reproducible)
 	if err != nil {
 		if err := os.Remove(tarName); err != nil {
 			log.Warnf("Failed to remove a tarball file %s: %s", tarName, err)
@@ -71,7 +79,7 @@ func (packer *archivePacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 		return err
 	}
 	log.Infof("Bundle is packed successfully to %s.", tarName)
-	return nil
+	return copySbom(bundlePath, tarName, packCtx)
 }
 
 // generateVersionLuaFile generates VERSION.lua file (for cartridge-cli compatibility).
@@ -114,7 +122,14 @@ func generateVersionFile(bundlePath string, cmdCtx *cmdcontext.CmdCtx, packCtx *
 		log.Warnf("Can't process rocks manifest file. Dependency information can't be "+
 			"shipped to the resulting package: %s", err)
 	} else {
-		for rockName, versions := range rocksVersionsMap {
+		rockNames := make([]string, 0, len(rocksVersionsMap))
+		for rockName := range rocksVersionsMap {
+			rockNames = append(rockNames, rockName)
+		}
+		// Sort the rocks to get the same file for the same rocks.
+		sort.Strings(rockNames)
+		for _, rockName := range rockNames {
+			versions := rocksVersionsMap[rockName]
 			if rockName != packCtx.Name {
 				rockLine := fmt.Sprintf("%s=%s", rockName, versions[len(versions)-1])
 				versionFileLines = append(versionFileLines, rockLine)
@@ -136,12 +151,13 @@ func generateVersionFile(bundlePath string, cmdCtx *cmdcontext.CmdCtx, packCtx *
 	return nil
 }
 
-// getTgzSuffix returns suffix for a tarball.
-func getTgzSuffix() (string, error) {
+// getTgzSuffix returns suffix for a tarball: .tar.gz, .tar.xz or .tar.zst
+// depending on the compression.
+func getTgzSuffix(compression util.CompressionOpts) (string, error) {
 	arch, err := util.GetArch()
 	if err != nil {
 		return "", err
 	}
-	tgzSuffix := strings.Join([]string{"", arch, "tar", "gz"}, ".")
+	tgzSuffix := strings.Join([]string{"", arch, "tar"}, ".") + compression.Ext()
 	return tgzSuffix, nil
 }
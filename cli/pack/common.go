@@ -2,6 +2,7 @@ package pack
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -160,9 +161,10 @@ func prepareBundle(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	// Copy all apps to a temp directory step.
 	for _, appInfo := range appList {
 		if packCtx.CartridgeCompat {
-			err = copyAppSrc(appInfo.Location, appInfo.Name, basePath)
+			err = copyAppSrc(appInfo.Location, appInfo.Name, basePath, packCtx.Exclude)
 		} else {
-			err = copyAppSrc(appInfo.Location, filepath.Base(appInfo.Location), basePath)
+			err = copyAppSrc(appInfo.Location, filepath.Base(appInfo.Location), basePath,
+				packCtx.Exclude)
 		}
 		if err != nil {
 			return "", err
@@ -198,9 +200,45 @@ func prepareBundle(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	if err != nil {
 		return "", err
 	}
+
+	// The SBOM lists the built rocks, so it is generated only for the final bundle.
+	if buildRocks && packCtx.Sbom != "" {
+		if err = genSbom(cmdCtx, packCtx, cliOpts, basePath); err != nil {
+			return "", err
+		}
+	}
 	return basePath, nil
 }
 
+// ListBundleFiles writes the list of the files that would be packed to the
+// writer. The rocks are not built.
+func ListBundleFiles(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts,
+	writer io.Writer) error {
+	bundlePath, err := prepareBundle(cmdCtx, packCtx, opts, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := os.RemoveAll(bundlePath)
+		if err != nil {
+			log.Warnf("Failed to remove a temporary directory %s: %s",
+				bundlePath, err.Error())
+		}
+	}()
+
+	return filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(bundlePath, path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, filepath.ToSlash(relPath))
+		return err
+	})
+}
+
 // createPackageStructure initializes a standard package structure in passed directory.
 func createPackageStructure(destPath string, cartridgeCompat bool) error {
 	basePaths := []string{destPath}
@@ -230,7 +268,9 @@ func createPackageStructure(destPath string, cartridgeCompat bool) error {
 }
 
 // copyAppSrc copies a source file or directory to the directory, that will be packed.
-func copyAppSrc(appPath string, appName string, packagePath string) error {
+// The files matched by the patterns from the .packignore file of the application
+// and the excludes patterns are skipped.
+func copyAppSrc(appPath string, appName string, packagePath string, excludes []string) error {
 	// In compat mode there must be only one application, so there will be no symlinks.
 	// However, without the compat flag, encountering symlink must change appName.
 	previousPath := appPath
@@ -244,10 +284,18 @@ func copyAppSrc(appPath string, appName string, packagePath string) error {
 		appName = filepath.Base(appPath)
 	}
 
-	if _, err = os.Stat(appPath); err != nil {
+	appInfo, err := os.Stat(appPath)
+	if err != nil {
 		return err
 	}
 
+	matcher := &ignoreMatcher{}
+	if appInfo.IsDir() {
+		if matcher, err = loadIgnoreMatcher(appPath, excludes); err != nil {
+			return err
+		}
+	}
+
 	// Copying application.
 	err = copy.Copy(appPath, filepath.Join(packagePath, appName), copy.Options{
 		Skip: func(src string) (bool, error) {
@@ -263,7 +311,15 @@ func copyAppSrc(appPath string, appName string, packagePath string) error {
 			if strings.HasPrefix(src, ".git") || strings.Contains(src, "/.git") {
 				return true, nil
 			}
-			return false, nil
+
+			relPath, err := filepath.Rel(appPath, src)
+			if err != nil || relPath == "." {
+				return false, err
+			}
+			if relPath == packIgnoreFileName {
+				return true, nil
+			}
+			return matcher.match(relPath, fileInfo.IsDir()), nil
 		},
 	})
 	if err != nil {
@@ -578,7 +634,7 @@ func getPackageName(packCtx *PackCtx, opts *config.CliOpts, suffix string,
 	if addVersion {
 		var separator string
 		switch packCtx.Type {
-		case Tgz, Rpm:
+		case Tgz, Rpm, Oci, Selfextract:
 			separator = "-"
 		case Deb:
 			separator = "_"
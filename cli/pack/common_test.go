@@ -85,7 +85,7 @@ func TestCopyAppSrc(t *testing.T) {
 	require.NoErrorf(t, err, "failed to create test directories: %v", err)
 
 	for _, name := range appLocations {
-		err = copyAppSrc(filepath.Join(testDir, name), name, testCopyDir)
+		err = copyAppSrc(filepath.Join(testDir, name), name, testCopyDir, nil)
 		require.NoErrorf(t, err, "failed to copy an app: %v", err)
 	}
 
@@ -2,15 +2,137 @@ package pack
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// cpioNewcMagic is the magic of the SVR4 cpio format without checksums.
+	cpioNewcMagic = "070701"
+	// cpioTrailer is the name of the last cpio entry.
+	cpioTrailer = "TRAILER!!!"
+	// cpioAlignment is the alignment of cpio headers and file data.
+	cpioAlignment = 4
+	// cpioMaxNameSize is the maximum size of the entry name and the symlink
+	// target (PATH_MAX).
+	cpioMaxNameSize = 4096
 )
 
-// packCpio runs cpio command and packs the passed directory into the new package.
-func packCpio(relPaths []string, resFileName, packageFilesDir string) error {
+// cpioHeader contains the fields of the SVR4 cpio entry header.
+type cpioHeader struct {
+	ino      uint32
+	mode     uint32
+	uid      uint32
+	gid      uint32
+	nlink    uint32
+	mtime    int64
+	fileSize int64
+	devMajor uint32
+	devMinor uint32
+	name     string
+}
+
+// writeCpioPadding aligns the written data.
+func writeCpioPadding(writer io.Writer, size int64) error {
+	if padding := (cpioAlignment - size%cpioAlignment) % cpioAlignment; padding != 0 {
+		_, err := writer.Write(make([]byte, padding))
+		return err
+	}
+	return nil
+}
+
+// writeCpioHeader writes the padded cpio entry header and name.
+func writeCpioHeader(writer io.Writer, header cpioHeader) error {
+	written, err := fmt.Fprintf(writer,
+		"%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%s\x00",
+		cpioNewcMagic, header.ino, header.mode, header.uid, header.gid, header.nlink,
+		uint32(header.mtime), uint32(header.fileSize), header.devMajor, header.devMinor, 0, 0,
+		len(header.name)+1, 0, header.name)
+	if err != nil {
+		return err
+	}
+	return writeCpioPadding(writer, int64(written))
+}
+
+// getCpioHeader returns the cpio header of the file. If reproducible is set,
+// the owner, the device and the inode do not depend on the file system.
+func getCpioHeader(fileInfo fs.FileInfo, relPath string, index int,
+	reproducible bool) (cpioHeader, error) {
+	sysFileInfo, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return cpioHeader{}, fmt.Errorf("failed to get file info")
+	}
+	header := cpioHeader{
+		mode:  uint32(sysFileInfo.Mode),
+		nlink: 1,
+		mtime: fileInfo.ModTime().Unix(),
+		name:  relPath,
+	}
+	if fileInfo.IsDir() {
+		header.nlink = 2
+	}
+	if reproducible {
+		header.ino = uint32(index + 1)
+		return header, nil
+	}
+	header.ino = uint32(sysFileInfo.Ino)
+	header.uid = sysFileInfo.Uid
+	header.gid = sysFileInfo.Gid
+	header.nlink = uint32(sysFileInfo.Nlink)
+	header.devMajor = unix.Major(uint64(sysFileInfo.Dev))
+	header.devMinor = unix.Minor(uint64(sysFileInfo.Dev))
+	if fileInfo.Mode().IsRegular() {
+		// Hard links are packed as separate files.
+		header.nlink = 1
+	}
+	return header, nil
+}
+
+// writeCpioEntry writes the cpio entry of the file.
+func writeCpioEntry(writer io.Writer, fullFilePath string, fileInfo fs.FileInfo,
+	header cpioHeader) error {
+	var content io.Reader
+	switch {
+	case fileInfo.Mode().IsRegular():
+		file, err := os.Open(fullFilePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		header.fileSize, content = fileInfo.Size(), file
+	case fileInfo.Mode().Type() == fs.ModeSymlink:
+		target, err := os.Readlink(fullFilePath)
+		if err != nil {
+			return err
+		}
+		header.fileSize, content = int64(len(target)), strings.NewReader(target)
+	}
+
+	if err := writeCpioHeader(writer, header); err != nil {
+		return err
+	}
+	if content == nil {
+		return nil
+	}
+	if _, err := io.CopyN(writer, content, header.fileSize); err != nil {
+		return fmt.Errorf("failed to pack %s: %s", fullFilePath, err)
+	}
+	return writeCpioPadding(writer, header.fileSize)
+}
+
+// packCpio packs the passed items of the directory into the SVR4 cpio archive
+// without checksums. If reproducible is set, the archive does not depend on
+// the owner of the items and the file system.
+func packCpio(relPaths []string, resFileName, packageFilesDir string,
+	reproducible bool) error {
 	cpioFile, err := os.Create(resFileName)
 	if err != nil {
 		return err
@@ -18,22 +140,104 @@ func packCpio(relPaths []string, resFileName, packageFilesDir string) error {
 	defer cpioFile.Close()
 
 	cpioFileWriter := bufio.NewWriter(cpioFile)
-	defer cpioFileWriter.Flush()
 
-	var stderrBuf bytes.Buffer
+	for i, relPath := range relPaths {
+		fullFilePath := filepath.Join(packageFilesDir, relPath)
+		fileInfo, err := os.Lstat(fullFilePath)
+		if err != nil {
+			return err
+		}
+		header, err := getCpioHeader(fileInfo, relPath, i, reproducible)
+		if err != nil {
+			return err
+		}
 
-	filesBuffer := bytes.Buffer{}
-	filesBuffer.WriteString(strings.Join(relPaths, "\n"))
-
-	cmd := exec.Command("cpio", "-o", "-H", "newc")
-	cmd.Stdin = &filesBuffer
-	cmd.Stdout = cpioFileWriter
-	cmd.Stderr = &stderrBuf
-	cmd.Dir = packageFilesDir
+		err = writeCpioEntry(cpioFileWriter, fullFilePath, fileInfo, header)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run \n%s\n\nStderr: %s", cmd.String(), stderrBuf.String())
+	if err = writeCpioHeader(cpioFileWriter, cpioHeader{nlink: 1,
+		name: cpioTrailer}); err != nil {
+		return err
+	}
+	if err = cpioFileWriter.Flush(); err != nil {
+		return err
 	}
+	return cpioFile.Close()
+}
 
+// skipCpioPadding skips the alignment of the read data.
+func skipCpioPadding(reader io.Reader, size int64) error {
+	if padding := (cpioAlignment - size%cpioAlignment) % cpioAlignment; padding != 0 {
+		_, err := io.CopyN(io.Discard, reader, padding)
+		return err
+	}
 	return nil
 }
+
+// readCpio reads the SVR4 cpio archive and calls the callback for every entry
+// with the entry content reader. The content not read by the callback is
+// skipped.
+func readCpio(reader io.Reader, callback func(header cpioHeader, content io.Reader) error) error {
+	// The magic and 13 hexadecimal fields.
+	rawHeader := make([]byte, len(cpioNewcMagic)+13*8)
+	for {
+		if _, err := io.ReadFull(reader, rawHeader); err != nil {
+			return fmt.Errorf("failed to read cpio header: %s", err)
+		}
+		if string(rawHeader[:len(cpioNewcMagic)]) != cpioNewcMagic {
+			return fmt.Errorf("invalid cpio magic %q", rawHeader[:len(cpioNewcMagic)])
+		}
+		fields := make([]uint32, 13)
+		for i := range fields {
+			start := len(cpioNewcMagic) + i*8
+			field, err := strconv.ParseUint(string(rawHeader[start:start+8]), 16, 32)
+			if err != nil {
+				return fmt.Errorf("invalid cpio header field: %s", err)
+			}
+			fields[i] = uint32(field)
+		}
+		if fields[11] > cpioMaxNameSize {
+			return fmt.Errorf("too long cpio entry name: %d bytes", fields[11])
+		}
+		name := make([]byte, fields[11])
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return fmt.Errorf("failed to read cpio entry name: %s", err)
+		}
+		if err := skipCpioPadding(reader, int64(len(rawHeader)+len(name))); err != nil {
+			return err
+		}
+		header := cpioHeader{
+			ino:      fields[0],
+			mode:     fields[1],
+			uid:      fields[2],
+			gid:      fields[3],
+			nlink:    fields[4],
+			mtime:    int64(fields[5]),
+			fileSize: int64(fields[6]),
+			devMajor: fields[7],
+			devMinor: fields[8],
+			name:     strings.TrimRight(string(name), "\x00"),
+		}
+		if header.name == cpioTrailer {
+			return nil
+		}
+		if header.mode&unix.S_IFMT == unix.S_IFLNK && header.fileSize > cpioMaxNameSize {
+			return fmt.Errorf("too long symlink target of %s: %d bytes", header.name,
+				header.fileSize)
+		}
+
+		content := io.LimitReader(reader, header.fileSize)
+		if err := callback(header, content); err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return fmt.Errorf("failed to read %s: %s", header.name, err)
+		}
+		if err := skipCpioPadding(reader, header.fileSize); err != nil {
+			return err
+		}
+	}
+}
@@ -9,6 +9,10 @@ func CreatePacker(packCtx *PackCtx) Packer {
 		return &debPacker{}
 	case Rpm:
 		return &rpmPacker{}
+	case Oci:
+		return &ociPacker{}
+	case Selfextract:
+		return &selfextractPacker{}
 	case Docker:
 		return nil
 	default:
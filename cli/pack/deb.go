@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/apex/log"
 	"github.com/otiai10/copy"
 	"github.com/tarantool/tt/cli/cmdcontext"
@@ -20,13 +21,19 @@ const (
 	dataDirName    = "data"
 	controlDirName = "control_dir"
 
-	dataArchiveName    = "data.tar.gz"
-	controlArchiveName = "control.tar.gz"
+	// dataArchiveBaseName is the name of the data archive without the
+	// compression suffix: data.tar.gz, data.tar.xz or data.tar.zst.
+	dataArchiveBaseName = "data.tar"
+	controlArchiveName  = "control.tar.gz"
 
 	debianBinaryFileName = "debian-binary"
 
 	debianBinaryFileContent = "2.0\n"
 
+	// debSignatureFileName is the name of the package member with the
+	// origin signature, see debsigs(1).
+	debSignatureFileName = "_gpgorigin"
+
 	PreInstScriptName  = "preinst"
 	PostInstScriptName = "postinst"
 )
@@ -57,6 +64,14 @@ func (packer *debPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 		return err
 	}
 
+	var signKey *openpgp.Entity
+	if packCtx.RpmDeb.SignKey != "" {
+		signKey, err = loadSignKey(packCtx.RpmDeb.SignKey, packCtx.RpmDeb.SignKeyring)
+		if err != nil {
+			return fmt.Errorf("failed to load the signing key: %s", err)
+		}
+	}
+
 	// Create a package directory, where it will be built.
 	packageDir, err := os.MkdirTemp("", "tt_pack")
 	if err != nil {
@@ -108,15 +123,25 @@ func (packer *debPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	if err != nil {
 		return err
 	}
+	if err = initTmpfilesConf(packCtx, opts, packageDataDir); err != nil {
+		return err
+	}
 
 	// App directory.
 	if err = copy.Copy(bundlePath, packagePrefixedPath); err != nil {
 		return err
 	}
 
-	// Create data.tar.gz.
-	dataArchivePath := filepath.Join(packageDir, dataArchiveName)
-	err = WriteTgzArchive(packageDataDir, dataArchivePath)
+	if packCtx.Reproducible {
+		if err = normalizeTree(packageDataDir, packCtx.SourceDate); err != nil {
+			return err
+		}
+	}
+
+	// Create data.tar.gz, data.tar.xz or data.tar.zst.
+	dataArchivePath := filepath.Join(packageDir, dataArchiveBaseName+packCtx.Compression.Ext())
+	err = WriteCompressedTarArchive(packageDataDir, dataArchivePath, packCtx.Compression,
+		packCtx.Reproducible)
 	if err != nil {
 		return err
 	}
@@ -129,10 +154,20 @@ func (packer *debPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	if err != nil {
 		return err
 	}
+	err = createConffiles(controlDirPath, packagePrefixedPath, envSystemPath)
+	if err != nil {
+		return err
+	}
+
+	if packCtx.Reproducible {
+		if err = normalizeTree(controlDirPath, packCtx.SourceDate); err != nil {
+			return err
+		}
+	}
 
 	// Create control.tar.gz.
 	controlArchivePath := filepath.Join(packageDir, controlArchiveName)
-	err = WriteTgzArchive(controlDirPath, controlArchivePath)
+	err = WriteTgzArchive(controlDirPath, controlArchivePath, packCtx.Reproducible)
 	if err != nil {
 		return err
 	}
@@ -154,14 +189,32 @@ func (packer *debPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 		return err
 	}
 
-	// Create result archive.
-	packDebCmd := exec.Command(
-		"ar", "r",
-		packageName,
+	// Create result archive. The deterministic mode of ar zeroes timestamps
+	// and owners of the archive members.
+	arOperation := "r"
+	if packCtx.Reproducible {
+		arOperation = "rD"
+	}
+	members := []string{
 		filepath.Join(packageDir, debianBinaryFileName),
 		controlArchivePath,
 		dataArchivePath,
-	)
+	}
+	if signKey != nil {
+		log.Info("Signing the package")
+		signaturePath, err := createDebSignature(packageDir, members, signKey)
+		if err != nil {
+			return err
+		}
+		members = append(members, signaturePath)
+	}
+	// ar adds the members to the existing archive, so the package built before
+	// is removed: it may contain the data archive with another compression.
+	if err = os.Remove(packageName); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	packDebCmd := exec.Command("ar", append([]string{arOperation, packageName},
+		members...)...)
 
 	err = packDebCmd.Run()
 	if err != nil {
@@ -170,7 +223,7 @@ func (packer *debPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 
 	log.Infof("Created result DEB package: %s", packageName)
 
-	return err
+	return copySbom(bundlePath, packageName, packCtx)
 }
 
 // createDebianBinary creates a debian-binary file for deb package.
@@ -186,6 +239,21 @@ func createDebianBinary(packageDir string) error {
 	return nil
 }
 
+// createDebSignature creates the _gpgorigin file with the detached signature of
+// the concatenated package members and returns its path.
+func createDebSignature(packageDir string, members []string,
+	signKey *openpgp.Entity) (string, error) {
+	signature, err := signFiles(signKey, members...)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign DEB: %s", err)
+	}
+	signaturePath := filepath.Join(packageDir, debSignatureFileName)
+	if err = os.WriteFile(signaturePath, signature, 0644); err != nil {
+		return "", err
+	}
+	return signaturePath, nil
+}
+
 // getTntTTAsDeps returns tarantool and tt cli from bin_dir as dependencies.
 func getTntTTAsDeps(cmdCtx *cmdcontext.CmdCtx) (PackDependencies, error) {
 	tntVerRaw, err := util.GetTarantoolVersion(&cmdCtx.Cli)
@@ -10,7 +10,6 @@ import (
 
 	"github.com/alecthomas/participle/v2/lexer/stateful"
 	"github.com/apex/log"
-	"github.com/otiai10/copy"
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/config"
 	"github.com/tarantool/tt/cli/util"
@@ -34,7 +33,8 @@ type PackDependency struct {
 
 type PackDependencies []PackDependency
 
-// createControlDir creates a control directory that contains control file, postinst and preinst.
+// createControlDir creates a control directory that contains control file and the package
+// scripts: preinst, postinst, prerm and postrm.
 func createControlDir(cmdCtx *cmdcontext.CmdCtx, packCtx PackCtx,
 	opts *config.CliOpts, destDirPath string) error {
 	log.Debug("Create DEB control file")
@@ -71,27 +71,14 @@ func createControlDir(cmdCtx *cmdcontext.CmdCtx, packCtx PackCtx,
 
 	log.Debugf("Created control file in %s", destDirPath)
 
-	// Add postinst and preinst scripts step.
-	if packCtx.RpmDeb.PreInst == "" {
-		err = initScript(destDirPath, PreInstScriptName, map[string]interface{}{})
-		if err != nil {
-			return err
-		}
-	} else {
-		err = copy.Copy(packCtx.RpmDeb.PreInst,
-			filepath.Join(destDirPath, PreInstScriptName))
-		if err != nil {
-			return err
-		}
+	// Add the package scripts step.
+	scripts, err := genPackageScripts(&packCtx, opts, Deb)
+	if err != nil {
+		return err
 	}
-	if packCtx.RpmDeb.PostInst == "" {
-		err = initScript(destDirPath, PostInstScriptName, map[string]interface{}{})
-		if err != nil {
-			return err
-		}
-	} else {
-		err = copy.Copy(packCtx.RpmDeb.PostInst,
-			filepath.Join(destDirPath, PostInstScriptName))
+	for _, scriptName := range packageScriptNames {
+		err = os.WriteFile(filepath.Join(destDirPath, scriptName), []byte(scripts[scriptName]),
+			scriptPermissions)
 		if err != nil {
 			return err
 		}
@@ -100,6 +87,25 @@ func createControlDir(cmdCtx *cmdcontext.CmdCtx, packCtx PackCtx,
 	return nil
 }
 
+// createConffiles creates the conffiles file with the configuration files of
+// the environment, dpkg keeps their changes on upgrade. envPath is the path to
+// the packed environment, envSystemPath is its path in the target system.
+func createConffiles(destDirPath, envPath, envSystemPath string) error {
+	configFiles, err := getConfigFiles(envPath)
+	if err != nil {
+		return err
+	}
+	if len(configFiles) == 0 {
+		return nil
+	}
+	conffiles := strings.Builder{}
+	for _, configFile := range configFiles {
+		conffiles.WriteString(filepath.Join(envSystemPath, configFile) + "\n")
+	}
+	return os.WriteFile(filepath.Join(destDirPath, conffilesFileName),
+		[]byte(conffiles.String()), 0644)
+}
+
 // getDebRelation returns a correct relation string from the passed one.
 func getDebRelation(relation string) string {
 	if relation == ">" || relation == "<" {
@@ -174,27 +180,6 @@ func getLexer() *stateful.Definition {
 	})
 }
 
-// initScript initializes post- and pre-install script from the passed parameters
-// inside the passed directory.
-func initScript(destDirPath, scriptName string, mp map[string]interface{}) error {
-	var scriptTemplate string
-	if scriptName == PostInstScriptName {
-		scriptTemplate = PostInstScriptContent
-	} else if scriptName == PreInstScriptName {
-		scriptTemplate = PreInstScriptContent
-	}
-
-	text, err := util.GetTextTemplatedStr(&scriptTemplate, mp)
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(filepath.Join(destDirPath, scriptName), []byte(text), filePermissions)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 const (
 	defaultMaintainer = "Tarantool developer"
 
@@ -206,15 +191,4 @@ Description: Tarantool environment: {{ .Name }}
 Depends: {{ .Depends }}
 
 `
-	PreInstScriptContent = `/bin/sh -c 'groupadd -r tarantool > /dev/null 2>&1 || :'
-/bin/sh -c 'useradd -M -N -g tarantool -r -d /var/lib/tarantool -s /sbin/nologin \
-    -c "Tarantool Server" tarantool > /dev/null 2>&1 || :'
-/bin/sh -c 'mkdir -p /etc/tarantool/conf.d/ --mode 755 2>&1 || :'
-/bin/sh -c 'mkdir -p /var/lib/tarantool/ --mode 755 2>&1 || :'
-/bin/sh -c 'chown tarantool:tarantool /var/lib/tarantool 2>&1 || :'
-/bin/sh -c 'mkdir -p /var/run/tarantool/ --mode 755 2>&1 || :'
-/bin/sh -c 'chown tarantool:tarantool /var/run/tarantool 2>&1 || :'
-`
-
-	PostInstScriptContent = ``
 )
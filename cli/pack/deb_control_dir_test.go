@@ -103,7 +103,7 @@ func TestCreateControlDir(t *testing.T) {
 			},
 			destPath: t.TempDir(),
 			correctError: func(err error) bool {
-				return strings.Contains(err.Error(), "lstat nothing: no such file or directory")
+				return strings.Contains(err.Error(), "open nothing: no such file or directory")
 			},
 			correctDir: func(controlPath string) bool {
 				return true
@@ -127,7 +127,7 @@ func TestCreateControlDir(t *testing.T) {
 			},
 			destPath: t.TempDir(),
 			correctError: func(err error) bool {
-				return strings.Contains(err.Error(), "lstat nothing: no such file or directory")
+				return strings.Contains(err.Error(), "open nothing: no such file or directory")
 			},
 			correctDir: func(controlPath string) bool {
 				return true
@@ -243,34 +243,3 @@ func TestGetDebRelation(t *testing.T) {
 		})
 	}
 }
-
-func TestInitScript(t *testing.T) {
-	testDir := t.TempDir()
-	testCases := []struct {
-		name            string
-		scriptName      string
-		expectedToExist string
-		args            map[string]interface{}
-	}{
-		{
-			name:            "preinst script initialization",
-			scriptName:      PreInstScriptName,
-			expectedToExist: filepath.Join(testDir, PreInstScriptName),
-			args:            map[string]interface{}{},
-		},
-		{
-			name:            "postinst script initialization",
-			scriptName:      PostInstScriptName,
-			expectedToExist: filepath.Join(testDir, PostInstScriptName),
-			args:            map[string]interface{}{},
-		},
-	}
-
-	for _, testCase := range testCases {
-		t.Run(testCase.name, func(t *testing.T) {
-			err := initScript(testDir, testCase.scriptName, testCase.args)
-			require.NoErrorf(t, err, "Failed to init a script: %s", err)
-			require.FileExists(t, testCase.expectedToExist, "Script file doesn't exist")
-		})
-	}
-}
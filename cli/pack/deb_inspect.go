@@ -0,0 +1,195 @@
+package pack
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// arHeaderSize is the size of the ar archive member header.
+const arHeaderSize = 60
+
+// arMember is the member of the ar archive.
+type arMember struct {
+	name    string
+	content *io.SectionReader
+}
+
+// readArMembers reads the members list of the ar archive.
+func readArMembers(reader io.ReaderAt, size int64) ([]arMember, error) {
+	magic := make([]byte, len(arMagic))
+	if _, err := reader.ReadAt(magic, 0); err != nil {
+		return nil, fmt.Errorf("failed to read ar magic: %s", err)
+	}
+	if !bytes.Equal(magic, arMagic) {
+		return nil, fmt.Errorf("invalid ar magic %q", magic)
+	}
+
+	members := []arMember{}
+	header := make([]byte, arHeaderSize)
+	for offset := int64(len(arMagic)); offset < size; {
+		if _, err := reader.ReadAt(header, offset); err != nil {
+			return nil, fmt.Errorf("failed to read ar member header: %s", err)
+		}
+		if string(header[58:60]) != "`\n" {
+			return nil, fmt.Errorf("invalid ar member header at %d", offset)
+		}
+		name := strings.TrimSuffix(strings.TrimRight(string(header[0:16]), " "), "/")
+		memberSize, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || memberSize < 0 || offset+arHeaderSize+memberSize > size {
+			return nil, fmt.Errorf("invalid size of ar member %q", name)
+		}
+		members = append(members, arMember{
+			name:    name,
+			content: io.NewSectionReader(reader, offset+arHeaderSize, memberSize),
+		})
+		// The member data is aligned to 2 bytes.
+		offset += arHeaderSize + memberSize + memberSize%2
+	}
+	return members, nil
+}
+
+// parseDebControl parses the deb control file fields keeping their order.
+func parseDebControl(content string) []PackageField {
+	fields := []PackageField{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].Value += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if found {
+			fields = append(fields, PackageField{Name: strings.TrimSpace(name),
+				Value: strings.TrimSpace(value)})
+		}
+	}
+	return fields
+}
+
+// findArMember returns the ar member with the passed name.
+func findArMember(members []arMember, name string) *arMember {
+	for i := range members {
+		if members[i].name == name {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+// findDataArMember returns the data archive member compressed with any
+// supported compression.
+func findDataArMember(members []arMember) *arMember {
+	for i := range members {
+		if strings.HasPrefix(members[i].name, dataArchiveBaseName+".") {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+// inspectDeb inspects the deb package.
+func inspectDeb(file *os.File, opts InspectOpts,
+	keyring openpgp.EntityList) (*PackageInfo, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	members, err := readArMembers(file, fileInfo.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 || members[0].name != debianBinaryFileName {
+		return nil, fmt.Errorf("%s is not the first package member", debianBinaryFileName)
+	}
+	debianBinary, err := io.ReadAll(members[0].content)
+	if err != nil {
+		return nil, err
+	}
+	if string(debianBinary) != debianBinaryFileContent {
+		return nil, fmt.Errorf("unsupported deb format version %q",
+			strings.TrimSpace(string(debianBinary)))
+	}
+	control := findArMember(members, controlArchiveName)
+	data := findDataArMember(members)
+	if control == nil || data == nil {
+		return nil, fmt.Errorf("the package must contain %s and %s members",
+			controlArchiveName, dataArchiveBaseName+".*")
+	}
+
+	info := PackageInfo{Format: "deb"}
+	err = readTarGz(control.content, func(header *tar.Header, content io.Reader) error {
+		name := path.Clean(header.Name)
+		isScript := false
+		for _, scriptName := range packageScriptNames {
+			isScript = isScript || name == scriptName
+		}
+		if name != "control" && !isScript {
+			return nil
+		}
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		if name != "control" {
+			info.Scripts = append(info.Scripts, PackageScript{Name: name,
+				Content: string(data)})
+			return nil
+		}
+		for _, field := range parseDebControl(string(data)) {
+			if field.Name != "Depends" {
+				info.Metadata = append(info.Metadata, field)
+				continue
+			}
+			for _, dep := range strings.Split(field.Value, ",") {
+				if dep = strings.TrimSpace(dep); dep != "" {
+					info.Dependencies = append(info.Dependencies, dep)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", controlArchiveName, err)
+	}
+
+	err = readTarGz(data.content, func(header *tar.Header, content io.Reader) error {
+		info.Files = append(info.Files, getTarFile(header, "/"))
+		return nil
+	})
+	if opts.Verify {
+		info.addCheck(data.name+" integrity", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", data.name, err)
+	}
+
+	if opts.Verify {
+		signature := findArMember(members, debSignatureFileName)
+		if signature == nil {
+			info.skipCheck("signature", "the package is not signed")
+			return &info, nil
+		}
+		signatureData, err := io.ReadAll(signature.content)
+		if err != nil {
+			return nil, err
+		}
+		signed := io.MultiReader(
+			io.NewSectionReader(members[0].content, 0, members[0].content.Size()),
+			io.NewSectionReader(control.content, 0, control.content.Size()),
+			io.NewSectionReader(data.content, 0, data.content.Size()))
+		verifySignature(&info, "signature", keyring, signed, signatureData)
+	}
+	return &info, nil
+}
@@ -56,6 +56,11 @@ func PackInDocker(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 
 	// Generate pack command line for tt in container.
 	ttPackCommandLine := append([]string{"tt"}, cmdArgs[1:]...)
+	if packCtx.Reproducible {
+		// Pass the timestamp of the packed files to the container.
+		ttPackCommandLine = append([]string{fmt.Sprintf("%s=%d", sourceDateEpochEnv,
+			packCtx.SourceDate.Unix())}, ttPackCommandLine...)
+	}
 
 	// If bin_dir is not empty, we need to pack binaries built in container.
 	relEnvBinPath := configure.BinPath
@@ -91,24 +96,32 @@ func PackInDocker(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 		return err
 	}
 
-	skipRegularFilesFunc := func(path string) (bool, error) {
-		switch filepath.Ext(path) {
-		case ".deb", ".rpm", ".gz":
-			return false, nil
-		default:
-			return true, nil
-		}
-	}
-
 	curDir, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
-	err = copy.Copy(tempEnvDir, curDir, copy.Options{Skip: skipRegularFilesFunc})
+	err = copy.Copy(tempEnvDir, curDir, copy.Options{Skip: skipNonPackageFiles})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// skipNonPackageFiles reports whether the file of the environment packed in
+// docker is not a result package, so it is not copied back.
+func skipNonPackageFiles(path string) (bool, error) {
+	for _, sbomSuffix := range sbomSuffixes {
+		// The SBOM files next to the packages.
+		if strings.HasSuffix(path, sbomSuffix) {
+			return false, nil
+		}
+	}
+	switch filepath.Ext(path) {
+	case ".deb", ".rpm", ".gz", ".xz", ".zst", ".run":
+		return false, nil
+	default:
+		return true, nil
+	}
+}
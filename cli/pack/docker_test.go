@@ -0,0 +1,23 @@
+package pack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipNonPackageFiles(t *testing.T) {
+	for _, path := range []string{"app-0.1.0.0-1.x86_64.rpm", "app_0.1.0.0-1_amd64.deb",
+		"app-0.1.0.0.x86_64.tar.gz", "app-0.1.0.0.x86_64.tar.xz",
+		"app-0.1.0.0.x86_64.tar.zst", "app-0.1.0.0.x86_64.run",
+		"app-0.1.0.0.x86_64.rpm.cdx.json", "app-0.1.0.0.x86_64.tar.gz.spdx.json"} {
+		skip, err := skipNonPackageFiles(path)
+		assert.NoError(t, err)
+		assert.False(t, skip, path)
+	}
+	for _, path := range []string{"tt.yaml", "app/init.lua", "bin/tarantool", "app.json"} {
+		skip, err := skipNonPackageFiles(path)
+		assert.NoError(t, err)
+		assert.True(t, skip, path)
+	}
+}
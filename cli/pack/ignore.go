@@ -0,0 +1,177 @@
+package pack
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// packIgnoreFileName is the name of the file with the patterns of the
+// application files excluded from the package.
+const packIgnoreFileName = ".packignore"
+
+// ignorePattern is a single gitignore-like pattern.
+type ignorePattern struct {
+	// re matches the slash separated path relative to the application root.
+	re *regexp.Regexp
+	// negate re-includes the matched path.
+	negate bool
+	// dirOnly matches only directories.
+	dirOnly bool
+}
+
+// ignoreMatcher checks the paths against the list of gitignore-like patterns.
+// The last matched pattern wins.
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// globToRegexp converts the gitignore glob to the regular expression.
+func globToRegexp(glob string) (string, error) {
+	re := strings.Builder{}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if strings.HasPrefix(glob[i:], "**") {
+				start := i == 0 || glob[i-1] == '/'
+				end := i+2 == len(glob) || glob[i+2] == '/'
+				if start && end {
+					i++
+					if i+1 < len(glob) {
+						// "**/" matches zero or more directories.
+						i++
+						re.WriteString("(?:.*/)?")
+					} else {
+						re.WriteString(".*")
+					}
+					continue
+				}
+			}
+			re.WriteString("[^/]*")
+		case '?':
+			re.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class in %q", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String(), nil
+}
+
+// parseIgnorePattern parses the gitignore-like pattern. It returns nil for
+// blank lines and comments.
+func parseIgnorePattern(line string) (*ignorePattern, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	pattern := ignorePattern{}
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil, nil
+	}
+
+	// A pattern without a slash matches a name at any level, otherwise it is
+	// relative to the application root.
+	prefix := "^(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+	re, err := globToRegexp(line)
+	if err != nil {
+		return nil, err
+	}
+	pattern.re, err = regexp.Compile(prefix + re + "$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %s", line, err)
+	}
+	return &pattern, nil
+}
+
+// newIgnoreMatcher creates the matcher from the patterns.
+func newIgnoreMatcher(lines []string) (*ignoreMatcher, error) {
+	matcher := ignoreMatcher{}
+	for _, line := range lines {
+		pattern, err := parseIgnorePattern(line)
+		if err != nil {
+			return nil, err
+		}
+		if pattern != nil {
+			matcher.patterns = append(matcher.patterns, *pattern)
+		}
+	}
+	return &matcher, nil
+}
+
+// loadIgnoreMatcher creates the matcher from the .packignore file in the
+// application directory followed by the passed patterns. The patterns from
+// the command line take precedence over the file ones.
+func loadIgnoreMatcher(appPath string, excludes []string) (*ignoreMatcher, error) {
+	lines := []string{}
+	file, err := os.Open(filepath.Join(appPath, packIgnoreFileName))
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file.Name(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	lines = append(lines, excludes...)
+
+	matcher, err := newIgnoreMatcher(lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the exclude patterns: %s", err)
+	}
+	return matcher, nil
+}
+
+// match checks if the path relative to the application root is excluded.
+// The parent directories are not checked: an excluded directory is skipped
+// with all its content.
+func (matcher *ignoreMatcher) match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	excluded := false
+	for _, pattern := range matcher.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if pattern.re.MatchString(relPath) {
+			excluded = !pattern.negate
+		}
+	}
+	return excluded
+}
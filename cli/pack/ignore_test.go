@@ -0,0 +1,105 @@
+package pack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	matcher, err := newIgnoreMatcher([]string{
+		"# comment",
+		"",
+		"*.snap",
+		"!keep.snap",
+		"/local.lua",
+		"tmp/",
+		"doc/*.md",
+		"**/fixtures",
+		"build/**",
+		"a/**/b.lua",
+		`\#hash`,
+		"file[0-9].txt",
+		"name?.log   ",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		excluded bool
+	}{
+		{"00000.snap", false, true},
+		{"var/00000.snap", false, true},
+		{"keep.snap", false, false},
+		{"data/keep.snap", false, false},
+		{"local.lua", false, true},
+		{"src/local.lua", false, false},
+		{"tmp", true, true},
+		{"src/tmp", true, true},
+		{"tmp", false, false},
+		{"doc/README.md", false, true},
+		{"doc/api/README.md", false, false},
+		{"src/doc/README.md", false, false},
+		{"fixtures", true, true},
+		{"test/unit/fixtures", true, true},
+		{"build", true, false},
+		{"build/app.so", false, true},
+		{"a/b.lua", false, true},
+		{"a/x/y/b.lua", false, true},
+		{"#hash", false, true},
+		{"file1.txt", false, true},
+		{"filex.txt", false, false},
+		{"name1.log", false, true},
+		{"name10.log", false, false},
+		{"init.lua", false, false},
+		{"# comment", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.excluded, matcher.match(tt.path, tt.isDir), tt.path)
+	}
+
+	_, err = newIgnoreMatcher([]string{"file[0-9"})
+	assert.ErrorContains(t, err, "unterminated character class")
+}
+
+func TestLoadIgnoreMatcher(t *testing.T) {
+	appPath := t.TempDir()
+	matcher, err := loadIgnoreMatcher(appPath, []string{"*.log"})
+	require.NoError(t, err)
+	assert.True(t, matcher.match("tarantool.log", false))
+
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, packIgnoreFileName),
+		[]byte("*.snap\n!important.log\n"), 0644))
+	matcher, err = loadIgnoreMatcher(appPath, []string{"*.log"})
+	require.NoError(t, err)
+	assert.True(t, matcher.match("00000.snap", false))
+	// The command line patterns take precedence.
+	assert.True(t, matcher.match("important.log", false))
+	assert.False(t, matcher.match("init.lua", false))
+}
+
+func TestCopyAppSrcExclude(t *testing.T) {
+	appPath := filepath.Join(t.TempDir(), "app")
+	for _, name := range []string{"init.lua", "local.snap", "test/fixtures/data.snap",
+		"test/unit_test.lua", "tmp/session.lock", ".editorconfig"} {
+		path := filepath.Join(appPath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte{}, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, packIgnoreFileName),
+		[]byte("*.snap\ntmp/\n"), 0644))
+
+	packagePath := t.TempDir()
+	require.NoError(t, copyAppSrc(appPath, "app", packagePath, []string{"test/"}))
+	for _, name := range []string{"init.lua", ".editorconfig"} {
+		assert.FileExists(t, filepath.Join(packagePath, "app", name))
+	}
+	for _, name := range []string{packIgnoreFileName, "local.snap", "test", "tmp"} {
+		assert.NoFileExists(t, filepath.Join(packagePath, "app", name))
+		assert.NoDirExists(t, filepath.Join(packagePath, "app", name))
+	}
+}
@@ -0,0 +1,316 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/tarantool/tt/cli/util"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// CheckOk is the status of the passed verification check.
+	CheckOk = "ok"
+	// CheckFailed is the status of the failed verification check.
+	CheckFailed = "failed"
+	// CheckSkipped is the status of the check that can not be done.
+	CheckSkipped = "skipped"
+)
+
+var (
+	rpmMagic = []byte{0xed, 0xab, 0xee, 0xdb}
+	arMagic  = []byte("!<arch>\n")
+)
+
+// InspectOpts contains the options of the package inspection.
+type InspectOpts struct {
+	// Verify enables the digests and signatures verification.
+	Verify bool
+	// Keyring is the keyring file with the public keys to check signatures.
+	Keyring string
+}
+
+// PackageField is the package metadata field.
+type PackageField struct {
+	Name  string
+	Value string
+}
+
+// PackageScript is the package installation script.
+type PackageScript struct {
+	Name    string
+	Content string
+}
+
+// PackageFile describes the packed file.
+type PackageFile struct {
+	Path   string
+	Mode   os.FileMode
+	Size   int64
+	Owner  string
+	Group  string
+	LinkTo string
+	Digest string
+}
+
+// PackageCheck is the result of the package verification check.
+type PackageCheck struct {
+	Name    string
+	Status  string
+	Details string
+}
+
+// PackageInfo describes the package content.
+type PackageInfo struct {
+	Format       string
+	Metadata     []PackageField
+	Dependencies []string
+	Scripts      []PackageScript
+	Files        []PackageFile
+	Checks       []PackageCheck
+}
+
+// addField adds the metadata field if the value is not empty.
+func (info *PackageInfo) addField(name, value string) {
+	if value != "" {
+		info.Metadata = append(info.Metadata, PackageField{Name: name, Value: value})
+	}
+}
+
+// addCheck adds the check result: passed if err is nil, failed otherwise.
+func (info *PackageInfo) addCheck(name string, err error) {
+	check := PackageCheck{Name: name, Status: CheckOk}
+	if err != nil {
+		check.Status, check.Details = CheckFailed, err.Error()
+	}
+	info.Checks = append(info.Checks, check)
+}
+
+// skipCheck adds the skipped check with the reason.
+func (info *PackageInfo) skipCheck(name, reason string) {
+	info.Checks = append(info.Checks, PackageCheck{Name: name, Status: CheckSkipped,
+		Details: reason})
+}
+
+// Verified returns true if no verification check is failed.
+func (info *PackageInfo) Verified() bool {
+	for _, check := range info.Checks {
+		if check.Status == CheckFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDigest compares the expected and the actual digests.
+func checkDigest(expected, actual string) error {
+	if expected != actual {
+		return fmt.Errorf("expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// verifySignature checks the detached OpenPGP signature of the signed data.
+func verifySignature(info *PackageInfo, name string, keyring openpgp.EntityList,
+	signed io.Reader, signature []byte) {
+	if keyring == nil {
+		info.skipCheck(name, "the keyring is not set")
+		return
+	}
+	signer, err := openpgp.CheckDetachedSignature(keyring, signed,
+		bytes.NewReader(signature), nil)
+	if err != nil {
+		info.addCheck(name, err)
+		return
+	}
+	details := fmt.Sprintf("signed by %X", signer.PrimaryKey.Fingerprint)
+	for identity := range signer.Identities {
+		details = "signed by " + identity
+		break
+	}
+	info.Checks = append(info.Checks, PackageCheck{Name: name, Status: CheckOk,
+		Details: details})
+}
+
+// fileModeFromUnix converts the unix file mode to os.FileMode.
+func fileModeFromUnix(mode uint32) os.FileMode {
+	fileMode := os.FileMode(mode & 0777)
+	switch mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+		fileMode |= os.ModeDir
+	case unix.S_IFLNK:
+		fileMode |= os.ModeSymlink
+	case unix.S_IFIFO:
+		fileMode |= os.ModeNamedPipe
+	case unix.S_IFSOCK:
+		fileMode |= os.ModeSocket
+	case unix.S_IFCHR:
+		fileMode |= os.ModeDevice | os.ModeCharDevice
+	case unix.S_IFBLK:
+		fileMode |= os.ModeDevice
+	}
+	if mode&unix.S_ISUID != 0 {
+		fileMode |= os.ModeSetuid
+	}
+	if mode&unix.S_ISGID != 0 {
+		fileMode |= os.ModeSetgid
+	}
+	if mode&unix.S_ISVTX != 0 {
+		fileMode |= os.ModeSticky
+	}
+	return fileMode
+}
+
+// readTarGz reads the tar.gz, tar.xz or tar.zst archive and calls the callback
+// for every entry.
+func readTarGz(reader io.Reader, callback func(header *tar.Header, content io.Reader) error) error {
+	decompressReader, err := util.NewDecompressReader(reader)
+	if err != nil {
+		return err
+	}
+	defer decompressReader.Close()
+	tarReader := tar.NewReader(decompressReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err = callback(header, tarReader); err != nil {
+			return err
+		}
+	}
+	// Read the rest of the stream to check the checksum.
+	_, err = io.Copy(io.Discard, decompressReader)
+	return err
+}
+
+// getTarFile returns the package file description of the tar entry.
+func getTarFile(header *tar.Header, root string) PackageFile {
+	file := PackageFile{
+		Path:   path.Join(root, header.Name),
+		Mode:   header.FileInfo().Mode(),
+		Size:   header.Size,
+		Owner:  header.Uname,
+		Group:  header.Gname,
+		LinkTo: header.Linkname,
+	}
+	if file.Owner == "" {
+		file.Owner = fmt.Sprint(header.Uid)
+	}
+	if file.Group == "" {
+		file.Group = fmt.Sprint(header.Gid)
+	}
+	return file
+}
+
+// inspectTgz inspects the tar.gz, tar.xz or tar.zst archive. The archive is verified by reading
+// it completely.
+func inspectTgz(file *os.File, compression string, opts InspectOpts) (*PackageInfo, error) {
+	info := PackageInfo{Format: "tgz"}
+	info.addField("Compression", compression)
+	err := readTarGz(file, func(header *tar.Header, content io.Reader) error {
+		info.Files = append(info.Files, getTarFile(header, ""))
+		return nil
+	})
+	if opts.Verify {
+		info.addCheck("archive integrity", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read the archive: %s", err)
+	}
+	return &info, nil
+}
+
+// Inspect reads the rpm, deb or tgz (tar.gz, tar.xz, tar.zst) package and
+// returns its description. If the verification is enabled, the digests and the
+// signatures are checked.
+func Inspect(packagePath string, opts InspectOpts) (*PackageInfo, error) {
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keyring openpgp.EntityList
+	if opts.Verify && opts.Keyring != "" {
+		if keyring, err = readKeyRing(opts.Keyring); err != nil {
+			return nil, err
+		}
+	}
+
+	magic := make([]byte, len(arMagic))
+	if _, err = io.ReadFull(file, magic); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", packagePath, err)
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	compression := util.DetectCompression(magic)
+	switch {
+	case bytes.HasPrefix(magic, rpmMagic):
+		return inspectRpm(file, opts, keyring)
+	case bytes.HasPrefix(magic, arMagic):
+		return inspectDeb(file, opts, keyring)
+	case compression != "":
+		return inspectTgz(file, compression, opts)
+	}
+	return nil, fmt.Errorf("unknown package format of %s", packagePath)
+}
+
+// WritePackageInfo writes the package description in the human-readable form.
+func WritePackageInfo(writer io.Writer, info *PackageInfo) error {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "Format: %s\n", info.Format)
+	for _, field := range info.Metadata {
+		fmt.Fprintf(&buf, "%s: %s\n", field.Name, strings.ReplaceAll(field.Value, "\n", "\n  "))
+	}
+
+	if len(info.Dependencies) > 0 {
+		buf.WriteString("\nDependencies:\n")
+		for _, dep := range info.Dependencies {
+			fmt.Fprintf(&buf, "  %s\n", dep)
+		}
+	}
+
+	if len(info.Scripts) > 0 {
+		buf.WriteString("\nScripts:\n")
+		for _, script := range info.Scripts {
+			fmt.Fprintf(&buf, "  %s:\n", script.Name)
+			for _, line := range strings.Split(strings.TrimRight(script.Content, "\n"), "\n") {
+				fmt.Fprintf(&buf, "    %s\n", line)
+			}
+		}
+	}
+
+	buf.WriteString("\nFiles:\n")
+	for _, file := range info.Files {
+		fmt.Fprintf(&buf, "  %s %s/%s %10d %s", file.Mode, file.Owner, file.Group, file.Size,
+			file.Path)
+		if file.LinkTo != "" {
+			fmt.Fprintf(&buf, " -> %s", file.LinkTo)
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(info.Checks) > 0 {
+		buf.WriteString("\nVerification:\n")
+		for _, check := range info.Checks {
+			fmt.Fprintf(&buf, "  %-7s %s", check.Status, check.Name)
+			if check.Details != "" {
+				fmt.Fprintf(&buf, ": %s", check.Details)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,388 @@
+package pack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/util"
+	"golang.org/x/sys/unix"
+)
+
+// makeInspectTree creates the directory with the packed files.
+func makeInspectTree(t *testing.T) string {
+	dir := t.TempDir()
+	appPath := filepath.Join(dir, "usr", "share", "tarantool", "app")
+	require.NoError(t, os.MkdirAll(appPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appPath, "init.lua"), []byte("return {}"),
+		0644))
+	require.NoError(t, os.Symlink("init.lua", filepath.Join(appPath, "main.lua")))
+	return dir
+}
+
+func TestReadTagSet(t *testing.T) {
+	tagSet := rpmTagSetType{
+		{ID: tagName, Type: rpmTypeString, Value: "app"},
+		{ID: tagFileModes, Type: rpmTypeInt16, Value: []int16{0644, -32348}},
+		{ID: tagSize, Type: rpmTypeInt32, Value: []int32{1, 2}},
+		{ID: tagBaseNames, Type: rpmTypeStringArray, Value: []string{"a", "", "b"}},
+		{ID: signatureTagMD5, Type: rpmTypeBin, Value: []byte{0xde, 0xad}},
+		{ID: tagEpoch, Type: rpmTypeInt64, Value: []int64{1 << 40}},
+		{ID: tagRelease, Type: rpmTypeInt8, Value: []int8{-1}},
+	}
+	packed, err := packTagSet(tagSet, headerImmutable)
+	require.NoError(t, err)
+	raw := append([]byte{}, packed.Bytes()...)
+	packed.WriteString("tail")
+
+	read, rawRead, err := readTagSet(packed)
+	require.NoError(t, err)
+	assert.Equal(t, tagSet, read)
+	assert.Equal(t, raw, rawRead)
+	assert.Equal(t, "tail", packed.String())
+
+	_, _, err = readTagSet(bytes.NewReader([]byte("invalid tags set")))
+	assert.ErrorContains(t, err, "invalid tags set magic")
+	_, _, err = readTagSet(bytes.NewReader(raw[:len(raw)-1]))
+	assert.ErrorContains(t, err, "failed to read tags set")
+}
+
+func TestReadCpio(t *testing.T) {
+	dir := makeInspectTree(t)
+	relPaths, err := getSortedRelPaths(dir)
+	require.NoError(t, err)
+	cpioPath := filepath.Join(t.TempDir(), "cpio")
+	require.NoError(t, packCpio(relPaths, cpioPath, dir, true))
+
+	cpioFile, err := os.Open(cpioPath)
+	require.NoError(t, err)
+	defer cpioFile.Close()
+	entries := map[string]string{}
+	names := []string{}
+	require.NoError(t, readCpio(cpioFile, func(header cpioHeader, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return err
+		}
+		names = append(names, header.name)
+		entries[header.name] = fmt.Sprintf("%o %s", header.mode&0777, data)
+		return nil
+	}))
+	assert.Equal(t, relPaths, names)
+	assert.Equal(t, "644 return {}", entries["usr/share/tarantool/app/init.lua"])
+	assert.Equal(t, "777 init.lua", entries["usr/share/tarantool/app/main.lua"])
+
+	err = readCpio(strings.NewReader("invalid"), func(cpioHeader, io.Reader) error {
+		return nil
+	})
+	assert.ErrorContains(t, err, "failed to read cpio header")
+
+	// The crafted sizes are rejected before allocating or reading the data.
+	buf := bytes.Buffer{}
+	require.NoError(t, writeCpioHeader(&buf, cpioHeader{
+		name: strings.Repeat("a", cpioMaxNameSize)}))
+	err = readCpio(&buf, func(cpioHeader, io.Reader) error { return nil })
+	assert.ErrorContains(t, err, "too long cpio entry name: 4097 bytes")
+
+	buf.Reset()
+	require.NoError(t, writeCpioHeader(&buf, cpioHeader{mode: unix.S_IFLNK | 0777,
+		fileSize: cpioMaxNameSize + 1, name: "link"}))
+	err = readCpio(&buf, func(cpioHeader, io.Reader) error { return nil })
+	assert.ErrorContains(t, err, "too long symlink target of link: 4097 bytes")
+}
+
+func TestVerifyRpmPayloadDigestAlgo(t *testing.T) {
+	dir := makeInspectTree(t)
+	relPaths, err := getSortedRelPaths(dir)
+	require.NoError(t, err)
+	cpioPath := filepath.Join(t.TempDir(), "cpio")
+	require.NoError(t, packCpio(relPaths, cpioPath, dir, true))
+	cpioData, err := os.ReadFile(cpioPath)
+	require.NoError(t, err)
+	payload := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&payload)
+	_, err = gzipWriter.Write(cpioData)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	verify := func(digestAlgo int32, digest string, size int64) map[string]string {
+		rpm := &rpmPackage{
+			signature: rpmTagSetType{{ID: signatureTagPayloadSize, Type: rpmTypeInt32,
+				Value: []int32{int32(len(cpioData))}}},
+			header: rpmTagSetType{{ID: tagFileDigestAlgo, Type: rpmTypeInt32,
+				Value: []int32{digestAlgo}}},
+			payload: io.NewSectionReader(bytes.NewReader(payload.Bytes()), 0,
+				int64(payload.Len())),
+		}
+		info := &PackageInfo{Files: []PackageFile{{Path: "/usr/share/tarantool/app/init.lua",
+			Mode: 0644, Size: size, Digest: digest}}}
+		verifyRpmPayload(info, rpm)
+		return getChecks(info)
+	}
+
+	sha256Digest := fmt.Sprintf("%x", sha256.Sum256([]byte("return {}")))
+	checks := verify(hashAlgoSHA256, sha256Digest, 9)
+	assert.Equal(t, CheckOk, checks["file digests"])
+	assert.Equal(t, CheckOk, checks["uncompressed payload size"])
+	checks = verify(hashAlgoSHA256, "2d5e36656993b814e737cdc02f01fddd", 9)
+	assert.Equal(t, CheckFailed, checks["file digests"])
+	checks = verify(hashAlgoSHA256, sha256Digest, 1<<30)
+	assert.Equal(t, CheckFailed, checks["file digests"])
+	checks = verify(100, sha256Digest, 9)
+	assert.Equal(t, CheckSkipped, checks["file digests"])
+	assert.Equal(t, CheckOk, checks["uncompressed payload size"])
+}
+
+// makeInspectRpm creates the rpm package.
+func makeInspectRpm(t *testing.T, sign bool, compression util.CompressionOpts) string {
+	var err error
+	packCtx := &PackCtx{Name: "app", Version: "1.2.3", Type: Rpm, Reproducible: true,
+		Compression: compression}
+	packCtx.RpmDeb.Deps = []string{"tarantool>=2.11", "tt"}
+	packCtx.RpmDeb.PostInst = filepath.Join(t.TempDir(), PostInstScriptName)
+	require.NoError(t, os.WriteFile(packCtx.RpmDeb.PostInst, []byte("echo installed\n"), 0644))
+	var signKey *openpgp.Entity
+	if sign {
+		signKey, err = loadSignKey(testSignKeyFile, "")
+		require.NoError(t, err)
+	}
+	packagePath := filepath.Join(t.TempDir(), "app-1.2.3.rpm")
+	require.NoError(t, packRpm(&cmdcontext.CmdCtx{}, packCtx,
+		&config.CliOpts{App: &config.AppOpts{}}, makeInspectTree(t), packagePath, signKey))
+	return packagePath
+}
+
+// getChecks returns the statuses of the checks by names.
+func getChecks(info *PackageInfo) map[string]string {
+	checks := map[string]string{}
+	for _, check := range info.Checks {
+		checks[check.Name] = check.Status
+	}
+	return checks
+}
+
+func TestInspectRpm(t *testing.T) {
+	packagePath := makeInspectRpm(t, true, util.CompressionOpts{})
+	info, err := Inspect(packagePath, InspectOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, "rpm", info.Format)
+	assert.Contains(t, info.Metadata, PackageField{Name: "Name", Value: "app"})
+	assert.Contains(t, info.Metadata, PackageField{Name: "Version", Value: "1.2.3"})
+	assert.Equal(t, []string{"tarantool >= 2.11", "tt"}, info.Dependencies)
+	require.Len(t, info.Scripts, 4)
+	assert.Equal(t, PostInstScriptName, info.Scripts[1].Name)
+	assert.True(t, strings.HasSuffix(info.Scripts[1].Content, "\necho installed\n"))
+	assert.Equal(t, PostRmScriptName, info.Scripts[3].Name)
+	assert.Empty(t, info.Checks)
+
+	files := map[string]PackageFile{}
+	for _, file := range info.Files {
+		files[file.Path] = file
+	}
+	assert.Equal(t, PackageFile{Path: "/usr/share/tarantool/app/init.lua", Mode: 0644,
+		Size: 9, Owner: "root", Group: "root", Digest: "2d5e36656993b814e737cdc02f01fddd"},
+		files["/usr/share/tarantool/app/init.lua"])
+	assert.Equal(t, os.ModeSymlink|0777, files["/usr/share/tarantool/app/main.lua"].Mode)
+	assert.Equal(t, "init.lua", files["/usr/share/tarantool/app/main.lua"].LinkTo)
+	assert.True(t, files["/usr/share/tarantool/app"].Mode.IsDir())
+
+	info, err = Inspect(packagePath, InspectOpts{Verify: true})
+	require.NoError(t, err)
+	assert.True(t, info.Verified())
+	assert.Equal(t, map[string]string{
+		"header SHA1":                  CheckOk,
+		"header and payload size":      CheckOk,
+		"header and payload MD5":       CheckOk,
+		"payload SHA256":               CheckOk,
+		"file digests":                 CheckOk,
+		"uncompressed payload size":    CheckOk,
+		"header signature":             CheckSkipped,
+		"header and payload signature": CheckSkipped,
+	}, getChecks(info))
+
+	info, err = Inspect(packagePath, InspectOpts{Verify: true,
+		Keyring: testSignPublicKeyFile})
+	require.NoError(t, err)
+	assert.True(t, info.Verified())
+	checks := getChecks(info)
+	assert.Equal(t, CheckOk, checks["header signature"])
+	assert.Equal(t, CheckOk, checks["header and payload signature"])
+
+	info, err = Inspect(makeInspectRpm(t, false, util.CompressionOpts{}), InspectOpts{Verify: true})
+	require.NoError(t, err)
+	assert.Equal(t, CheckSkipped, getChecks(info)["signature"])
+}
+
+func TestInspectRpmCompression(t *testing.T) {
+	for _, compression := range []util.CompressionOpts{
+		{Type: util.CompressionXz},
+		{Type: util.CompressionZstd, Level: 19},
+	} {
+		info, err := Inspect(makeInspectRpm(t, false, compression), InspectOpts{Verify: true})
+		require.NoError(t, err)
+		assert.Contains(t, info.Metadata, PackageField{Name: "Payload compressor",
+			Value: compression.Type})
+		assert.Equal(t, []string{rpmPayloadRequires[compression.Type].name + " <= " +
+			rpmPayloadRequires[compression.Type].version, "tarantool >= 2.11", "tt"},
+			info.Dependencies)
+		checks := getChecks(info)
+		assert.Equal(t, CheckOk, checks["payload SHA256"])
+		assert.Equal(t, CheckOk, checks["uncompressed payload size"])
+		assert.Equal(t, CheckOk, checks["file digests"])
+	}
+}
+
+func TestInspectRpmTampered(t *testing.T) {
+	packagePath := makeInspectRpm(t, true, util.CompressionOpts{})
+	data, err := os.ReadFile(packagePath)
+	require.NoError(t, err)
+	// Corrupt the last byte of the payload: the gzip size.
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(packagePath, data, 0644))
+
+	info, err := Inspect(packagePath, InspectOpts{Verify: true,
+		Keyring: testSignPublicKeyFile})
+	require.NoError(t, err)
+	assert.False(t, info.Verified())
+	checks := getChecks(info)
+	assert.Equal(t, CheckOk, checks["header SHA1"])
+	assert.Equal(t, CheckOk, checks["header signature"])
+	assert.Equal(t, CheckFailed, checks["header and payload MD5"])
+	assert.Equal(t, CheckFailed, checks["payload SHA256"])
+	assert.Equal(t, CheckFailed, checks["header and payload signature"])
+}
+
+// writeAr writes the ar archive with the passed members.
+func writeAr(t *testing.T, arPath string, members []string) {
+	buf := bytes.NewBuffer(append([]byte{}, arMagic...))
+	for _, member := range members {
+		data, err := os.ReadFile(member)
+		require.NoError(t, err)
+		fmt.Fprintf(buf, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", filepath.Base(member)+"/", 0, 0, 0,
+			0644, len(data))
+		buf.Write(data)
+		if len(data)%2 != 0 {
+			buf.WriteString("\n")
+		}
+	}
+	require.NoError(t, os.WriteFile(arPath, buf.Bytes(), 0644))
+}
+
+// makeInspectDeb creates the deb package.
+func makeInspectDeb(t *testing.T, sign bool, compression util.CompressionOpts) string {
+	dir := t.TempDir()
+	controlDir := filepath.Join(dir, controlDirName)
+	require.NoError(t, os.MkdirAll(controlDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(controlDir, "control"), []byte(
+		"Package: app\nVersion: 1.2.3\nDepends: tarantool (>= 2.11), tt\n"+
+			"Description: test\n application\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(controlDir, PreInstScriptName),
+		[]byte("echo preinst\n"), 0755))
+	members := []string{
+		filepath.Join(dir, debianBinaryFileName),
+		filepath.Join(dir, controlArchiveName),
+		filepath.Join(dir, dataArchiveBaseName+compression.Ext()),
+	}
+	require.NoError(t, createDebianBinary(dir))
+	require.NoError(t, WriteTgzArchive(controlDir, members[1], true))
+	require.NoError(t, WriteCompressedTarArchive(makeInspectTree(t), members[2], compression,
+		true))
+	if sign {
+		signKey, err := loadSignKey(testSignKeyFile, "")
+		require.NoError(t, err)
+		signaturePath, err := createDebSignature(dir, members, signKey)
+		require.NoError(t, err)
+		members = append(members, signaturePath)
+	}
+	packagePath := filepath.Join(dir, "app_1.2.3.deb")
+	writeAr(t, packagePath, members)
+	return packagePath
+}
+
+func TestInspectDeb(t *testing.T) {
+	packagePath := makeInspectDeb(t, true, util.CompressionOpts{})
+	info, err := Inspect(packagePath, InspectOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, "deb", info.Format)
+	assert.Equal(t, []PackageField{{"Package", "app"}, {"Version", "1.2.3"},
+		{"Description", "test\napplication"}}, info.Metadata)
+	assert.Equal(t, []string{"tarantool (>= 2.11)", "tt"}, info.Dependencies)
+	assert.Equal(t, []PackageScript{{PreInstScriptName, "echo preinst\n"}}, info.Scripts)
+	paths := []string{}
+	for _, file := range info.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.Contains(t, paths, "/usr/share/tarantool/app/init.lua")
+
+	info, err = Inspect(packagePath, InspectOpts{Verify: true,
+		Keyring: testSignPublicKeyFile})
+	require.NoError(t, err)
+	assert.True(t, info.Verified())
+	assert.Equal(t, map[string]string{"data.tar.gz integrity": CheckOk,
+		"signature": CheckOk}, getChecks(info))
+
+	// Corrupt the data archive.
+	data, err := os.ReadFile(packagePath)
+	require.NoError(t, err)
+	dataArchive, err := os.ReadFile(filepath.Join(filepath.Dir(packagePath), "data.tar.gz"))
+	require.NoError(t, err)
+	offset := bytes.Index(data, dataArchive)
+	require.Greater(t, offset, 0)
+	data[offset+len(dataArchive)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(packagePath, data, 0644))
+	info, err = Inspect(packagePath, InspectOpts{Verify: true,
+		Keyring: testSignPublicKeyFile})
+	require.NoError(t, err)
+	assert.False(t, info.Verified())
+	assert.Equal(t, CheckFailed, getChecks(info)["signature"])
+
+	info, err = Inspect(makeInspectDeb(t, false, util.CompressionOpts{Type: util.CompressionZstd}),
+		InspectOpts{Verify: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"data.tar.zst integrity": CheckOk,
+		"signature": CheckSkipped}, getChecks(info))
+	assert.NotEmpty(t, info.Files)
+}
+
+func TestInspectTgz(t *testing.T) {
+	packagePath := filepath.Join(t.TempDir(), "app.tar.gz")
+	require.NoError(t, WriteTgzArchive(makeInspectTree(t), packagePath, true))
+	info, err := Inspect(packagePath, InspectOpts{Verify: true})
+	require.NoError(t, err)
+	assert.Equal(t, "tgz", info.Format)
+	assert.True(t, info.Verified())
+
+	buf := bytes.Buffer{}
+	require.NoError(t, WritePackageInfo(&buf, info))
+	assert.Contains(t, buf.String(), "Format: tgz\n")
+	assert.Contains(t, buf.String(),
+		"  -rw-r--r-- root/root          9 usr/share/tarantool/app/init.lua\n")
+	assert.Contains(t, buf.String(), " usr/share/tarantool/app/main.lua -> init.lua\n")
+	assert.Contains(t, buf.String(), "\nVerification:\n  ok      archive integrity\n")
+
+	_, err = Inspect(testSignPublicKeyFile, InspectOpts{})
+	assert.ErrorContains(t, err, "unknown package format")
+
+	for _, compression := range []string{util.CompressionXz, util.CompressionZstd} {
+		opts := util.CompressionOpts{Type: compression}
+		packagePath := filepath.Join(t.TempDir(), "app.tar"+opts.Ext())
+		require.NoError(t, WriteCompressedTarArchive(makeInspectTree(t), packagePath, opts,
+			true))
+		info, err := Inspect(packagePath, InspectOpts{Verify: true})
+		require.NoError(t, err)
+		assert.Equal(t, []PackageField{{"Compression", compression}}, info.Metadata)
+		assert.True(t, info.Verified())
+		assert.NotEmpty(t, info.Files)
+	}
+}
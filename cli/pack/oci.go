@@ -0,0 +1,655 @@
+package pack
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/otiai10/copy"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/util"
+)
+
+const (
+	// ociIndexFile is the index of the OCI image layout.
+	ociIndexFile = "index.json"
+	// ociBlobsDir is the directory with the blobs of the OCI image layout.
+	ociBlobsDir = "blobs"
+	// dockerManifestFile is the manifest of the image saved by docker save.
+	dockerManifestFile = "manifest.json"
+	// dockerManifestMediaType is the media type of the docker image manifest.
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	// dockerManifestListMediaType is the media type of the docker manifest list.
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	// ociDefaultPath is the PATH used if it is not set in the base image.
+	ociDefaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// ociBlob is a blob of the image stored on the disk.
+type ociBlob struct {
+	// descriptor is the blob descriptor.
+	descriptor ocispec.Descriptor
+	// path is the blob file path.
+	path string
+}
+
+// baseImage is the image the bundle layer is added to.
+type baseImage struct {
+	// config is the image configuration.
+	config ocispec.Image
+	// layers contains the image layers.
+	layers []ociBlob
+}
+
+// ociImageOpts contains options of the built image.
+type ociImageOpts struct {
+	// baseImage is a path to the base image tarball.
+	baseImage string
+	// name is the image name.
+	name string
+	// version is the image version.
+	version string
+	// exposedPorts contains the ports exposed by the image.
+	exposedPorts []string
+	// env contains additional environment variables in the KEY=VALUE format.
+	env []string
+	// created is the image creation time.
+	created time.Time
+	// reproducible normalizes the bundle layer.
+	reproducible bool
+}
+
+// ociPacker is a structure that implements Packer interface
+// with specific OCI image packing behavior.
+type ociPacker struct {
+}
+
+// Run packs a bundle into the OCI image layout tarball.
+func (packer *ociPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
+	opts *config.CliOpts) error {
+	if packCtx.Oci.BaseImage == "" {
+		return fmt.Errorf("the base image tarball is required to pack an OCI image, " +
+			"set it with --base-image")
+	}
+	if packCtx.WithoutBinaries {
+		return fmt.Errorf("the image entrypoint runs tt from the package, " +
+			"--without-binaries can not be used while packing OCI image")
+	}
+
+	bundlePath, err := prepareBundle(cmdCtx, packCtx, opts, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := os.RemoveAll(bundlePath)
+		if err != nil {
+			log.Warnf("Failed to remove a temporary directory %s: %s",
+				bundlePath, err.Error())
+		}
+	}()
+
+	name, err := getPackageName(packCtx, opts, "", false)
+	if err != nil {
+		return err
+	}
+	ociSuffix, err := getOciSuffix()
+	if err != nil {
+		return err
+	}
+	imagePath, err := getPackageName(packCtx, opts, ociSuffix, true)
+	if err != nil {
+		return err
+	}
+
+	imageOpts := ociImageOpts{
+		baseImage:    packCtx.Oci.BaseImage,
+		name:         name,
+		version:      getVersion(packCtx, opts, defaultLongVersion),
+		exposedPorts: packCtx.Oci.ExposedPorts,
+		env:          packCtx.Oci.Env,
+		created:      time.Now(),
+		reproducible: packCtx.Reproducible,
+	}
+	if packCtx.Reproducible {
+		imageOpts.created = packCtx.SourceDate
+	}
+
+	log.Infof("Creating OCI image.")
+	if err = writeOciImage(bundlePath, imageOpts, imagePath); err != nil {
+		os.Remove(imagePath)
+		return fmt.Errorf("failed to create OCI image: %s", err)
+	}
+	log.Infof("Created result OCI image: %s", imagePath)
+	return copySbom(bundlePath, imagePath, packCtx)
+}
+
+// getOciSuffix returns suffix for an OCI image tarball.
+func getOciSuffix() (string, error) {
+	arch, err := util.GetArch()
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{"", arch, "oci", "tar"}, "."), nil
+}
+
+// isGzip checks if the file is compressed with gzip.
+func isGzip(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	magic := make([]byte, 2)
+	if _, err = io.ReadFull(file, magic); err == io.ErrUnexpectedEOF || err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return magic[0] == 0x1f && magic[1] == 0x8b, nil
+}
+
+// writeImageFile writes the file extracted from the image.
+func writeImageFile(path string, reader io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, reader); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// extractImage extracts the image tarball to the directory. Symlinks and
+// hardlinks are extracted only if they point inside the directory.
+func extractImage(imagePath string, dir string) error {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if compressed, err := isGzip(imagePath); err != nil {
+		return err
+	} else if compressed {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the image %s: %s", imagePath, err)
+		}
+		name := filepath.Clean(header.Name)
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			return fmt.Errorf("invalid path %q in the image %s", header.Name, imagePath)
+		}
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), dirPermissions); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, dirPermissions)
+		case tar.TypeReg:
+			err = writeImageFile(path, tarReader)
+		case tar.TypeSymlink:
+			target := filepath.Join(filepath.Dir(name), header.Linkname)
+			if filepath.IsAbs(header.Linkname) || strings.HasPrefix(target, "..") {
+				return fmt.Errorf("invalid link %q in the image %s", header.Name, imagePath)
+			}
+			err = os.Symlink(header.Linkname, path)
+		case tar.TypeLink:
+			target := filepath.Clean(header.Linkname)
+			if filepath.IsAbs(target) || strings.HasPrefix(target, "..") {
+				return fmt.Errorf("invalid link %q in the image %s", header.Name, imagePath)
+			}
+			err = os.Link(filepath.Join(dir, target), path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readJSON reads the JSON file into the value.
+func readJSON(path string, value interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("failed to parse %s: %s", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// getBlobPath returns the path of the blob in the OCI image layout.
+func getBlobPath(dir string, blobDigest digest.Digest) (string, error) {
+	if err := blobDigest.Validate(); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ociBlobsDir, blobDigest.Algorithm().String(),
+		blobDigest.Encoded()), nil
+}
+
+// findManifest finds the image manifest for the current architecture in the
+// OCI image index. Nested indexes are checked.
+func findManifest(dir string, index ocispec.Index) (ocispec.Descriptor, error) {
+	for _, descriptor := range index.Manifests {
+		if descriptor.Platform != nil && (descriptor.Platform.OS != "linux" ||
+			descriptor.Platform.Architecture != runtime.GOARCH) {
+			continue
+		}
+		switch descriptor.MediaType {
+		case ocispec.MediaTypeImageManifest, dockerManifestMediaType:
+			return descriptor, nil
+		case ocispec.MediaTypeImageIndex, dockerManifestListMediaType:
+			indexPath, err := getBlobPath(dir, descriptor.Digest)
+			if err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			nestedIndex := ocispec.Index{}
+			if err = readJSON(indexPath, &nestedIndex); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+			if manifest, err := findManifest(dir, nestedIndex); err == nil {
+				return manifest, nil
+			}
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("the image manifest for linux/%s is not found",
+		runtime.GOARCH)
+}
+
+// readOciLayout reads the base image from the extracted OCI image layout.
+func readOciLayout(dir string) (baseImage, error) {
+	image := baseImage{}
+	index := ocispec.Index{}
+	if err := readJSON(filepath.Join(dir, ociIndexFile), &index); err != nil {
+		return image, err
+	}
+	manifestDescriptor, err := findManifest(dir, index)
+	if err != nil {
+		return image, err
+	}
+	manifestPath, err := getBlobPath(dir, manifestDescriptor.Digest)
+	if err != nil {
+		return image, err
+	}
+	manifest := ocispec.Manifest{}
+	if err = readJSON(manifestPath, &manifest); err != nil {
+		return image, err
+	}
+
+	configPath, err := getBlobPath(dir, manifest.Config.Digest)
+	if err != nil {
+		return image, err
+	}
+	if err = readJSON(configPath, &image.config); err != nil {
+		return image, err
+	}
+	for _, layer := range manifest.Layers {
+		layerPath, err := getBlobPath(dir, layer.Digest)
+		if err != nil {
+			return image, err
+		}
+		image.layers = append(image.layers, ociBlob{descriptor: layer, path: layerPath})
+	}
+	return image, nil
+}
+
+// getFileDescriptor returns the descriptor of the file.
+func getFileDescriptor(path string, mediaType string) (ocispec.Descriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer file.Close()
+	fileDigest, err := digest.SHA256.FromReader(file)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return ocispec.Descriptor{MediaType: mediaType, Digest: fileDigest,
+		Size: stat.Size()}, nil
+}
+
+// readDockerArchive reads the base image from the extracted docker save
+// archive.
+func readDockerArchive(dir string) (baseImage, error) {
+	image := baseImage{}
+	manifest := []struct {
+		Config string
+		Layers []string
+	}{}
+	if err := readJSON(filepath.Join(dir, dockerManifestFile), &manifest); err != nil {
+		return image, err
+	}
+	if len(manifest) == 0 {
+		return image, fmt.Errorf("%s contains no images", dockerManifestFile)
+	}
+
+	if err := readJSON(filepath.Join(dir, manifest[0].Config), &image.config); err != nil {
+		return image, err
+	}
+	for _, layer := range manifest[0].Layers {
+		layerPath := filepath.Join(dir, layer)
+		mediaType := ocispec.MediaTypeImageLayer
+		if compressed, err := isGzip(layerPath); err != nil {
+			return image, err
+		} else if compressed {
+			mediaType = ocispec.MediaTypeImageLayerGzip
+		}
+		descriptor, err := getFileDescriptor(layerPath, mediaType)
+		if err != nil {
+			return image, err
+		}
+		image.layers = append(image.layers, ociBlob{descriptor: descriptor, path: layerPath})
+	}
+	return image, nil
+}
+
+// readBaseImage reads the base image from the OCI image layout or docker save
+// tarball extracted to the directory.
+func readBaseImage(imagePath string, dir string) (baseImage, error) {
+	if err := extractImage(imagePath, dir); err != nil {
+		return baseImage{}, err
+	}
+	if util.IsRegularFile(filepath.Join(dir, ocispec.ImageLayoutFile)) {
+		return readOciLayout(dir)
+	}
+	if util.IsRegularFile(filepath.Join(dir, dockerManifestFile)) {
+		return readDockerArchive(dir)
+	}
+	return baseImage{}, fmt.Errorf("%s is neither an OCI image layout nor a docker image "+
+		"archive", imagePath)
+}
+
+// writeBundleLayer writes the bundle layer to the gzip compressed tarball.
+// The layer descriptor and the digest of the uncompressed layer are returned.
+func writeBundleLayer(layerRoot string, layerPath string) (ocispec.Descriptor,
+	digest.Digest, error) {
+	layerFile, err := os.Create(layerPath)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	defer layerFile.Close()
+
+	layerDigester := digest.SHA256.Digester()
+	gzipWriter := gzip.NewWriter(io.MultiWriter(layerFile, layerDigester.Hash()))
+	diffDigester := digest.SHA256.Digester()
+	// Files in the image are owned by root.
+	err = WriteTarArchive(layerRoot, io.MultiWriter(gzipWriter, diffDigester.Hash()), true)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	stat, err := layerFile.Stat()
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest: layerDigester.Digest(), Size: stat.Size()}, diffDigester.Digest(), nil
+}
+
+// setEnv sets the environment variable in the KEY=VALUE list.
+func setEnv(env []string, keyValue string) []string {
+	key, _, _ := strings.Cut(keyValue, "=")
+	for i, item := range env {
+		if strings.HasPrefix(item, key+"=") {
+			env[i] = keyValue
+			return env
+		}
+	}
+	return append(env, keyValue)
+}
+
+// genImageConfig generates the image configuration based on the base image one.
+// The entrypoint starts the environment instances in the foreground.
+func genImageConfig(base ocispec.Image, imageOpts ociImageOpts, envPath string,
+	diffID digest.Digest) (ocispec.Image, error) {
+	config := base
+	config.Created = &imageOpts.created
+	config.Config.Entrypoint = []string{"tt", "start", "--foreground"}
+	config.Config.Cmd = nil
+	config.Config.WorkingDir = envPath
+
+	env := append([]string{}, base.Config.Env...)
+	path := ociDefaultPath
+	for _, item := range env {
+		if strings.HasPrefix(item, "PATH=") {
+			path = strings.TrimPrefix(item, "PATH=")
+		}
+	}
+	env = setEnv(env, "PATH="+filepath.Join(envPath, "bin")+":"+path)
+	for _, item := range imageOpts.env {
+		if !strings.Contains(item, "=") {
+			return config, fmt.Errorf("invalid environment variable %q, KEY=VALUE is expected",
+				item)
+		}
+		env = setEnv(env, item)
+	}
+	config.Config.Env = env
+
+	if len(imageOpts.exposedPorts) > 0 {
+		ports := map[string]struct{}{}
+		for port := range base.Config.ExposedPorts {
+			ports[port] = struct{}{}
+		}
+		for _, port := range imageOpts.exposedPorts {
+			if !strings.Contains(port, "/") {
+				port += "/tcp"
+			}
+			ports[port] = struct{}{}
+		}
+		config.Config.ExposedPorts = ports
+	}
+
+	config.RootFS.DiffIDs = append(append([]digest.Digest{}, base.RootFS.DiffIDs...),
+		diffID)
+	config.History = append(append([]ocispec.History{}, base.History...),
+		ocispec.History{Created: &imageOpts.created, CreatedBy: "tt pack oci"})
+	return config, nil
+}
+
+// writeTarFile writes the file to the tarball.
+func writeTarFile(tarWriter *tar.Writer, name string, reader io.Reader, size int64,
+	modTime time.Time) error {
+	header := tar.Header{
+		Name:    name,
+		Mode:    reproducibleFileMode,
+		Size:    size,
+		ModTime: modTime,
+	}
+	normalizeTarHeader(&header)
+	if err := tarWriter.WriteHeader(&header); err != nil {
+		return err
+	}
+	_, err := io.CopyN(tarWriter, reader, size)
+	return err
+}
+
+// writeOciLayout writes the OCI image layout tarball with the blobs and the
+// index of the image.
+func writeOciLayout(imagePath string, blobs []ociBlob, jsonBlobs map[digest.Digest][]byte,
+	index ocispec.Index, modTime time.Time) error {
+	imageFile, err := os.Create(imagePath)
+	if err != nil {
+		return err
+	}
+	defer imageFile.Close()
+	tarWriter := tar.NewWriter(imageFile)
+
+	for _, blob := range blobs {
+		name := filepath.Join(ociBlobsDir, blob.descriptor.Digest.Algorithm().String(),
+			blob.descriptor.Digest.Encoded())
+		var reader io.Reader
+		if content, found := jsonBlobs[blob.descriptor.Digest]; found {
+			reader = bytes.NewReader(content)
+		} else {
+			blobFile, err := os.Open(blob.path)
+			if err != nil {
+				return err
+			}
+			defer blobFile.Close()
+			reader = blobFile
+		}
+		err = writeTarFile(tarWriter, name, reader, blob.descriptor.Size, modTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	indexContent, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{{ocispec.ImageLayoutFile, layout}, {ociIndexFile, indexContent}} {
+		err = writeTarFile(tarWriter, file.name, bytes.NewReader(file.content),
+			int64(len(file.content)), modTime)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	return imageFile.Close()
+}
+
+// writeOciImage writes the OCI image layout tarball with the bundle layer added
+// on top of the base image layers.
+func writeOciImage(bundlePath string, imageOpts ociImageOpts, imagePath string) error {
+	workDir, err := os.MkdirTemp("", "tt_pack_oci")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	log.Infof("Reading the base image %s.", imageOpts.baseImage)
+	baseDir := filepath.Join(workDir, "base")
+	base, err := readBaseImage(imageOpts.baseImage, baseDir)
+	if err != nil {
+		return err
+	}
+
+	// The bundle is placed to the same directory as in RPM and DEB packages.
+	envPath := filepath.Join("/", defaultEnvPrefix, imageOpts.name)
+	layerRoot := filepath.Join(workDir, "layer")
+	if err = copy.Copy(bundlePath, filepath.Join(layerRoot, envPath)); err != nil {
+		return err
+	}
+	if imageOpts.reproducible {
+		err = normalizeTree(layerRoot, imageOpts.created)
+	} else {
+		err = os.Chmod(layerRoot, reproducibleExecMode)
+	}
+	if err != nil {
+		return err
+	}
+	layerPath := filepath.Join(workDir, "layer.tar.gz")
+	layer, diffID, err := writeBundleLayer(layerRoot, layerPath)
+	if err != nil {
+		return err
+	}
+
+	config, err := genImageConfig(base.config, imageOpts, envPath, diffID)
+	if err != nil {
+		return err
+	}
+	configContent, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	configDescriptor := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig,
+		Digest: digest.FromBytes(configContent), Size: int64(len(configContent))}
+
+	blobs := append(append([]ociBlob{}, base.layers...),
+		ociBlob{descriptor: layer, path: layerPath})
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDescriptor,
+	}
+	for _, blob := range blobs {
+		manifest.Layers = append(manifest.Layers, blob.descriptor)
+	}
+	manifestContent, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestDescriptor := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest,
+		Digest: digest.FromBytes(manifestContent), Size: int64(len(manifestContent)),
+		Annotations: map[string]string{
+			ocispec.AnnotationRefName: imageOpts.name + ":" + imageOpts.version,
+		},
+		Platform: &ocispec.Platform{OS: config.OS, Architecture: config.Architecture},
+	}
+
+	jsonBlobs := map[digest.Digest][]byte{
+		configDescriptor.Digest:   configContent,
+		manifestDescriptor.Digest: manifestContent,
+	}
+	blobs = append(blobs, ociBlob{descriptor: configDescriptor},
+		ociBlob{descriptor: manifestDescriptor})
+	// Blobs are written once and in the same order.
+	sort.SliceStable(blobs, func(i, j int) bool {
+		return blobs[i].descriptor.Digest < blobs[j].descriptor.Digest
+	})
+	uniqueBlobs := []ociBlob{}
+	for i, blob := range blobs {
+		if i == 0 || blob.descriptor.Digest != blobs[i-1].descriptor.Digest {
+			uniqueBlobs = append(uniqueBlobs, blob)
+		}
+	}
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{manifestDescriptor},
+	}
+	return writeOciLayout(imagePath, uniqueBlobs, jsonBlobs, index, imageOpts.created)
+}
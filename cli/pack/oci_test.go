@@ -0,0 +1,297 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/util"
+)
+
+// makeTar returns the tarball with the passed files.
+func makeTar(t *testing.T, files map[string][]byte, names ...string) []byte {
+	buf := bytes.Buffer{}
+	tarWriter := tar.NewWriter(&buf)
+	for _, name := range names {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644,
+			Size: int64(len(files[name]))}))
+		_, err := tarWriter.Write(files[name])
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	return buf.Bytes()
+}
+
+// makeGzip returns the gzip compressed data.
+func makeGzip(t *testing.T, data []byte) []byte {
+	buf := bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// makeBaseConfig returns the base image configuration.
+func makeBaseConfig(t *testing.T, diffID digest.Digest) []byte {
+	config, err := json.Marshal(ocispec.Image{
+		Architecture: runtime.GOARCH,
+		OS:           "linux",
+		Config: ocispec.ImageConfig{
+			Env:          []string{"PATH=/usr/bin:/bin", "LANG=C.UTF-8"},
+			ExposedPorts: map[string]struct{}{"8080/tcp": {}},
+			Cmd:          []string{"/bin/sh"},
+		},
+		RootFS: ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{diffID}},
+	})
+	require.NoError(t, err)
+	return config
+}
+
+// blobName returns the name of the blob in the OCI image layout.
+func blobName(blobDigest digest.Digest) string {
+	return filepath.Join(ociBlobsDir, blobDigest.Algorithm().String(), blobDigest.Encoded())
+}
+
+// makeOciBaseImage writes the OCI image layout base image with a single layer.
+func makeOciBaseImage(t *testing.T, path string) {
+	layerTar := makeTar(t, map[string][]byte{"bin/sh": []byte("sh")}, "bin/sh")
+	layer := makeGzip(t, layerTar)
+	config := makeBaseConfig(t, digest.FromBytes(layerTar))
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig,
+			Digest: digest.FromBytes(config), Size: int64(len(config))},
+		Layers: []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip,
+			Digest: digest.FromBytes(layer), Size: int64(len(layer))}},
+	})
+	require.NoError(t, err)
+	// The manifest for the other platform is skipped.
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{
+			{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("other"),
+				Platform: &ocispec.Platform{OS: "windows", Architecture: runtime.GOARCH}},
+			{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromBytes(manifest),
+				Size: int64(len(manifest))},
+		},
+	})
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		ocispec.ImageLayoutFile:              []byte(`{"imageLayoutVersion":"1.0.0"}`),
+		ociIndexFile:                         index,
+		blobName(digest.FromBytes(layer)):    layer,
+		blobName(digest.FromBytes(config)):   config,
+		blobName(digest.FromBytes(manifest)): manifest,
+	}
+	names := []string{ocispec.ImageLayoutFile, ociIndexFile}
+	for name := range files {
+		if name != ocispec.ImageLayoutFile && name != ociIndexFile {
+			names = append(names, name)
+		}
+	}
+	require.NoError(t, os.WriteFile(path, makeTar(t, files, names...), 0644))
+}
+
+// makeDockerBaseImage writes the docker save base image with a single layer.
+func makeDockerBaseImage(t *testing.T, path string) {
+	layer := makeTar(t, map[string][]byte{"bin/sh": []byte("sh")}, "bin/sh")
+	config := makeBaseConfig(t, digest.FromBytes(layer))
+	files := map[string][]byte{
+		dockerManifestFile: []byte(`[{"Config":"config.json","RepoTags":["base:latest"],` +
+			`"Layers":["0123/layer.tar"]}]`),
+		"config.json":    config,
+		"0123/layer.tar": layer,
+	}
+	require.NoError(t, os.WriteFile(path, makeGzip(t, makeTar(t, files, "config.json",
+		"0123/layer.tar", dockerManifestFile)), 0644))
+}
+
+// readTar reads all files of the tarball.
+func readTar(t *testing.T, reader io.Reader) map[string][]byte {
+	files := map[string][]byte{}
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = content
+	}
+}
+
+// makeOciBundle creates the bundle to pack.
+func makeOciBundle(t *testing.T) string {
+	bundlePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "tt.yaml"), []byte("tt:\n"),
+		0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(bundlePath, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bundlePath, "app", "init.lua"),
+		[]byte("return {}"), 0644))
+	return bundlePath
+}
+
+func TestWriteOciImage(t *testing.T) {
+	for _, format := range []string{"oci", "docker"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			baseImage := filepath.Join(dir, "base.tar")
+			if format == "oci" {
+				makeOciBaseImage(t, baseImage)
+			} else {
+				makeDockerBaseImage(t, baseImage)
+			}
+
+			imagePath := filepath.Join(dir, "image.tar")
+			require.NoError(t, writeOciImage(makeOciBundle(t), ociImageOpts{
+				baseImage:    baseImage,
+				name:         "app",
+				version:      "1.2.3.4",
+				exposedPorts: []string{"3301", "3302/udp"},
+				env:          []string{"LANG=en_US.UTF-8", "TT_LISTEN=3301"},
+				created:      time.Now(),
+			}, imagePath))
+
+			imageFile, err := os.Open(imagePath)
+			require.NoError(t, err)
+			defer imageFile.Close()
+			files := readTar(t, imageFile)
+			for name, content := range files {
+				if filepath.Dir(name) == filepath.Join(ociBlobsDir, "sha256") {
+					assert.Equal(t, filepath.Base(name), digest.FromBytes(content).Encoded())
+				}
+			}
+			assert.JSONEq(t, `{"imageLayoutVersion":"1.0.0"}`,
+				string(files[ocispec.ImageLayoutFile]))
+
+			index := ocispec.Index{}
+			require.NoError(t, json.Unmarshal(files[ociIndexFile], &index))
+			require.Len(t, index.Manifests, 1)
+			assert.Equal(t, "app:1.2.3.4",
+				index.Manifests[0].Annotations[ocispec.AnnotationRefName])
+			manifest := ocispec.Manifest{}
+			require.NoError(t, json.Unmarshal(files[blobName(index.Manifests[0].Digest)],
+				&manifest))
+			require.Len(t, manifest.Layers, 2)
+			for _, layer := range manifest.Layers {
+				require.Contains(t, files, blobName(layer.Digest))
+				assert.Equal(t, layer.Size, int64(len(files[blobName(layer.Digest)])))
+			}
+
+			config := ocispec.Image{}
+			require.NoError(t, json.Unmarshal(files[blobName(manifest.Config.Digest)],
+				&config))
+			assert.Equal(t, runtime.GOARCH, config.Architecture)
+			assert.Equal(t, []string{"tt", "start", "--foreground"}, config.Config.Entrypoint)
+			assert.Nil(t, config.Config.Cmd)
+			assert.Equal(t, "/usr/share/tarantool/app", config.Config.WorkingDir)
+			assert.Equal(t, []string{"PATH=/usr/share/tarantool/app/bin:/usr/bin:/bin",
+				"LANG=en_US.UTF-8", "TT_LISTEN=3301"}, config.Config.Env)
+			assert.Equal(t, map[string]struct{}{"8080/tcp": {}, "3301/tcp": {},
+				"3302/udp": {}}, config.Config.ExposedPorts)
+			require.Len(t, config.RootFS.DiffIDs, 2)
+			require.Len(t, config.History, 1)
+
+			layer := files[blobName(manifest.Layers[1].Digest)]
+			gzipReader, err := gzip.NewReader(bytes.NewReader(layer))
+			require.NoError(t, err)
+			layerTar, err := io.ReadAll(gzipReader)
+			require.NoError(t, err)
+			assert.Equal(t, config.RootFS.DiffIDs[1], digest.FromBytes(layerTar))
+			layerFiles := readTar(t, bytes.NewReader(layerTar))
+			assert.Equal(t, []byte("return {}"),
+				layerFiles["usr/share/tarantool/app/app/init.lua"])
+			assert.Contains(t, layerFiles, "usr/share/tarantool/app/tt.yaml")
+		})
+	}
+}
+
+func TestWriteOciImageReproducible(t *testing.T) {
+	dir := t.TempDir()
+	baseImage := filepath.Join(dir, "base.tar")
+	makeOciBaseImage(t, baseImage)
+
+	checksums := []string{}
+	for i := 0; i < 2; i++ {
+		imagePath := filepath.Join(dir, "image.tar")
+		require.NoError(t, writeOciImage(makeOciBundle(t), ociImageOpts{
+			baseImage:    baseImage,
+			name:         "app",
+			version:      "1.0.0.0",
+			created:      time.Unix(1700000000, 0),
+			reproducible: true,
+		}, imagePath))
+		checksum, err := util.FileSHA256Hex(imagePath)
+		require.NoError(t, err)
+		checksums = append(checksums, checksum)
+	}
+	assert.Equal(t, checksums[0], checksums[1])
+}
+
+func TestWriteOciImageInvalidBase(t *testing.T) {
+	dir := t.TempDir()
+	baseImage := filepath.Join(dir, "base.tar")
+	require.NoError(t, os.WriteFile(baseImage, makeTar(t, map[string][]byte{"file": {}},
+		"file"), 0644))
+	err := writeOciImage(makeOciBundle(t), ociImageOpts{baseImage: baseImage, name: "app"},
+		filepath.Join(dir, "image.tar"))
+	assert.ErrorContains(t, err, "is neither an OCI image layout nor a docker image archive")
+
+	require.NoError(t, os.WriteFile(baseImage, makeTar(t,
+		map[string][]byte{"../file": {}}, "../file"), 0644))
+	err = writeOciImage(makeOciBundle(t), ociImageOpts{baseImage: baseImage, name: "app"},
+		filepath.Join(dir, "image.tar"))
+	assert.ErrorContains(t, err, `invalid path "../file"`)
+}
+
+func TestExtractImageHardlinks(t *testing.T) {
+	makeLinkTar := func(linkname string) []byte {
+		buf := bytes.Buffer{}
+		tarWriter := tar.NewWriter(&buf)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "bin/tarantool",
+			Mode: 0755, Size: 4}))
+		_, err := tarWriter.Write([]byte("tnt\n"))
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "usr/bin/tarantool",
+			Typeflag: tar.TypeLink, Linkname: linkname}))
+		require.NoError(t, tarWriter.Close())
+		return buf.Bytes()
+	}
+
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "image.tar")
+	require.NoError(t, os.WriteFile(imagePath, makeLinkTar("./bin/tarantool"), 0644))
+	extractDir := filepath.Join(dir, "extracted")
+	require.NoError(t, extractImage(imagePath, extractDir))
+	data, err := os.ReadFile(filepath.Join(extractDir, "usr", "bin", "tarantool"))
+	require.NoError(t, err)
+	assert.Equal(t, "tnt\n", string(data))
+
+	require.NoError(t, os.WriteFile(imagePath, makeLinkTar("../bin/tarantool"), 0644))
+	err = extractImage(imagePath, filepath.Join(dir, "invalid"))
+	assert.ErrorContains(t, err, `invalid link "usr/bin/tarantool"`)
+}
+
+func TestOciPackerWithoutBinaries(t *testing.T) {
+	packer := ociPacker{}
+	err := packer.Run(nil, &PackCtx{WithoutBinaries: true,
+		Oci: OciCtx{BaseImage: "base.tar"}}, nil)
+	assert.ErrorContains(t, err, "--without-binaries can not be used while packing OCI image")
+}
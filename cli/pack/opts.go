@@ -2,15 +2,18 @@ package pack
 
 import (
 	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/util"
 )
 
 type PackageType string
 
 const (
-	Tgz    = "tgz"
-	Rpm    = "rpm"
-	Deb    = "deb"
-	Docker = "docker"
+	Tgz         = "tgz"
+	Rpm         = "rpm"
+	Deb         = "deb"
+	Docker      = "docker"
+	Oci         = "oci"
+	Selfextract = "selfextract"
 )
 
 // FillCtx fills pack context.
@@ -21,5 +24,25 @@ func FillCtx(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	packCtx.TarantoolExecutable = cmdCtx.Cli.TarantoolExecutable
 	packCtx.Type = args[0]
 
+	if err := checkSbomFormat(packCtx.Sbom); err != nil {
+		return err
+	}
+	if err := util.ValidateCompression(packCtx.Compression); err != nil {
+		return err
+	}
+	if packCtx.RpmDeb.SystemdUnitParamsFile != "" {
+		if _, err := loadUnitParams(packCtx.RpmDeb.SystemdUnitParamsFile); err != nil {
+			return err
+		}
+	}
+
+	if packCtx.Reproducible {
+		sourceDate, err := getSourceDate()
+		if err != nil {
+			return err
+		}
+		packCtx.SourceDate = sourceDate
+	}
+
 	return nil
 }
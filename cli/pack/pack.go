@@ -1,5 +1,11 @@
 package pack
 
+import (
+	"time"
+
+	"github.com/tarantool/tt/cli/util"
+)
+
 // PackCtx contains all flags for tt pack command.
 type PackCtx struct {
 	// Type contains a type of packing.
@@ -24,10 +30,29 @@ type PackCtx struct {
 	Archive ArchiveCtx
 	// RpmDeb contains all information about rpm and deb type of packing.
 	RpmDeb RpmDebCtx
+	// Oci contains flags specific for OCI image type.
+	Oci OciCtx
 	// UseDocker is set if a package must be built in docker container.
 	UseDocker bool
 	// CartridgeCompat enables backward compatibility with cartridge cli.
 	CartridgeCompat bool
+	// Reproducible enables building of byte-identical packages from identical
+	// inputs: ownership, permissions and timestamps of the packed files are
+	// normalized.
+	Reproducible bool
+	// SourceDate is the timestamp of the packed files in the reproducible mode.
+	SourceDate time.Time
+	// Exclude contains gitignore-like patterns of the application files
+	// excluded from the package in addition to the .packignore file ones.
+	Exclude []string
+	// DryRun lists the files that would be packed without creating a package.
+	DryRun bool
+	// Sbom is the format of the software bill of materials embedded into the
+	// package and written next to it: cyclonedx or spdx. Empty if not set.
+	Sbom string
+	// Compression is the compression of the tgz archive, the rpm payload and
+	// the deb data archive.
+	Compression util.CompressionOpts
 }
 
 // ArchiveCtx contains flags specific for tgz type.
@@ -36,6 +61,17 @@ type ArchiveCtx struct {
 	All bool
 }
 
+// OciCtx contains flags specific for OCI image type.
+type OciCtx struct {
+	// BaseImage is a path to the base image tarball: OCI image layout or
+	// docker save archive.
+	BaseImage string
+	// ExposedPorts contains the ports exposed by the image.
+	ExposedPorts []string
+	// Env contains environment variables of the image in the KEY=VALUE format.
+	Env []string
+}
+
 // RpmDebCtx contains flags specific for RPM/DEB type.
 type RpmDebCtx struct {
 	// WithTarantoolDeps means to add to package dependencies versions
@@ -45,6 +81,16 @@ type RpmDebCtx struct {
 	PreInst string
 	// PostInst is a path to post-install script.
 	PostInst string
+	// PreRm is a path to pre-remove script.
+	PreRm string
+	// PostRm is a path to post-remove script.
+	PostRm string
+	// SystemUser is the system user created by the package to run the
+	// instances and to own the data directories.
+	SystemUser string
+	// SystemGroup is the group of the system user. The user name is used
+	// if it is not set.
+	SystemGroup string
 	// Deps is dependencies list. Format:
 	// dependency_06>=4
 	Deps []string
@@ -52,4 +98,9 @@ type RpmDebCtx struct {
 	DepsFile string
 	// SystemdUnitParamsFile is a path to file with systemd unit parameters.
 	SystemdUnitParamsFile string
+	// SignKey is a path to the OpenPGP secret key file or an ID of the key
+	// in the keyring to sign the package.
+	SignKey string
+	// SignKeyring is a path to the keyring file with the signing key.
+	SignKeyring string
 }
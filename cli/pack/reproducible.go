@@ -0,0 +1,89 @@
+package pack
+
+import (
+	"archive/tar"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sourceDateEpochEnv is the environment variable with the timestamp of
+	// the packed files in the reproducible mode, see
+	// https://reproducible-builds.org/specs/source-date-epoch/
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+	// reproducibleOwner is the owner of the packed files in the reproducible mode.
+	reproducibleOwner = "root"
+	// reproducibleFileMode is the mode of non-executable files in the
+	// reproducible mode.
+	reproducibleFileMode = 0644
+	// reproducibleExecMode is the mode of executable files and directories in
+	// the reproducible mode.
+	reproducibleExecMode = 0755
+	// reproduciblePrivateMask is applied to the mode of items that are not
+	// readable by others in the reproducible mode.
+	reproduciblePrivateMask = 0700
+)
+
+// getSourceDate returns the timestamp of the packed files in the reproducible
+// mode. It is taken from SOURCE_DATE_EPOCH, the Unix epoch is used if it is
+// not set.
+func getSourceDate() (time.Time, error) {
+	value, isSet := os.LookupEnv(sourceDateEpochEnv)
+	if !isSet || value == "" {
+		return time.Unix(0, 0), nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, fmt.Errorf("invalid %s value %q: a non-negative number of "+
+			"seconds since the Unix epoch is expected", sourceDateEpochEnv, value)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// normalizeTree sets permissions and modification times of all items inside
+// the directory, so the packed items do not depend on the environment of the
+// build. Directories and executable files get 0755 mode, other files get 0644
+// mode. Items that are not readable by others keep being accessible by the
+// owner only: they get 0700 and 0600 modes. Symlinks keep their mode.
+func normalizeTree(dirPath string, sourceDate time.Time) error {
+	times := []unix.Timeval{unix.NsecToTimeval(sourceDate.UnixNano()),
+		unix.NsecToTimeval(sourceDate.UnixNano())}
+	return filepath.Walk(dirPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().Type() != fs.ModeSymlink {
+			mode := fs.FileMode(reproducibleFileMode)
+			if info.IsDir() || info.Mode().Perm()&0111 != 0 {
+				mode = reproducibleExecMode
+			}
+			if info.Mode().Perm()&0004 == 0 {
+				mode &= reproduciblePrivateMask
+			}
+			if err = os.Chmod(path, mode); err != nil {
+				return err
+			}
+		}
+		if err = unix.Lutimes(path, times); err != nil {
+			return fmt.Errorf("failed to set modification time of %s: %s", path, err)
+		}
+		return nil
+	})
+}
+
+// normalizeTarHeader removes the build environment specific information from
+// the tar header.
+func normalizeTarHeader(header *tar.Header) {
+	header.Uid = 0
+	header.Gid = 0
+	header.Uname = reproducibleOwner
+	header.Gname = reproducibleOwner
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+}
@@ -0,0 +1,171 @@
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/util"
+)
+
+func TestGetSourceDate(t *testing.T) {
+	t.Setenv(sourceDateEpochEnv, "")
+	sourceDate, err := getSourceDate()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sourceDate.Unix())
+
+	t.Setenv(sourceDateEpochEnv, "1700000000")
+	sourceDate, err = getSourceDate()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), sourceDate.Unix())
+
+	for _, value := range []string{"-1", "yesterday", "1.5"} {
+		t.Setenv(sourceDateEpochEnv, value)
+		_, err = getSourceDate()
+		assert.ErrorContains(t, err, "invalid SOURCE_DATE_EPOCH value")
+	}
+}
+
+// createPackageTree creates the same files with the passed modes and
+// modification time. The files are created in the passed order.
+func createPackageTree(t *testing.T, names []string, fileMode, dirMode os.FileMode,
+	modTime time.Time) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app/init.lua":     "return {}",
+		"app/bin/start.sh": "#!/bin/sh",
+		"app/VERSION":      "app=1.0.0\n",
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), dirMode))
+		mode := fileMode
+		if filepath.Ext(name) == ".sh" {
+			mode |= 0100
+		}
+		require.NoError(t, os.WriteFile(path, []byte(files[name]), mode))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	require.NoError(t, os.Symlink("init.lua", filepath.Join(dir, "app", "main.lua")))
+	return dir
+}
+
+func TestReproducibleArchives(t *testing.T) {
+	sourceDate := time.Unix(1700000000, 0)
+	dirs := []string{
+		createPackageTree(t, []string{"app/init.lua", "app/bin/start.sh", "app/VERSION"},
+			0644, 0755, time.Now()),
+		createPackageTree(t, []string{"app/VERSION", "app/bin/start.sh", "app/init.lua"},
+			0664, 0775, time.Now().Add(-time.Hour)),
+	}
+
+	tgzChecksums := []string{}
+	cpioChecksums := []string{}
+	for _, dir := range dirs {
+		require.NoError(t, normalizeTree(dir, sourceDate))
+		relPaths, err := getSortedRelPaths(dir)
+		require.NoError(t, err)
+
+		resDir := t.TempDir()
+		tgzPath := filepath.Join(resDir, "package.tar.gz")
+		require.NoError(t, WriteTgzArchive(dir, tgzPath, true))
+		checksum, err := util.FileSHA256Hex(tgzPath)
+		require.NoError(t, err)
+		tgzChecksums = append(tgzChecksums, checksum)
+
+		cpioPath := filepath.Join(resDir, "cpio")
+		require.NoError(t, packCpio(relPaths, cpioPath, dir, true))
+		checksum, err = util.FileSHA256Hex(cpioPath)
+		require.NoError(t, err)
+		cpioChecksums = append(cpioChecksums, checksum)
+
+		info, err := getFilesInfo(relPaths, dir, nil, true)
+		require.NoError(t, err)
+		for i := range relPaths {
+			assert.Equal(t, int32(i+1), info.FileInodes[i])
+			assert.Equal(t, int32(sourceDate.Unix()), info.FileMtimes[i])
+		}
+	}
+	assert.Equal(t, tgzChecksums[0], tgzChecksums[1])
+	assert.Equal(t, cpioChecksums[0], cpioChecksums[1])
+
+	// Check the normalized headers.
+	tgzPath := filepath.Join(t.TempDir(), "package.tar.gz")
+	require.NoError(t, WriteTgzArchive(dirs[0], tgzPath, true))
+	tgzFile, err := os.Open(tgzPath)
+	require.NoError(t, err)
+	defer tgzFile.Close()
+	gzipReader, err := gzip.NewReader(tgzFile)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	modes := map[string]int64{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, 0, header.Uid)
+		assert.Equal(t, 0, header.Gid)
+		assert.Equal(t, reproducibleOwner, header.Uname)
+		assert.Equal(t, reproducibleOwner, header.Gname)
+		assert.Equal(t, sourceDate.Unix(), header.ModTime.Unix())
+		modes[header.Name] = header.Mode & 0777
+		if header.Typeflag == tar.TypeSymlink {
+			assert.Equal(t, "init.lua", header.Linkname)
+		}
+	}
+	assert.Equal(t, map[string]int64{
+		".":                0755,
+		"app":              0755,
+		"app/VERSION":      0644,
+		"app/bin":          0755,
+		"app/bin/start.sh": 0755,
+		"app/init.lua":     0644,
+		"app/main.lua":     0777,
+	}, modes)
+}
+
+func TestNormalizeTreePrivateModes(t *testing.T) {
+	dir := t.TempDir()
+	modes := map[string]fs.FileMode{
+		"public":         0664,
+		"public.sh":      0775,
+		"private":        0600,
+		"private.sh":     0740,
+		"private_dir":    0750,
+		"private_dir/id": 0640,
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "private_dir"), 0750))
+	for name, mode := range modes {
+		path := filepath.Join(dir, name)
+		if name == "private_dir" {
+			continue
+		}
+		require.NoError(t, os.WriteFile(path, []byte{}, mode))
+		require.NoError(t, os.Chmod(path, mode))
+	}
+	require.NoError(t, os.Chmod(filepath.Join(dir, "private_dir"), 0750))
+
+	require.NoError(t, normalizeTree(dir, time.Unix(1700000000, 0)))
+	expected := map[string]fs.FileMode{
+		"public":         0644,
+		"public.sh":      0755,
+		"private":        0600,
+		"private.sh":     0700,
+		"private_dir":    0700,
+		"private_dir/id": 0600,
+	}
+	for name, mode := range expected {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, mode, info.Mode().Perm(), name)
+	}
+}
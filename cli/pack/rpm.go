@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/apex/log"
 	"github.com/otiai10/copy"
 	"github.com/tarantool/tt/cli/cmdcontext"
@@ -22,8 +23,12 @@ func (packer *rpmPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	opts *config.CliOpts) error {
 	var err error
 
-	if err := util.CheckRequiredBinaries("cpio"); err != nil {
-		return err
+	var signKey *openpgp.Entity
+	if packCtx.RpmDeb.SignKey != "" {
+		signKey, err = loadSignKey(packCtx.RpmDeb.SignKey, packCtx.RpmDeb.SignKeyring)
+		if err != nil {
+			return fmt.Errorf("failed to load the signing key: %s", err)
+		}
 	}
 
 	// Create a package directory, where it will be built.
@@ -78,8 +83,17 @@ func (packer *rpmPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 	if err != nil {
 		return err
 	}
+	if err = initTmpfilesConf(packCtx, opts, packageDir); err != nil {
+		return err
+	}
+
+	if packCtx.Reproducible {
+		if err = normalizeTree(packageDir, packCtx.SourceDate); err != nil {
+			return err
+		}
+	}
 
-	err = packRpm(cmdCtx, packCtx, opts, packageDir, resPackagePath)
+	err = packRpm(cmdCtx, packCtx, opts, packageDir, resPackagePath, signKey)
 
 	if err != nil {
 		return fmt.Errorf("failed to create RPM package: %s", err)
@@ -87,7 +101,7 @@ func (packer *rpmPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
 
 	log.Infof("Created result RPM package: %s", resPackagePath)
 
-	return nil
+	return copySbom(bundlePath, resPackagePath, packCtx)
 }
 
 // getRPMSuffix returns suffix for an RPM package.
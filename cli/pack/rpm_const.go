@@ -1,5 +1,7 @@
 package pack
 
+import "github.com/tarantool/tt/cli/util"
+
 const (
 	defaultFileUser   = "root"
 	defaultFileGroup  = "root"
@@ -10,11 +12,18 @@ const (
 	headerSignatures = 62
 	headerImmutable  = 63
 
+	hashAlgoMD5    = 1
+	hashAlgoSHA1   = 2
 	hashAlgoSHA256 = 8
+	hashAlgoSHA384 = 9
+	hashAlgoSHA512 = 10
 
 	// XXX
 	fileFlag = 1 << 4
 	dirFlag  = 0
+	// configFileFlag marks the configuration file, together with fileFlag
+	// (noreplace) it keeps the changed file on upgrade.
+	configFileFlag = 1 << 0
 
 	rpmTypeNull        = 0
 	rpmTypeChar        = 1
@@ -31,6 +40,10 @@ const (
 	signatureTagMD5         = 1004
 	signatureTagPayloadSize = 1007
 	signatureTagSHA1        = 269
+	signatureTagDSA         = 267
+	signatureTagRSA         = 268
+	signatureTagPGP         = 1002
+	signatureTagGPG         = 1005
 
 	tagName              = 1000
 	tagVersion           = 1001
@@ -49,8 +62,12 @@ const (
 	tagPayloadFlags      = 1126
 	tagPrein             = 1023
 	tagPostin            = 1024
+	tagPreun             = 1025
+	tagPostun            = 1026
 	tagPreinProg         = 1085
 	tagPostinProg        = 1086
+	tagPreunProg         = 1087
+	tagPostunProg        = 1088
 	tagDirNames          = 1118
 	tagBaseNames         = 1117
 	tagDirIndexes        = 1116
@@ -67,6 +84,7 @@ const (
 	tagFileRdevs         = 1033
 	tagFileDigests       = 1035
 	tagFileLinkTos       = 1036
+	tagFileDigestAlgo    = 5011
 	tagRequireFlags      = 1048
 	tagRequireName       = 1049
 	tagRequireVersion    = 1050
@@ -82,6 +100,7 @@ const (
 	rpmSenseScriptPost   = 0x400
 	rpmSenseScriptPreun  = 0x800
 	rpmSenseScriptPostun = 0x1000
+	rpmSenseRpmlib       = 0x1000000
 )
 
 var (
@@ -126,4 +145,21 @@ var (
 		rpmTypeInt32:       4,
 		rpmTypeInt64:       8,
 	}
+
+	// rpmPayloadDefaultFlags are the payload flags of the default compression
+	// levels.
+	rpmPayloadDefaultFlags = map[string]string{
+		util.CompressionXz:   "6",
+		util.CompressionZstd: "3",
+	}
+
+	// rpmPayloadRequires are the rpmlib features required to unpack the
+	// payload with the compression.
+	rpmPayloadRequires = map[string]struct {
+		name    string
+		version string
+	}{
+		util.CompressionXz:   {"rpmlib(PayloadIsXz)", "5.2-1"},
+		util.CompressionZstd: {"rpmlib(PayloadIsZstd)", "5.4.18-1"},
+	}
 )
@@ -54,16 +54,20 @@ func getRPMRelation(relation string) int32 {
 	return 0
 }
 
-// addDependenciesRPM writes all passed dependencies to the special rpm header.
-func addDependenciesRPM(rpmHeader *rpmTagSetType, deps PackDependencies) {
-	if len(deps) == 0 {
-		return
-	}
-
+// addDependenciesRPM writes all passed dependencies and the rpmlib feature
+// required by the payload compression to the special rpm header.
+func addDependenciesRPM(rpmHeader *rpmTagSetType, deps PackDependencies,
+	compressionType string) {
 	var names []string
 	var versions []string
 	var relations []int32
 
+	if payloadRequire, ok := rpmPayloadRequires[compressionType]; ok {
+		names = append(names, payloadRequire.name)
+		relations = append(relations, rpmSenseRpmlib|rpmSenseLess|rpmSenseEqual)
+		versions = append(versions, payloadRequire.version)
+	}
+
 	for _, dep := range deps {
 		for _, r := range dep.Relations {
 			names = append(names, dep.Name)
@@ -77,6 +81,9 @@ func addDependenciesRPM(rpmHeader *rpmTagSetType, deps PackDependencies) {
 			versions = append(versions, "")
 		}
 	}
+	if len(names) == 0 {
+		return
+	}
 
 	rpmHeader.addTags([]rpmTagType{
 		{ID: tagRequireName, Type: rpmTypeStringArray,
@@ -88,14 +95,13 @@ func addDependenciesRPM(rpmHeader *rpmTagSetType, deps PackDependencies) {
 	}...)
 }
 
-// addPreAndPostInstallScriptsRPM writes passed paths of pre-install
-// and post-install scripts to the rpm header.
-func addPreAndPostInstallScriptsRPM(rpmHeader *rpmTagSetType, preInst string, postInst string) {
+// addScriptsRPM writes the package scripts to the rpm header.
+func addScriptsRPM(rpmHeader *rpmTagSetType, scripts map[string]string) {
 	rpmHeader.addTags([]rpmTagType{
-		{ID: tagPrein, Type: rpmTypeString,
-			Value: strings.Join([]string{PreInstScriptContent, preInst}, "\n")},
-		{ID: tagPostin, Type: rpmTypeString,
-			Value: postInst},
+		{ID: tagPrein, Type: rpmTypeString, Value: scripts[PreInstScriptName]},
+		{ID: tagPostin, Type: rpmTypeString, Value: scripts[PostInstScriptName]},
+		{ID: tagPreun, Type: rpmTypeString, Value: scripts[PreRmScriptName]},
+		{ID: tagPostun, Type: rpmTypeString, Value: scripts[PostRmScriptName]},
 	}...)
 }
 
@@ -103,6 +109,7 @@ func addPreAndPostInstallScriptsRPM(rpmHeader *rpmTagSetType, preInst string, po
 func genRpmHeader(relPaths []string, cpioPath, compresedCpioPath, packageFilesDir string,
 	cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts) (rpmTagSetType, error) {
 	rpmHeader := rpmTagSetType{}
+	compression := getRpmPayloadCompression(packCtx)
 
 	// Compute payload digest.
 	payloadDigestAlgo := hashAlgoSHA256
@@ -117,12 +124,6 @@ func genRpmHeader(relPaths []string, cpioPath, compresedCpioPath, packageFilesDi
 	}
 	payloadSize := cpioFileInfo.Size()
 
-	// Generate fileinfo.
-	filesInfo, err := getFilesInfo(relPaths, packageFilesDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get files info: %s", err)
-	}
-
 	versionString := getVersion(packCtx, opts, defaultVersion)
 
 	ver, err := version.Parse(versionString)
@@ -134,6 +135,24 @@ func genRpmHeader(relPaths []string, cpioPath, compresedCpioPath, packageFilesDi
 		return nil, err
 	}
 
+	// Generate fileinfo.
+	envPath := filepath.Join(defaultEnvPrefix, name)
+	configFiles := map[string]bool{}
+	if util.IsDir(filepath.Join(packageFilesDir, envPath)) {
+		envConfigFiles, err := getConfigFiles(filepath.Join(packageFilesDir, envPath))
+		if err != nil {
+			return nil, err
+		}
+		for _, configFile := range envConfigFiles {
+			configFiles[filepath.Join(envPath, configFile)] = true
+		}
+	}
+	filesInfo, err := getFilesInfo(relPaths, packageFilesDir, configFiles,
+		packCtx.Reproducible)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get files info: %s", err)
+	}
+
 	versionStr := strings.Join([]string{
 		strconv.FormatUint(ver.Major, 10),
 		strconv.FormatUint(ver.Minor, 10),
@@ -155,11 +174,13 @@ func genRpmHeader(relPaths []string, cpioPath, compresedCpioPath, packageFilesDi
 		{ID: tagArch, Type: rpmTypeString, Value: arch},
 
 		{ID: tagPayloadFormat, Type: rpmTypeString, Value: "cpio"},
-		{ID: tagPayloadCompressor, Type: rpmTypeString, Value: "gzip"},
-		{ID: tagPayloadFlags, Type: rpmTypeString, Value: "5"},
+		{ID: tagPayloadCompressor, Type: rpmTypeString, Value: compression.Type},
+		{ID: tagPayloadFlags, Type: rpmTypeString, Value: getRpmPayloadFlags(compression)},
 
 		{ID: tagPreinProg, Type: rpmTypeString, Value: "/bin/sh"},
 		{ID: tagPostinProg, Type: rpmTypeString, Value: "/bin/sh"},
+		{ID: tagPreunProg, Type: rpmTypeString, Value: "/bin/sh"},
+		{ID: tagPostunProg, Type: rpmTypeString, Value: "/bin/sh"},
 
 		{ID: tagDirNames, Type: rpmTypeStringArray, Value: filesInfo.DirNames},
 		{ID: tagBaseNames, Type: rpmTypeStringArray, Value: filesInfo.BaseNames},
@@ -188,19 +209,26 @@ func genRpmHeader(relPaths []string, cpioPath, compresedCpioPath, packageFilesDi
 		return nil, err
 	}
 
-	addDependenciesRPM(&rpmHeader, deps)
-	addPreAndPostInstallScriptsRPM(&rpmHeader, packCtx.RpmDeb.PreInst,
-		packCtx.RpmDeb.PostInst)
+	addDependenciesRPM(&rpmHeader, deps, compression.Type)
+
+	scripts, err := genPackageScripts(packCtx, opts, Rpm)
+	if err != nil {
+		return nil, err
+	}
+	addScriptsRPM(&rpmHeader, scripts)
 
 	return rpmHeader, nil
 }
 
 // getFilesInfo returns the meta information about all items inside the passed
-// directory needed for packing it into rpm headers.
-func getFilesInfo(relPaths []string, dirPath string) (filesInfo, error) {
+// directory needed for packing it into rpm headers. The files from configFiles
+// are marked as configuration ones. If reproducible is set, inodes and devices
+// do not depend on the file system.
+func getFilesInfo(relPaths []string, dirPath string, configFiles map[string]bool,
+	reproducible bool) (filesInfo, error) {
 	info := filesInfo{}
 
-	for _, relPath := range relPaths {
+	for i, relPath := range relPaths {
 		fullFilePath := filepath.Join(dirPath, relPath)
 		fileInfo, err := os.Lstat(fullFilePath)
 		if err != nil {
@@ -208,7 +236,11 @@ func getFilesInfo(relPaths []string, dirPath string) (filesInfo, error) {
 		}
 
 		if fileInfo.Mode().IsRegular() {
-			info.FileFlags = append(info.FileFlags, fileFlag)
+			if configFiles[relPath] {
+				info.FileFlags = append(info.FileFlags, fileFlag|configFileFlag)
+			} else {
+				info.FileFlags = append(info.FileFlags, fileFlag)
+			}
 
 			fileDigest, err := util.FileMD5Hex(fullFilePath)
 			if err != nil {
@@ -257,9 +289,17 @@ func getFilesInfo(relPaths []string, dirPath string) (filesInfo, error) {
 		}
 		info.FileSizes = append(info.FileSizes, int32(sysFileInfo.Size))
 		info.FileModes = append(info.FileModes, int16(sysFileInfo.Mode))
-		info.FileInodes = append(info.FileInodes, int32(sysFileInfo.Ino))
-		info.FileDevices = append(info.FileDevices, int32(sysFileInfo.Dev))
-		info.FileRdevs = append(info.FileRdevs, int16(sysFileInfo.Rdev))
+		if reproducible {
+			// Inodes must be unique within the package, they are matched
+			// with the payload ones to find hard links.
+			info.FileInodes = append(info.FileInodes, int32(i+1))
+			info.FileDevices = append(info.FileDevices, 1)
+			info.FileRdevs = append(info.FileRdevs, 0)
+		} else {
+			info.FileInodes = append(info.FileInodes, int32(sysFileInfo.Ino))
+			info.FileDevices = append(info.FileDevices, int32(sysFileInfo.Dev))
+			info.FileRdevs = append(info.FileRdevs, int16(sysFileInfo.Rdev))
+		}
 	}
 
 	return info, nil
@@ -0,0 +1,391 @@
+package pack
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/tarantool/tt/cli/util"
+)
+
+// rpmLeadSize is the size of the RPM lead.
+const rpmLeadSize = 96
+
+// findTag returns the value of the tag with the passed ID.
+func (tagSet rpmTagSetType) findTag(tagID int) (interface{}, bool) {
+	for _, tag := range tagSet {
+		if tag.ID == tagID {
+			return tag.Value, true
+		}
+	}
+	return nil, false
+}
+
+// getString returns the string value of the tag or the first string of the
+// string array.
+func (tagSet rpmTagSetType) getString(tagID int) string {
+	value, _ := tagSet.findTag(tagID)
+	switch value := value.(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
+
+// getStrings returns the string array value of the tag.
+func (tagSet rpmTagSetType) getStrings(tagID int) []string {
+	value, _ := tagSet.findTag(tagID)
+	strs, _ := value.([]string)
+	return strs
+}
+
+// getInt32s returns the int32 array value of the tag.
+func (tagSet rpmTagSetType) getInt32s(tagID int) []int32 {
+	value, _ := tagSet.findTag(tagID)
+	ints, _ := value.([]int32)
+	return ints
+}
+
+// getInt16s returns the int16 array value of the tag.
+func (tagSet rpmTagSetType) getInt16s(tagID int) []int16 {
+	value, _ := tagSet.findTag(tagID)
+	ints, _ := value.([]int16)
+	return ints
+}
+
+// getBytes returns the binary value of the tag.
+func (tagSet rpmTagSetType) getBytes(tagID int) []byte {
+	value, _ := tagSet.findTag(tagID)
+	data, _ := value.([]byte)
+	return data
+}
+
+// getRPMRelationString returns the string representation of the dependency
+// relation flags, it is the inverse of getRPMRelation.
+func getRPMRelationString(flags int32) string {
+	relation := ""
+	if flags&rpmSenseLess != 0 {
+		relation += "<"
+	}
+	if flags&rpmSenseGreater != 0 {
+		relation += ">"
+	}
+	if flags&rpmSenseEqual != 0 {
+		relation += "="
+	}
+	return relation
+}
+
+// rpmPackage contains the parsed sections of the RPM package.
+type rpmPackage struct {
+	signature rpmTagSetType
+	header    rpmTagSetType
+	rawHeader []byte
+	payload   *io.SectionReader
+}
+
+// readRpm reads the lead, the signature and the header of the RPM package.
+func readRpm(file *os.File) (*rpmPackage, error) {
+	lead := make([]byte, rpmLeadSize)
+	if _, err := io.ReadFull(file, lead); err != nil {
+		return nil, fmt.Errorf("failed to read RPM lead: %s", err)
+	}
+	signature, rawSignature, err := readTagSet(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPM signature: %s", err)
+	}
+	// The signature is aligned to 8 bytes.
+	if padding := (8 - len(rawSignature)%8) % 8; padding != 0 {
+		if _, err = file.Seek(int64(padding), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+	header, rawHeader, err := readTagSet(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RPM header: %s", err)
+	}
+
+	payloadOffset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &rpmPackage{
+		signature: signature,
+		header:    header,
+		rawHeader: rawHeader,
+		payload:   io.NewSectionReader(file, payloadOffset, fileInfo.Size()-payloadOffset),
+	}, nil
+}
+
+// getRpmFiles returns the packed files described in the RPM header.
+func getRpmFiles(header rpmTagSetType) []PackageFile {
+	dirNames := header.getStrings(tagDirNames)
+	dirIndexes := header.getInt32s(tagDirIndexes)
+	modes := header.getInt16s(tagFileModes)
+	sizes := header.getInt32s(tagFileSizes)
+	users := header.getStrings(tagFileUsernames)
+	groups := header.getStrings(tagFileGroupnames)
+	linkTos := header.getStrings(tagFileLinkTos)
+	digests := header.getStrings(tagFileDigests)
+
+	files := []PackageFile{}
+	for i, baseName := range header.getStrings(tagBaseNames) {
+		file := PackageFile{Path: "/" + baseName}
+		if i < len(dirIndexes) && int(dirIndexes[i]) < len(dirNames) {
+			file.Path = path.Join(dirNames[dirIndexes[i]], baseName)
+		}
+		if i < len(modes) {
+			file.Mode = fileModeFromUnix(uint32(uint16(modes[i])))
+		}
+		if i < len(sizes) {
+			file.Size = int64(sizes[i])
+		}
+		if i < len(users) {
+			file.Owner = users[i]
+		}
+		if i < len(groups) {
+			file.Group = groups[i]
+		}
+		if i < len(linkTos) {
+			file.LinkTo = linkTos[i]
+		}
+		if i < len(digests) {
+			file.Digest = digests[i]
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// inspectRpm inspects the RPM package.
+func inspectRpm(file *os.File, opts InspectOpts,
+	keyring openpgp.EntityList) (*PackageInfo, error) {
+	rpm, err := readRpm(file)
+	if err != nil {
+		return nil, err
+	}
+	header := rpm.header
+
+	info := PackageInfo{Format: "rpm"}
+	info.addField("Name", header.getString(tagName))
+	info.addField("Version", header.getString(tagVersion))
+	info.addField("Release", header.getString(tagRelease))
+	info.addField("Architecture", header.getString(tagArch))
+	info.addField("OS", header.getString(tagOs))
+	info.addField("License", header.getString(tagLicense))
+	info.addField("Group", header.getString(tagGroup))
+	info.addField("Summary", header.getString(tagSummary))
+	info.addField("Description", header.getString(tagDescription))
+	info.addField("Payload compressor", header.getString(tagPayloadCompressor))
+	if size := header.getInt32s(tagSize); len(size) > 0 {
+		info.addField("Installed size", fmt.Sprint(size[0]))
+	}
+
+	requireFlags := header.getInt32s(tagRequireFlags)
+	requireVersions := header.getStrings(tagRequireVersion)
+	for i, name := range header.getStrings(tagRequireName) {
+		dep := name
+		if i < len(requireFlags) && i < len(requireVersions) && requireVersions[i] != "" {
+			dep = fmt.Sprintf("%s %s %s", name, getRPMRelationString(requireFlags[i]),
+				requireVersions[i])
+		}
+		info.Dependencies = append(info.Dependencies, dep)
+	}
+
+	for _, script := range []struct {
+		name  string
+		tagID int
+	}{
+		{PreInstScriptName, tagPrein},
+		{PostInstScriptName, tagPostin},
+		{PreRmScriptName, tagPreun},
+		{PostRmScriptName, tagPostun},
+	} {
+		if content := header.getString(script.tagID); strings.TrimSpace(content) != "" {
+			info.Scripts = append(info.Scripts, PackageScript{Name: script.name,
+				Content: content})
+		}
+	}
+
+	info.Files = getRpmFiles(header)
+
+	if opts.Verify {
+		verifyRpm(&info, rpm, keyring)
+	}
+	return &info, nil
+}
+
+// verifyRpm checks the digests and the signatures of the RPM package.
+func verifyRpm(info *PackageInfo, rpm *rpmPackage, keyring openpgp.EntityList) {
+	signature := rpm.signature
+
+	headerSHA1 := fmt.Sprintf("%x", sha1.Sum(rpm.rawHeader))
+	info.addCheck("header SHA1", checkDigest(signature.getString(signatureTagSHA1),
+		headerSHA1))
+
+	bodyMD5 := md5.New()
+	payloadSHA256 := sha256.New()
+	bodyMD5.Write(rpm.rawHeader)
+	payloadSize, err := io.Copy(io.MultiWriter(bodyMD5, payloadSHA256), rpm.payload)
+	if err != nil {
+		info.addCheck("payload", err)
+		return
+	}
+
+	var sizeErr error
+	bodySize := int64(len(rpm.rawHeader)) + payloadSize
+	if size := signature.getInt32s(signatureTagSize); len(size) == 0 {
+		sizeErr = fmt.Errorf("the size is not set")
+	} else if int64(size[0]) != bodySize {
+		sizeErr = fmt.Errorf("expected %d, got %d", size[0], bodySize)
+	}
+	info.addCheck("header and payload size", sizeErr)
+	info.addCheck("header and payload MD5", checkDigest(
+		fmt.Sprintf("%x", signature.getBytes(signatureTagMD5)),
+		fmt.Sprintf("%x", bodyMD5.Sum(nil))))
+	info.addCheck("payload SHA256", checkDigest(rpm.header.getString(tagPayloadDigest),
+		fmt.Sprintf("%x", payloadSHA256.Sum(nil))))
+
+	verifyRpmPayload(info, rpm)
+
+	headerSignature := signature.getBytes(signatureTagRSA)
+	if headerSignature == nil {
+		headerSignature = signature.getBytes(signatureTagDSA)
+	}
+	bodySignature := signature.getBytes(signatureTagPGP)
+	if bodySignature == nil {
+		bodySignature = signature.getBytes(signatureTagGPG)
+	}
+	if headerSignature == nil && bodySignature == nil {
+		info.skipCheck("signature", "the package is not signed")
+		return
+	}
+	if headerSignature != nil {
+		verifySignature(info, "header signature", keyring,
+			bytes.NewReader(rpm.rawHeader), headerSignature)
+	}
+	if bodySignature != nil {
+		verifySignature(info, "header and payload signature", keyring,
+			io.MultiReader(bytes.NewReader(rpm.rawHeader),
+				io.NewSectionReader(rpm.payload, 0, rpm.payload.Size())),
+			bodySignature)
+	}
+}
+
+// fileDigestHashes contains the hash functions of the supported RPM file
+// digest algorithms.
+var fileDigestHashes = map[int32]func() hash.Hash{
+	hashAlgoMD5:    md5.New,
+	hashAlgoSHA1:   sha1.New,
+	hashAlgoSHA256: sha256.New,
+	hashAlgoSHA384: sha512.New384,
+	hashAlgoSHA512: sha512.New,
+}
+
+// verifyRpmPayload checks the uncompressed payload size and the digests of
+// the packed files.
+func verifyRpmPayload(info *PackageInfo, rpm *rpmPackage) {
+	payloadReader, err := util.NewDecompressReader(
+		io.NewSectionReader(rpm.payload, 0, rpm.payload.Size()))
+	if err != nil {
+		info.addCheck("payload", err)
+		return
+	}
+	defer payloadReader.Close()
+
+	// MD5 is used if the algorithm is not set.
+	digestAlgo := int32(hashAlgoMD5)
+	if algo := rpm.header.getInt32s(tagFileDigestAlgo); len(algo) > 0 {
+		digestAlgo = algo[0]
+	}
+	newHash, digestSupported := fileDigestHashes[digestAlgo]
+
+	files := map[string]PackageFile{}
+	for _, file := range info.Files {
+		if file.Mode.IsRegular() {
+			files[strings.TrimPrefix(file.Path, "/")] = file
+		}
+	}
+	counter := &countingReader{reader: payloadReader}
+	err = readCpio(counter, func(header cpioHeader, content io.Reader) error {
+		name := path.Clean(header.name)
+		file, ok := files[name]
+		if !ok {
+			return nil
+		}
+		delete(files, name)
+		if header.fileSize != file.Size {
+			return fmt.Errorf("%s: expected size %d, got %d", name, file.Size,
+				header.fileSize)
+		}
+		if !digestSupported {
+			return nil
+		}
+		fileHash := newHash()
+		if _, err := io.Copy(fileHash, content); err != nil {
+			return err
+		}
+		if actual := fmt.Sprintf("%x", fileHash.Sum(nil)); actual != file.Digest {
+			return fmt.Errorf("%s: expected %s, got %s", name, file.Digest, actual)
+		}
+		return nil
+	})
+	if err == nil {
+		for name := range files {
+			err = fmt.Errorf("%s is not found in the payload", name)
+			break
+		}
+	}
+	if err == nil && !digestSupported {
+		info.skipCheck("file digests",
+			fmt.Sprintf("the digest algorithm %d is not supported", digestAlgo))
+	} else {
+		info.addCheck("file digests", err)
+	}
+	if err != nil {
+		return
+	}
+
+	// The rest of the archive is the padding.
+	if _, err = io.Copy(io.Discard, counter); err != nil {
+		info.addCheck("payload", err)
+		return
+	}
+	var sizeErr error
+	if size := rpm.signature.getInt32s(signatureTagPayloadSize); len(size) == 0 {
+		sizeErr = fmt.Errorf("the size is not set")
+	} else if int64(size[0]) != counter.count {
+		sizeErr = fmt.Errorf("expected %d, got %d", size[0], counter.count)
+	}
+	info.addCheck("uncompressed payload size", sizeErr)
+}
+
+// countingReader counts the read bytes.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read implements io.Reader.
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.count += int64(n)
+	return n, err
+}
@@ -2,13 +2,16 @@ package pack
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/apex/log"
 	"github.com/tarantool/tt/cli/cmdcontext"
 	"github.com/tarantool/tt/cli/config"
@@ -61,10 +64,30 @@ import (
  *
  */
 
+// getRpmPayloadCompression returns the compression of the rpm payload. The
+// gzip payload is compressed with the best level by default.
+func getRpmPayloadCompression(packCtx *PackCtx) util.CompressionOpts {
+	compression := packCtx.Compression
+	compression.Type = compression.GetType()
+	if compression.Type == util.CompressionGzip && compression.Level == 0 {
+		compression.Level = gzip.BestCompression
+	}
+	return compression
+}
+
+// getRpmPayloadFlags returns the compression level stored in the rpm header.
+func getRpmPayloadFlags(compression util.CompressionOpts) string {
+	if compression.Level != 0 {
+		return strconv.Itoa(compression.Level)
+	}
+	return rpmPayloadDefaultFlags[compression.Type]
+}
+
 // packRpm creates an RPM archive in resPackagePath
-// that contains files from packageDir.
+// that contains files from packageDir. The package is signed
+// if the signing key is passed.
 func packRpm(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts, packageDir,
-	resPackagePath string) error {
+	resPackagePath string, signKey *openpgp.Entity) error {
 	var err error
 
 	relPaths, err := getSortedRelPaths(packageDir)
@@ -75,12 +98,13 @@ func packRpm(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts,
 	log.Info("Creating data section")
 
 	cpioPath := filepath.Join(packageDir, "cpio")
-	if err := packCpio(relPaths, cpioPath, packageDir); err != nil {
+	if err := packCpio(relPaths, cpioPath, packageDir, packCtx.Reproducible); err != nil {
 		return fmt.Errorf("failed to pack CPIO: %s", err)
 	}
 
-	compresedCpioPath := filepath.Join(packageDir, "cpio.gz")
-	if err := CompressGzip(cpioPath, compresedCpioPath); err != nil {
+	compression := getRpmPayloadCompression(packCtx)
+	compresedCpioPath := filepath.Join(packageDir, "cpio"+compression.Ext())
+	if err := CompressFile(cpioPath, compresedCpioPath, compression); err != nil {
 		return fmt.Errorf("failed to compress CPIO: %s", err)
 	}
 
@@ -118,7 +142,7 @@ func packRpm(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts,
 	log.Info("Computing a signature")
 
 	// Compute signature.
-	signature, err := genSignature(rpmBodyFilePath, rpmHeaderFilePath, cpioPath)
+	signature, err := genSignature(rpmBodyFilePath, rpmHeaderFilePath, cpioPath, signKey)
 	if err != nil {
 		return fmt.Errorf("failed to gen RPM signature: %s", err)
 	}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	"github.com/tarantool/tt/cli/util"
 )
 
-// genSignature generates the signature for rpm.
-func genSignature(rpmBodyFilePath, rpmHeaderFilePath, cpioPath string) (*rpmTagSetType, error) {
+// genSignature generates the signature for rpm. If the signing key is passed,
+// OpenPGP signatures of the header and of the header with the payload are added.
+func genSignature(rpmBodyFilePath, rpmHeaderFilePath, cpioPath string,
+	signKey *openpgp.Entity) (*rpmTagSetType, error) {
 	// SHA1
 	sha1, err := util.FileSHA1Hex(rpmHeaderFilePath)
 	if err != nil {
@@ -42,5 +46,41 @@ func genSignature(rpmBodyFilePath, rpmHeaderFilePath, cpioPath string) (*rpmTagS
 		{ID: signatureTagMD5, Type: rpmTypeBin, Value: md5},
 	}
 
+	if signKey != nil {
+		openpgpTags, err := genOpenPGPSignature(rpmBodyFilePath, rpmHeaderFilePath, signKey)
+		if err != nil {
+			return nil, err
+		}
+		signature.addTags(openpgpTags...)
+	}
+
 	return &signature, nil
 }
+
+// genOpenPGPSignature generates the OpenPGP signature tags of the header and
+// of the header with the payload. RSA signatures are stored in the RSA and PGP
+// tags, signatures of other algorithms are stored in the DSA and GPG tags.
+func genOpenPGPSignature(rpmBodyFilePath, rpmHeaderFilePath string,
+	signKey *openpgp.Entity) ([]rpmTagType, error) {
+	headerSignature, err := signFiles(signKey, rpmHeaderFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign RPM header: %s", err)
+	}
+	bodySignature, err := signFiles(signKey, rpmBodyFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign RPM header and payload: %s", err)
+	}
+
+	algo, err := getSignatureAlgo(headerSignature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse RPM header signature: %s", err)
+	}
+	headerTag, bodyTag := signatureTagDSA, signatureTagGPG
+	if algo == packet.PubKeyAlgoRSA || algo == packet.PubKeyAlgoRSASignOnly {
+		headerTag, bodyTag = signatureTagRSA, signatureTagPGP
+	}
+	return []rpmTagType{
+		{ID: headerTag, Type: rpmTypeBin, Value: headerSignature},
+		{ID: bodyTag, Type: rpmTypeBin, Value: bodySignature},
+	}, nil
+}
@@ -2,6 +2,7 @@ package pack
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 
@@ -388,3 +389,117 @@ func packTag(tag rpmTagType) (*packedTagType, error) {
 
 	return &packed, nil
 }
+
+// maxTagSetSize is the maximum size of the read tags set.
+const maxTagSetSize = 256 << 20
+
+// readTagSet reads the packed tags set. It returns the tags without the
+// region tag and the raw packed tags set.
+func readTagSet(reader io.Reader) (rpmTagSetType, []byte, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, nil, fmt.Errorf("failed to read tags set header: %s", err)
+	}
+	if !bytes.Equal(header[:len(headerMagic)], headerMagic) {
+		return nil, nil, fmt.Errorf("invalid tags set magic %x", header[:len(headerMagic)])
+	}
+	tagsNum := int64(binary.BigEndian.Uint32(header[8:12]))
+	dataLen := int64(binary.BigEndian.Uint32(header[12:16]))
+	if tagsNum*16+dataLen > maxTagSetSize {
+		return nil, nil, fmt.Errorf("tags set is too big: %d tags, %d bytes of data",
+			tagsNum, dataLen)
+	}
+
+	body := make([]byte, tagsNum*16+dataLen)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, nil, fmt.Errorf("failed to read tags set: %s", err)
+	}
+	index, data := body[:tagsNum*16], body[tagsNum*16:]
+
+	tagSet := rpmTagSetType{}
+	for i := int64(0); i < tagsNum; i++ {
+		entry := index[i*16 : (i+1)*16]
+		tag := rpmTagType{
+			ID:   int(int32(binary.BigEndian.Uint32(entry[0:4]))),
+			Type: rpmValueType(int32(binary.BigEndian.Uint32(entry[4:8]))),
+		}
+		if tag.ID == headerSignatures || tag.ID == headerImmutable {
+			continue
+		}
+		offset := int(int32(binary.BigEndian.Uint32(entry[8:12])))
+		count := int(int32(binary.BigEndian.Uint32(entry[12:16])))
+		value, err := unpackTag(tag.Type, data, offset, count)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read tag %d: %s", tag.ID, err)
+		}
+		tag.Value = value
+		tagSet = append(tagSet, tag)
+	}
+	return tagSet, append(header, body...), nil
+}
+
+// unpackStrings reads count null-terminated strings starting from the offset.
+func unpackStrings(data []byte, offset int, count int) ([]string, error) {
+	strs := []string{}
+	for i := 0; i < count; i++ {
+		end := bytes.IndexByte(data[offset:], 0)
+		if end < 0 {
+			return nil, fmt.Errorf("string is not terminated")
+		}
+		strs = append(strs, string(data[offset:offset+end]))
+		offset += end + 1
+	}
+	return strs, nil
+}
+
+// unpackTag unpacks the tag value of the passed type. The value has the same
+// Go type as the one accepted by packTag.
+func unpackTag(tagType rpmValueType, data []byte, offset int, count int) (interface{}, error) {
+	size, ok := boundariesByType[tagType]
+	if !ok && tagType != rpmTypeI18nstring {
+		return nil, fmt.Errorf("unknown tag type: %d", tagType)
+	}
+	if offset < 0 || count < 0 || offset > len(data) {
+		return nil, fmt.Errorf("invalid offset %d or count %d", offset, count)
+	}
+	switch tagType {
+	case rpmTypeNull, rpmTypeString, rpmTypeStringArray, rpmTypeI18nstring:
+	default:
+		if int64(offset)+int64(count)*int64(size) > int64(len(data)) {
+			return nil, fmt.Errorf("value is out of data: offset %d, count %d",
+				offset, count)
+		}
+	}
+
+	switch tagType {
+	case rpmTypeNull:
+		return nil, nil
+	case rpmTypeChar, rpmTypeBin:
+		return append([]byte{}, data[offset:offset+count]...), nil
+	case rpmTypeString:
+		strs, err := unpackStrings(data, offset, 1)
+		if err != nil {
+			return nil, err
+		}
+		return strs[0], nil
+	case rpmTypeStringArray, rpmTypeI18nstring:
+		return unpackStrings(data, offset, count)
+	}
+
+	values := bytes.NewReader(data[offset:])
+	var value interface{}
+	switch tagType {
+	case rpmTypeInt8:
+		value = make([]int8, count)
+	case rpmTypeInt16:
+		value = make([]int16, count)
+	case rpmTypeInt32:
+		value = make([]int32, count)
+	case rpmTypeInt64:
+		value = make([]int64, count)
+	}
+	if err := binary.Read(values, binary.BigEndian, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,555 @@
+package pack
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/util"
+	"github.com/tarantool/tt/cli/version"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// SbomCycloneDX is the CycloneDX JSON SBOM format.
+	SbomCycloneDX = "cyclonedx"
+	// SbomSpdx is the SPDX JSON SBOM format.
+	SbomSpdx = "spdx"
+
+	// sbomNoAssertion is the SPDX value for the unknown fields.
+	sbomNoAssertion = "NOASSERTION"
+	// sbomRootRef is the reference of the package component.
+	sbomRootRef = "package"
+)
+
+// sbomSuffixes contains the SBOM file suffixes. The SBOM embedded into the
+// package is named sbom<suffix>, the SBOM next to the package is named
+// <package file><suffix>.
+var sbomSuffixes = map[string]string{
+	SbomCycloneDX: ".cdx.json",
+	SbomSpdx:      ".spdx.json",
+}
+
+// spdxLicenses contains the SPDX identifiers of the common licenses of rocks
+// by the lowercase license names used in rockspecs.
+var spdxLicenses = map[string]string{
+	"mit":          "MIT",
+	"mit/x11":      "MIT",
+	"bsd-2-clause": "BSD-2-Clause",
+	"bsd-3-clause": "BSD-3-Clause",
+	"apache-2.0":   "Apache-2.0",
+	"apache 2.0":   "Apache-2.0",
+	"isc":          "ISC",
+	"mpl-2.0":      "MPL-2.0",
+	"lgpl-2.1":     "LGPL-2.1-only",
+	"gpl-2.0":      "GPL-2.0-only",
+	"gpl-3.0":      "GPL-3.0-only",
+	"zlib":         "Zlib",
+	"unlicense":    "Unlicense",
+}
+
+// spdxInvalidIDChars matches the characters not allowed in SPDX identifiers.
+var spdxInvalidIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// sbomComponent is a component of the package: an application, a rock or
+// a binary.
+type sbomComponent struct {
+	// Ref is the unique reference of the component.
+	Ref string
+	// Type is the CycloneDX component type.
+	Type string
+	Name string
+	// Version is empty if it is unknown.
+	Version string
+	// License is a license from the rockspec as is.
+	License string
+	// Purl is the package URL.
+	Purl string
+	// Sha256 is the checksum of the component file.
+	Sha256 string
+	// DependsOn contains the references of the component dependencies.
+	DependsOn []string
+}
+
+// sbomDocument is a format independent bill of materials.
+type sbomDocument struct {
+	// Name is the package name.
+	Name string
+	// Version is the package version.
+	Version string
+	// Created is the document creation time.
+	Created time.Time
+	// Components contains the package components sorted by reference.
+	Components []sbomComponent
+}
+
+// readRockspecLicense returns the license from the rockspec description.
+func readRockspecLicense(rockspecPath string) (string, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	if err := L.DoFile(rockspecPath); err != nil {
+		return "", fmt.Errorf("failed to read rockspec %s: %s", rockspecPath, err)
+	}
+	description, ok := L.Env.RawGetString("description").(*lua.LTable)
+	if !ok {
+		return "", nil
+	}
+	if license, ok := description.RawGetString("license").(lua.LString); ok {
+		return string(license), nil
+	}
+	return "", nil
+}
+
+// getRockLicense returns the license of the installed rock or an empty string
+// if it is unknown.
+func getRockLicense(appPath, name, rockVersion string) string {
+	rockspecPath := filepath.Join(appPath, filepath.Dir(rocksManifestPath), name,
+		rockVersion, fmt.Sprintf("%s-%s.rockspec", name, rockVersion))
+	if _, err := os.Stat(rockspecPath); err != nil {
+		return ""
+	}
+	license, err := readRockspecLicense(rockspecPath)
+	if err != nil {
+		log.Warnf("Failed to get the license of %s %s: %s", name, rockVersion, err)
+	}
+	return license
+}
+
+// getAppComponents returns the application and its rocks components.
+func getAppComponents(appPath, appVersion string) ([]sbomComponent, error) {
+	appName := strings.TrimSuffix(filepath.Base(appPath), ".lua")
+	app := sbomComponent{
+		Ref:     "app:" + appName,
+		Type:    "application",
+		Name:    appName,
+		Version: appVersion,
+	}
+	components := []sbomComponent{}
+
+	if info, err := os.Stat(appPath); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		rockspecs, _ := filepath.Glob(filepath.Join(appPath, "*.rockspec"))
+		if len(rockspecs) > 0 {
+			if app.License, err = readRockspecLicense(rockspecs[0]); err != nil {
+				log.Warnf("Failed to get the license of %s: %s", appName, err)
+			}
+		}
+
+		rocks, err := LuaGetRocksVersions(appPath)
+		if err != nil {
+			return nil, err
+		}
+		for name, versions := range rocks {
+			if name == appName {
+				continue
+			}
+			for _, rockVersion := range versions {
+				purl := fmt.Sprintf("pkg:luarocks/%s@%s", name, rockVersion)
+				components = append(components, sbomComponent{
+					Ref:     purl,
+					Type:    "library",
+					Name:    name,
+					Version: rockVersion,
+					License: getRockLicense(appPath, name, rockVersion),
+					Purl:    purl,
+				})
+				app.DependsOn = append(app.DependsOn, purl)
+			}
+		}
+		sort.Strings(app.DependsOn)
+	}
+	return append(components, app), nil
+}
+
+// getBinaryComponents returns the components of the packed binaries.
+func getBinaryComponents(binPath, tarantoolVersion string) ([]sbomComponent, error) {
+	entries, err := os.ReadDir(binPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	components := []sbomComponent{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		checksum, err := util.FileSHA256Hex(filepath.Join(binPath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		component := sbomComponent{
+			Ref:    "bin:" + entry.Name(),
+			Type:   "application",
+			Name:   entry.Name(),
+			Sha256: checksum,
+		}
+		switch {
+		case strings.HasPrefix(entry.Name(), "tarantool"):
+			component.Version = tarantoolVersion
+		case strings.HasPrefix(entry.Name(), "tt"):
+			component.Version = version.GetVersion(true, false)
+		}
+		if component.Version != "" {
+			component.Purl = fmt.Sprintf("pkg:generic/%s@%s", component.Name,
+				component.Version)
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// getBundleApps returns the paths of the applications in the bundle.
+func getBundleApps(bundlePath string, packCtx *PackCtx) ([]string, error) {
+	if packCtx.CartridgeCompat {
+		return []string{filepath.Join(bundlePath, packCtx.Name)}, nil
+	}
+	instancesEnabled := filepath.Join(bundlePath, configure.InstancesEnabledDirName)
+	entries, err := os.ReadDir(instancesEnabled)
+	if err != nil {
+		return nil, err
+	}
+	apps := []string{}
+	for _, entry := range entries {
+		// The applications are linked from the bundle root.
+		appPath, err := filepath.EvalSymlinks(filepath.Join(instancesEnabled, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, appPath)
+	}
+	return apps, nil
+}
+
+// collectSbom collects the components of the prepared bundle.
+func collectSbom(bundlePath string, packCtx *PackCtx, name, pkgVersion,
+	tarantoolVersion string, created time.Time) (*sbomDocument, error) {
+	apps, err := getBundleApps(bundlePath, packCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqComponents := map[string]sbomComponent{}
+	for _, appPath := range apps {
+		components, err := getAppComponents(appPath, pkgVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, component := range components {
+			uniqComponents[component.Ref] = component
+		}
+	}
+	binaries, err := getBinaryComponents(filepath.Join(bundlePath, configure.BinPath),
+		tarantoolVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range binaries {
+		uniqComponents[component.Ref] = component
+	}
+
+	doc := sbomDocument{Name: name, Version: pkgVersion, Created: created.UTC()}
+	for _, component := range uniqComponents {
+		doc.Components = append(doc.Components, component)
+	}
+	sort.Slice(doc.Components, func(i, j int) bool {
+		return doc.Components[i].Ref < doc.Components[j].Ref
+	})
+	return &doc, nil
+}
+
+// uuid returns the UUID derived from the document content, so the same
+// documents get the same UUID.
+func (doc *sbomDocument) uuid() (string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10],
+		sum[10:16]), nil
+}
+
+// cycloneDXLicenses returns the CycloneDX licenses list.
+func cycloneDXLicenses(license string) []interface{} {
+	if license == "" {
+		return nil
+	}
+	if id, ok := spdxLicenses[strings.ToLower(license)]; ok {
+		return []interface{}{map[string]interface{}{"license": map[string]string{"id": id}}}
+	}
+	return []interface{}{map[string]interface{}{"license": map[string]string{"name": license}}}
+}
+
+// marshalCycloneDX returns the CycloneDX 1.5 JSON document.
+func marshalCycloneDX(doc *sbomDocument) ([]byte, error) {
+	uuid, err := doc.uuid()
+	if err != nil {
+		return nil, err
+	}
+
+	components := []interface{}{}
+	dependencies := []interface{}{}
+	rootDeps := []string{}
+	for _, component := range doc.Components {
+		cdxComponent := map[string]interface{}{
+			"bom-ref": component.Ref,
+			"type":    component.Type,
+			"name":    component.Name,
+		}
+		if component.Version != "" {
+			cdxComponent["version"] = component.Version
+		}
+		if component.Purl != "" {
+			cdxComponent["purl"] = component.Purl
+		}
+		if licenses := cycloneDXLicenses(component.License); licenses != nil {
+			cdxComponent["licenses"] = licenses
+		}
+		if component.Sha256 != "" {
+			cdxComponent["hashes"] = []interface{}{
+				map[string]string{"alg": "SHA-256", "content": component.Sha256},
+			}
+		}
+		components = append(components, cdxComponent)
+		if component.Type == "application" {
+			rootDeps = append(rootDeps, component.Ref)
+		}
+		dependsOn := component.DependsOn
+		if dependsOn == nil {
+			dependsOn = []string{}
+		}
+		dependencies = append(dependencies, map[string]interface{}{
+			"ref":       component.Ref,
+			"dependsOn": dependsOn,
+		})
+	}
+	dependencies = append([]interface{}{map[string]interface{}{
+		"ref":       sbomRootRef,
+		"dependsOn": rootDeps,
+	}}, dependencies...)
+
+	return json.MarshalIndent(map[string]interface{}{
+		"bomFormat":    "CycloneDX",
+		"specVersion":  "1.5",
+		"serialNumber": "urn:uuid:" + uuid,
+		"version":      1,
+		"metadata": map[string]interface{}{
+			"timestamp": doc.Created.Format(time.RFC3339),
+			"tools": []interface{}{map[string]string{
+				"vendor":  "Tarantool",
+				"name":    "tt",
+				"version": version.GetVersion(true, false),
+			}},
+			"component": map[string]string{
+				"bom-ref": sbomRootRef,
+				"type":    "application",
+				"name":    doc.Name,
+				"version": doc.Version,
+			},
+		},
+		"components":   components,
+		"dependencies": dependencies,
+	}, "", "  ")
+}
+
+// spdxID returns the SPDX identifier of the element.
+func spdxID(ref string) string {
+	return "SPDXRef-" + spdxInvalidIDChars.ReplaceAllString(ref, "-")
+}
+
+// marshalSpdx returns the SPDX 2.3 JSON document.
+func marshalSpdx(doc *sbomDocument) ([]byte, error) {
+	uuid, err := doc.uuid()
+	if err != nil {
+		return nil, err
+	}
+
+	rootID := spdxID(sbomRootRef)
+	packages := []interface{}{map[string]interface{}{
+		"SPDXID":           rootID,
+		"name":             doc.Name,
+		"versionInfo":      doc.Version,
+		"downloadLocation": sbomNoAssertion,
+		"filesAnalyzed":    false,
+		"licenseConcluded": sbomNoAssertion,
+		"licenseDeclared":  sbomNoAssertion,
+		"copyrightText":    sbomNoAssertion,
+	}}
+	relationships := []interface{}{map[string]string{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": rootID,
+	}}
+	extractedLicenses := map[string]string{}
+	for _, component := range doc.Components {
+		id := spdxID(component.Ref)
+		license := sbomNoAssertion
+		if component.License != "" {
+			if spdxLicense, ok := spdxLicenses[strings.ToLower(component.License)]; ok {
+				license = spdxLicense
+			} else {
+				license = "LicenseRef-" +
+					spdxInvalidIDChars.ReplaceAllString(component.License, "-")
+				extractedLicenses[license] = component.License
+			}
+		}
+		spdxPackage := map[string]interface{}{
+			"SPDXID":           id,
+			"name":             component.Name,
+			"downloadLocation": sbomNoAssertion,
+			"filesAnalyzed":    false,
+			"licenseConcluded": sbomNoAssertion,
+			"licenseDeclared":  license,
+			"copyrightText":    sbomNoAssertion,
+		}
+		if component.Version != "" {
+			spdxPackage["versionInfo"] = component.Version
+		}
+		if component.Purl != "" {
+			spdxPackage["externalRefs"] = []interface{}{map[string]string{
+				"referenceCategory": "PACKAGE-MANAGER",
+				"referenceType":     "purl",
+				"referenceLocator":  component.Purl,
+			}}
+		}
+		if component.Sha256 != "" {
+			spdxPackage["checksums"] = []interface{}{map[string]string{
+				"algorithm":     "SHA256",
+				"checksumValue": component.Sha256,
+			}}
+		}
+		packages = append(packages, spdxPackage)
+
+		if component.Type == "application" {
+			relationships = append(relationships, map[string]string{
+				"spdxElementId":      rootID,
+				"relationshipType":   "CONTAINS",
+				"relatedSpdxElement": id,
+			})
+		}
+		for _, dependency := range component.DependsOn {
+			relationships = append(relationships, map[string]string{
+				"spdxElementId":      id,
+				"relationshipType":   "DEPENDS_ON",
+				"relatedSpdxElement": spdxID(dependency),
+			})
+		}
+	}
+
+	namespace := fmt.Sprintf("https://tarantool.io/spdxdocs/%s-%s-%s", doc.Name,
+		doc.Version, uuid)
+	spdxDoc := map[string]interface{}{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              fmt.Sprintf("%s-%s", doc.Name, doc.Version),
+		"documentNamespace": namespace,
+		"creationInfo": map[string]interface{}{
+			"created":  doc.Created.Format(time.RFC3339),
+			"creators": []string{"Tool: tt-" + version.GetVersion(true, false)},
+		},
+		"packages":      packages,
+		"relationships": relationships,
+	}
+	if len(extractedLicenses) > 0 {
+		ids := make([]string, 0, len(extractedLicenses))
+		for id := range extractedLicenses {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		infos := []interface{}{}
+		for _, id := range ids {
+			infos = append(infos, map[string]string{
+				"licenseId":     id,
+				"name":          extractedLicenses[id],
+				"extractedText": extractedLicenses[id],
+			})
+		}
+		spdxDoc["hasExtractedLicensingInfos"] = infos
+	}
+	return json.MarshalIndent(spdxDoc, "", "  ")
+}
+
+// checkSbomFormat checks if the SBOM format is supported.
+func checkSbomFormat(format string) error {
+	if _, ok := sbomSuffixes[format]; !ok && format != "" {
+		return fmt.Errorf("unsupported SBOM format %q, supported formats: %s, %s",
+			format, SbomCycloneDX, SbomSpdx)
+	}
+	return nil
+}
+
+// genSbom writes the SBOM of the prepared bundle into the bundle root.
+func genSbom(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx, opts *config.CliOpts,
+	bundlePath string) error {
+	log.Infof("Generating %s SBOM.", packCtx.Sbom)
+
+	name, err := getPackageName(packCtx, opts, "", false)
+	if err != nil {
+		return err
+	}
+	// The version is requested from the packed tarantool executable: it may
+	// differ from the one used by tt.
+	tarantoolVersion := ""
+	packedTarantool := filepath.Join(bundlePath, configure.BinPath, "tarantool")
+	if _, err = os.Stat(packedTarantool); err == nil {
+		tarantoolCli := cmdcontext.CliCtx{TarantoolExecutable: packedTarantool}
+		if tarantoolVersion, err = util.GetTarantoolVersion(&tarantoolCli); err != nil {
+			log.Warnf("The SBOM is generated without the tarantool version: %s", err)
+		}
+	}
+	created := time.Now()
+	if packCtx.Reproducible {
+		created = packCtx.SourceDate
+	}
+	doc, err := collectSbom(bundlePath, packCtx, name,
+		getVersion(packCtx, opts, defaultLongVersion), tarantoolVersion, created)
+	if err != nil {
+		return fmt.Errorf("failed to collect SBOM components: %s", err)
+	}
+
+	var data []byte
+	if packCtx.Sbom == SbomSpdx {
+		data, err = marshalSpdx(doc)
+	} else {
+		data, err = marshalCycloneDX(doc)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(bundlePath, "sbom"+sbomSuffixes[packCtx.Sbom]),
+		append(data, '\n'), 0644)
+}
+
+// copySbom copies the SBOM embedded into the bundle next to the package.
+// The SBOM file name is the package file name with the SBOM suffix.
+func copySbom(bundlePath, packagePath string, packCtx *PackCtx) error {
+	if packCtx.Sbom == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(bundlePath, "sbom"+sbomSuffixes[packCtx.Sbom]))
+	if err != nil {
+		return err
+	}
+	sbomPath := packagePath + sbomSuffixes[packCtx.Sbom]
+	if err = os.WriteFile(sbomPath, data, 0644); err != nil {
+		return err
+	}
+	log.Infof("Created SBOM: %s", sbomPath)
+	return nil
+}
@@ -0,0 +1,246 @@
+package pack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/util"
+)
+
+// makeSbomBundle creates the bundle with two applications, rocks and binaries.
+func makeSbomBundle(t *testing.T) string {
+	bundlePath := t.TempDir()
+	rocksPath := filepath.Join("app", filepath.Dir(rocksManifestPath))
+	files := map[string]string{
+		"app/init.lua": "return {}",
+		"app/app-scm-1.rockspec": "package = 'app'\n" +
+			"description = { license = 'Apache-2.0' }\n",
+		filepath.Join("app", rocksManifestPath): "dependencies = {\n" +
+			"  app = { ['scm-1'] = {} },\n" +
+			"  checks = { ['3.1.0-1'] = {} },\n" +
+			"  http = { ['1.1.0-1'] = {} },\n" +
+			"}\n",
+		filepath.Join(rocksPath, "checks", "3.1.0-1", "checks-3.1.0-1.rockspec"): "" +
+			"package = 'checks'\ndescription = { license = 'BSD' }\n",
+		filepath.Join(rocksPath, "http", "1.1.0-1", "http-1.1.0-1.rockspec"): "" +
+			"package = 'http'\ndescription = { license = 'MIT' }\n",
+		"single.lua":    "return {}",
+		"bin/tarantool": "tarantool",
+		"bin/tt":        "tt",
+	}
+	for name, content := range files {
+		path := filepath.Join(bundlePath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	instancesEnabled := filepath.Join(bundlePath, configure.InstancesEnabledDirName)
+	require.NoError(t, os.MkdirAll(instancesEnabled, 0755))
+	require.NoError(t, os.Symlink("../app", filepath.Join(instancesEnabled, "app")))
+	require.NoError(t, os.Symlink("../single.lua", filepath.Join(instancesEnabled, "single")))
+	return bundlePath
+}
+
+func TestCollectSbom(t *testing.T) {
+	bundlePath := makeSbomBundle(t)
+	created := time.Unix(1700000000, 0)
+	doc, err := collectSbom(bundlePath, &PackCtx{}, "bundle", "1.0.0.0", "2.11.1", created)
+	require.NoError(t, err)
+	assert.Equal(t, "bundle", doc.Name)
+	assert.Equal(t, "1.0.0.0", doc.Version)
+
+	checksum, err := util.FileSHA256Hex(filepath.Join(bundlePath, "bin", "tarantool"))
+	require.NoError(t, err)
+	refs := []string{}
+	components := map[string]sbomComponent{}
+	for _, component := range doc.Components {
+		refs = append(refs, component.Ref)
+		components[component.Ref] = component
+	}
+	assert.Equal(t, []string{"app:app", "app:single", "bin:tarantool", "bin:tt",
+		"pkg:luarocks/checks@3.1.0-1", "pkg:luarocks/http@1.1.0-1"}, refs)
+	assert.Equal(t, sbomComponent{Ref: "app:app", Type: "application", Name: "app",
+		Version: "1.0.0.0", License: "Apache-2.0", DependsOn: []string{
+			"pkg:luarocks/checks@3.1.0-1", "pkg:luarocks/http@1.1.0-1"}},
+		components["app:app"])
+	assert.Equal(t, sbomComponent{Ref: "bin:tarantool", Type: "application",
+		Name: "tarantool", Version: "2.11.1", Purl: "pkg:generic/tarantool@2.11.1",
+		Sha256: checksum}, components["bin:tarantool"])
+	assert.Equal(t, "BSD", components["pkg:luarocks/checks@3.1.0-1"].License)
+	assert.Equal(t, "library", components["pkg:luarocks/http@1.1.0-1"].Type)
+	assert.Empty(t, components["app:single"].DependsOn)
+}
+
+func TestMarshalCycloneDX(t *testing.T) {
+	doc, err := collectSbom(makeSbomBundle(t), &PackCtx{}, "bundle", "1.0.0.0", "2.11.1",
+		time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	data, err := marshalCycloneDX(doc)
+	require.NoError(t, err)
+	// The same document gets the same serial number.
+	dataAgain, err := marshalCycloneDX(doc)
+	require.NoError(t, err)
+	assert.Equal(t, data, dataAgain)
+
+	bom := struct {
+		BomFormat    string `json:"bomFormat"`
+		SpecVersion  string `json:"specVersion"`
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Timestamp string            `json:"timestamp"`
+			Component map[string]string `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Ref      string `json:"bom-ref"`
+			Name     string `json:"name"`
+			Purl     string `json:"purl"`
+			Licenses []struct {
+				License map[string]string `json:"license"`
+			} `json:"licenses"`
+			Hashes []map[string]string `json:"hashes"`
+		} `json:"components"`
+		Dependencies []struct {
+			Ref       string   `json:"ref"`
+			DependsOn []string `json:"dependsOn"`
+		} `json:"dependencies"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &bom))
+	assert.Equal(t, "CycloneDX", bom.BomFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.Regexp(t, "^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-"+
+		"[0-9a-f]{12}$", bom.SerialNumber)
+	assert.Equal(t, "2023-11-14T22:13:20Z", bom.Metadata.Timestamp)
+	assert.Equal(t, "bundle", bom.Metadata.Component["name"])
+	require.Len(t, bom.Components, 6)
+	assert.Equal(t, map[string]string{"id": "Apache-2.0"}, bom.Components[0].Licenses[0].License)
+	assert.Equal(t, "SHA-256", bom.Components[2].Hashes[0]["alg"])
+	assert.Equal(t, map[string]string{"name": "BSD"}, bom.Components[4].Licenses[0].License)
+	assert.Equal(t, "pkg:luarocks/http@1.1.0-1", bom.Components[5].Purl)
+	require.Len(t, bom.Dependencies, 7)
+	assert.Equal(t, sbomRootRef, bom.Dependencies[0].Ref)
+	assert.Equal(t, []string{"app:app", "app:single", "bin:tarantool", "bin:tt"},
+		bom.Dependencies[0].DependsOn)
+	assert.Equal(t, []string{"pkg:luarocks/checks@3.1.0-1", "pkg:luarocks/http@1.1.0-1"},
+		bom.Dependencies[1].DependsOn)
+}
+
+func TestMarshalSpdx(t *testing.T) {
+	doc, err := collectSbom(makeSbomBundle(t), &PackCtx{}, "bundle", "1.0.0.0", "2.11.1",
+		time.Unix(1700000000, 0))
+	require.NoError(t, err)
+	data, err := marshalSpdx(doc)
+	require.NoError(t, err)
+
+	spdx := struct {
+		SpdxVersion       string `json:"spdxVersion"`
+		DocumentNamespace string `json:"documentNamespace"`
+		Packages          []struct {
+			SPDXID          string              `json:"SPDXID"`
+			Name            string              `json:"name"`
+			LicenseDeclared string              `json:"licenseDeclared"`
+			Checksums       []map[string]string `json:"checksums"`
+			ExternalRefs    []map[string]string `json:"externalRefs"`
+		} `json:"packages"`
+		Relationships []map[string]string `json:"relationships"`
+		Extracted     []map[string]string `json:"hasExtractedLicensingInfos"`
+	}{}
+	require.NoError(t, json.Unmarshal(data, &spdx))
+	assert.Equal(t, "SPDX-2.3", spdx.SpdxVersion)
+	assert.Regexp(t, "^https://tarantool.io/spdxdocs/bundle-1.0.0.0-", spdx.DocumentNamespace)
+	require.Len(t, spdx.Packages, 7)
+	assert.Equal(t, "SPDXRef-package", spdx.Packages[0].SPDXID)
+	assert.Equal(t, "SPDXRef-app-app", spdx.Packages[1].SPDXID)
+	assert.Equal(t, "Apache-2.0", spdx.Packages[1].LicenseDeclared)
+	assert.Equal(t, sbomNoAssertion, spdx.Packages[2].LicenseDeclared)
+	assert.Equal(t, "SHA256", spdx.Packages[3].Checksums[0]["algorithm"])
+	assert.Equal(t, "SPDXRef-pkg-luarocks-checks-3.1.0-1", spdx.Packages[5].SPDXID)
+	assert.Equal(t, "LicenseRef-BSD", spdx.Packages[5].LicenseDeclared)
+	assert.Equal(t, "pkg:luarocks/checks@3.1.0-1",
+		spdx.Packages[5].ExternalRefs[0]["referenceLocator"])
+	assert.Equal(t, "MIT", spdx.Packages[6].LicenseDeclared)
+	assert.Equal(t, []map[string]string{{"licenseId": "LicenseRef-BSD", "name": "BSD",
+		"extractedText": "BSD"}}, spdx.Extracted)
+	assert.Contains(t, spdx.Relationships, map[string]string{
+		"spdxElementId":      "SPDXRef-DOCUMENT",
+		"relationshipType":   "DESCRIBES",
+		"relatedSpdxElement": "SPDXRef-package",
+	})
+	assert.Contains(t, spdx.Relationships, map[string]string{
+		"spdxElementId":      "SPDXRef-app-app",
+		"relationshipType":   "DEPENDS_ON",
+		"relatedSpdxElement": "SPDXRef-pkg-luarocks-http-1.1.0-1",
+	})
+}
+
+func TestGenSbom(t *testing.T) {
+	assert.NoError(t, checkSbomFormat(""))
+	assert.NoError(t, checkSbomFormat(SbomSpdx))
+	assert.ErrorContains(t, checkSbomFormat("swid"), `unsupported SBOM format "swid"`)
+
+	bundlePath := makeSbomBundle(t)
+	packCtx := &PackCtx{Name: "bundle", Version: "1.0.0", Sbom: SbomCycloneDX,
+		Reproducible: true, SourceDate: time.Unix(1700000000, 0)}
+	cmdCtx := &cmdcontext.CmdCtx{}
+	opts := &config.CliOpts{App: &config.AppOpts{}}
+	require.NoError(t, genSbom(cmdCtx, packCtx, opts, bundlePath))
+	require.FileExists(t, filepath.Join(bundlePath, "sbom.cdx.json"))
+
+	packagePath := filepath.Join(t.TempDir(), "bundle-1.0.0.x86_64.tar.gz")
+	require.NoError(t, copySbom(bundlePath, packagePath, packCtx))
+	embedded, err := os.ReadFile(filepath.Join(bundlePath, "sbom.cdx.json"))
+	require.NoError(t, err)
+	copied, err := os.ReadFile(packagePath + ".cdx.json")
+	require.NoError(t, err)
+	assert.Equal(t, embedded, copied)
+
+	packCtx.Sbom = ""
+	require.NoError(t, copySbom(bundlePath, packagePath+"2", packCtx))
+	assert.NoFileExists(t, packagePath+"2.cdx.json")
+}
+
+func TestGenSbomTarantoolVersion(t *testing.T) {
+	bundlePath := makeSbomBundle(t)
+	// The version is requested from the packed tarantool, not the one used by tt.
+	tarantoolPath := filepath.Join(bundlePath, "bin", "tarantool")
+	require.NoError(t, os.WriteFile(tarantoolPath,
+		[]byte("#!/bin/sh\necho 'Tarantool 2.11.1-0-g96877bd'\n"), 0755))
+	require.NoError(t, os.Chmod(tarantoolPath, 0755))
+	packCtx := &PackCtx{Name: "bundle", Version: "1.0.0", Sbom: SbomCycloneDX,
+		Reproducible: true, SourceDate: time.Unix(1700000000, 0)}
+	cmdCtx := &cmdcontext.CmdCtx{}
+	cmdCtx.Cli.TarantoolExecutable = filepath.Join(t.TempDir(), "tarantool")
+	cmdCtx.Cli.TarantoolVersion = "3.0.0"
+	opts := &config.CliOpts{App: &config.AppOpts{}}
+
+	getVersions := func() map[string]string {
+		require.NoError(t, genSbom(cmdCtx, packCtx, opts, bundlePath))
+		data, err := os.ReadFile(filepath.Join(bundlePath, "sbom.cdx.json"))
+		require.NoError(t, err)
+		bom := struct {
+			Components []struct {
+				Ref     string `json:"bom-ref"`
+				Version string `json:"version"`
+			} `json:"components"`
+		}{}
+		require.NoError(t, json.Unmarshal(data, &bom))
+		versions := map[string]string{}
+		for _, component := range bom.Components {
+			versions[component.Ref] = component.Version
+		}
+		return versions
+	}
+	assert.Equal(t, "2.11.1-0-g96877bd", getVersions()["bin:tarantool"])
+
+	// The SBOM is generated without the version if it can't be requested.
+	require.NoError(t, os.Chmod(tarantoolPath, 0644))
+	versions := getVersions()
+	assert.Contains(t, versions, "bin:tarantool")
+	assert.Empty(t, versions["bin:tarantool"])
+}
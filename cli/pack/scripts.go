@@ -0,0 +1,214 @@
+package pack
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/util"
+)
+
+const (
+	PreRmScriptName  = "prerm"
+	PostRmScriptName = "postrm"
+
+	// defaultSystemUser is the default system user of the package.
+	defaultSystemUser = "tarantool"
+	// scriptPermissions are the permissions of the package scripts.
+	scriptPermissions = 0755
+	// conffilesFileName is the name of the deb control file with the list of
+	// configuration files.
+	conffilesFileName = "conffiles"
+	// instancesFileName and instancesFileNameYaml are the names of the
+	// application instances files.
+	instancesFileName     = "instances.yml"
+	instancesFileNameYaml = "instances.yaml"
+)
+
+// tmpfilesDir is the directory of tmpfiles.d configuration files.
+var tmpfilesDir = filepath.Join("usr", "lib", "tmpfiles.d")
+
+//go:embed templates/package-script.sh
+var packageScriptTemplate string
+
+// packageScriptNames contains the names of the package scripts in the order
+// of execution on install.
+var packageScriptNames = []string{
+	PreInstScriptName,
+	PostInstScriptName,
+	PreRmScriptName,
+	PostRmScriptName,
+}
+
+// getSystemUser returns the system user and group of the package.
+func getSystemUser(packCtx *PackCtx) (string, string) {
+	user := packCtx.RpmDeb.SystemUser
+	if user == "" {
+		user = defaultSystemUser
+	}
+	group := packCtx.RpmDeb.SystemGroup
+	if group == "" {
+		group = user
+	}
+	return user, group
+}
+
+// getDataDirs returns the data directories of the environment relative to
+// its root.
+func getDataDirs(opts *config.CliOpts) []string {
+	dirs := []string{configure.VarPath, configure.VarRunPath, configure.VarLogPath,
+		configure.VarDataPath}
+	if opts != nil && opts.App != nil &&
+		!(opts.App.MemtxDir == opts.App.WalDir && opts.App.WalDir == opts.App.VinylDir) {
+		dirs = append(dirs, configure.VarVinylPath, configure.VarWalPath,
+			configure.VarMemtxPath)
+	}
+	return dirs
+}
+
+// getScriptParams returns the parameters of the package scripts template.
+func getScriptParams(packCtx *PackCtx, opts *config.CliOpts,
+	format string) (map[string]interface{}, error) {
+	name, err := getPackageName(packCtx, opts, "", false)
+	if err != nil {
+		return nil, err
+	}
+	envPath := filepath.Join("/", defaultEnvPrefix, name)
+	dataDirs := []string{}
+	for _, dir := range getDataDirs(opts) {
+		dataDirs = append(dataDirs, filepath.Join(envPath, dir))
+	}
+	appInstances, err := getAppInstances(packCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+	instanceApps := make([]string, 0, len(appInstances))
+	for appName := range appInstances {
+		instanceApps = append(instanceApps, appName)
+	}
+	sort.Strings(instanceApps)
+	user, group := getSystemUser(packCtx)
+	return map[string]interface{}{
+		"Format":       format,
+		"Name":         name,
+		"User":         user,
+		"Group":        group,
+		"Home":         filepath.Join(envPath, configure.VarDataPath),
+		"DataDirs":     dataDirs,
+		"InstanceApps": instanceApps,
+		"Tmpfiles":     filepath.Join("/", tmpfilesDir, name+".conf"),
+	}, nil
+}
+
+// getUserScriptPath returns the path to the user script appended to the
+// generated package script.
+func getUserScriptPath(packCtx *PackCtx, scriptName string) string {
+	switch scriptName {
+	case PreInstScriptName:
+		return packCtx.RpmDeb.PreInst
+	case PostInstScriptName:
+		return packCtx.RpmDeb.PostInst
+	case PreRmScriptName:
+		return packCtx.RpmDeb.PreRm
+	case PostRmScriptName:
+		return packCtx.RpmDeb.PostRm
+	}
+	return ""
+}
+
+// genPackageScript generates the package script. The script sets tt_action
+// from the package manager arguments and runs the generated actions followed
+// by the user script.
+func genPackageScript(scriptName, userScriptPath string,
+	params map[string]interface{}) (string, error) {
+	userScript := ""
+	if userScriptPath != "" {
+		content, err := os.ReadFile(userScriptPath)
+		if err != nil {
+			return "", err
+		}
+		userScript = strings.TrimRight(string(content), "\n")
+	}
+
+	scriptParams := map[string]interface{}{
+		"Script":     scriptName,
+		"UserScript": userScript,
+	}
+	for key, value := range params {
+		scriptParams[key] = value
+	}
+	scriptTemplate := packageScriptTemplate
+	return util.GetTextTemplatedStr(&scriptTemplate, scriptParams)
+}
+
+// genPackageScripts generates all package scripts of the rpm or deb package.
+func genPackageScripts(packCtx *PackCtx, opts *config.CliOpts,
+	format string) (map[string]string, error) {
+	params, err := getScriptParams(packCtx, opts, format)
+	if err != nil {
+		return nil, err
+	}
+	scripts := map[string]string{}
+	for _, scriptName := range packageScriptNames {
+		script, err := genPackageScript(scriptName, getUserScriptPath(packCtx, scriptName),
+			params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s script: %s", scriptName, err)
+		}
+		scripts[scriptName] = script
+	}
+	return scripts, nil
+}
+
+// initTmpfilesConf creates the tmpfiles.d configuration file that creates
+// the data directories of the environment owned by the system user.
+// baseDirPath is a root of the directory which will get packed.
+func initTmpfilesConf(packCtx *PackCtx, opts *config.CliOpts, baseDirPath string) error {
+	params, err := getScriptParams(packCtx, opts, "")
+	if err != nil {
+		return err
+	}
+
+	conf := strings.Builder{}
+	fmt.Fprintf(&conf, "# Data directories of the %s environment.\n", params["Name"])
+	for _, dir := range params["DataDirs"].([]string) {
+		fmt.Fprintf(&conf, "d %s 0750 %s %s -\n", dir, params["User"], params["Group"])
+	}
+
+	confPath := filepath.Join(baseDirPath, strings.TrimPrefix(params["Tmpfiles"].(string), "/"))
+	if err = os.MkdirAll(filepath.Dir(confPath), dirPermissions); err != nil {
+		return err
+	}
+	return os.WriteFile(confPath, []byte(conf.String()), 0644)
+}
+
+// getConfigFiles returns the configuration files of the environment, relative
+// to its root: tt.yaml and the instances files of the applications. The
+// operator changes of these files are kept on upgrade.
+func getConfigFiles(envPath string) ([]string, error) {
+	configFiles := []string{}
+	if util.IsRegularFile(filepath.Join(envPath, configure.ConfigName)) {
+		configFiles = append(configFiles, configure.ConfigName)
+	}
+
+	entries, err := os.ReadDir(envPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == configure.InstancesEnabledDirName {
+			continue
+		}
+		for _, fileName := range []string{instancesFileName, instancesFileNameYaml} {
+			if util.IsRegularFile(filepath.Join(envPath, entry.Name(), fileName)) {
+				configFiles = append(configFiles, filepath.Join(entry.Name(), fileName))
+			}
+		}
+	}
+	return configFiles, nil
+}
@@ -0,0 +1,151 @@
+package pack
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/config"
+)
+
+func Test_getSystemUser(t *testing.T) {
+	packCtx := &PackCtx{}
+	user, group := getSystemUser(packCtx)
+	assert.Equal(t, "tarantool", user)
+	assert.Equal(t, "tarantool", group)
+
+	packCtx.RpmDeb.SystemUser = "app"
+	user, group = getSystemUser(packCtx)
+	assert.Equal(t, "app", user)
+	assert.Equal(t, "app", group)
+
+	packCtx.RpmDeb.SystemGroup = "apps"
+	user, group = getSystemUser(packCtx)
+	assert.Equal(t, "app", user)
+	assert.Equal(t, "apps", group)
+}
+
+func Test_genPackageScripts(t *testing.T) {
+	userScript := filepath.Join(t.TempDir(), "postrm.sh")
+	require.NoError(t, os.WriteFile(userScript, []byte("echo removed\n"), 0644))
+
+	packCtx := &PackCtx{Name: "app"}
+	packCtx.RpmDeb.PostRm = userScript
+	opts := &config.CliOpts{App: &config.AppOpts{}}
+
+	for _, format := range []string{"rpm", "deb"} {
+		t.Run(format, func(t *testing.T) {
+			scripts, err := genPackageScripts(packCtx, opts, format)
+			require.NoError(t, err)
+			require.Len(t, scripts, len(packageScriptNames))
+
+			assert.Contains(t, scripts[PreInstScriptName],
+				"useradd -M -N -g 'tarantool' -r -d '/usr/share/tarantool/app/var/lib'")
+			assert.Contains(t, scripts[PreInstScriptName],
+				"mkdir -p -m 0755 /etc/tarantool/conf.d /var/lib/tarantool /var/run/tarantool")
+			assert.Contains(t, scripts[PreInstScriptName],
+				"chown 'tarantool:tarantool' /var/lib/tarantool /var/run/tarantool")
+			assert.Contains(t, scripts[PostInstScriptName],
+				"systemd-tmpfiles --create '/usr/lib/tmpfiles.d/app.conf'")
+			assert.Contains(t, scripts[PostInstScriptName],
+				"systemctl try-restart 'app.service' 'app@*.service'")
+			assert.Contains(t, scripts[PreRmScriptName],
+				"systemctl disable --now 'app.service'")
+			assert.Contains(t, scripts[PostRmScriptName],
+				"rm -rf '/usr/share/tarantool/app/var/lib'")
+			assert.True(t, strings.HasSuffix(scripts[PostRmScriptName], "\necho removed\n"))
+
+			if _, err := exec.LookPath("sh"); err != nil {
+				return
+			}
+			for name, script := range scripts {
+				scriptPath := filepath.Join(t.TempDir(), name)
+				require.NoError(t, os.WriteFile(scriptPath, []byte(script), 0644))
+				out, err := exec.Command("sh", "-n", scriptPath).CombinedOutput()
+				assert.NoError(t, err, "%s: %s", name, out)
+			}
+		})
+	}
+
+	packCtx.RpmDeb.PostRm = "nothing"
+	_, err := genPackageScripts(packCtx, opts, "deb")
+	assert.ErrorContains(t, err, "failed to generate postrm script")
+}
+
+func Test_initTmpfilesConf(t *testing.T) {
+	baseDir := t.TempDir()
+	packCtx := &PackCtx{Name: "app"}
+	packCtx.RpmDeb.SystemUser = "app"
+	opts := &config.CliOpts{App: &config.AppOpts{WalDir: "wal", MemtxDir: "snap",
+		VinylDir: "vinyl"}}
+	require.NoError(t, initTmpfilesConf(packCtx, opts, baseDir))
+
+	content, err := os.ReadFile(filepath.Join(baseDir, "usr", "lib", "tmpfiles.d", "app.conf"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "d /usr/share/tarantool/app/var/lib 0750 app app -\n")
+	assert.Contains(t, string(content), "d /usr/share/tarantool/app/var/wal 0750 app app -\n")
+}
+
+func Test_getConfigFiles(t *testing.T) {
+	envPath := t.TempDir()
+	for _, file := range []string{
+		"tt.yaml",
+		filepath.Join("app", "instances.yml"),
+		filepath.Join("app2", "instances.yaml"),
+		filepath.Join("app3", "init.lua"),
+		filepath.Join("instances.enabled", "app", "instances.yml"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(envPath, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(envPath, file), []byte{}, 0644))
+	}
+
+	configFiles, err := getConfigFiles(envPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tt.yaml", filepath.Join("app", "instances.yml"),
+		filepath.Join("app2", "instances.yaml")}, configFiles)
+}
+
+func Test_createConffiles(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "env")
+	require.NoError(t, os.MkdirAll(filepath.Join(envPath, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(envPath, "tt.yaml"), []byte{}, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(envPath, "app", "instances.yml"), []byte{},
+		0644))
+
+	require.NoError(t, createConffiles(dir, envPath, "/usr/share/tarantool/env"))
+	content, err := os.ReadFile(filepath.Join(dir, conffilesFileName))
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/share/tarantool/env/tt.yaml\n"+
+		"/usr/share/tarantool/env/app/instances.yml\n", string(content))
+}
+
+func Test_getFilesInfoConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tt.yaml"), []byte{}, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "init.lua"), []byte{}, 0644))
+
+	info, err := getFilesInfo([]string{"init.lua", "tt.yaml"}, dir,
+		map[string]bool{"tt.yaml": true}, true)
+	require.NoError(t, err)
+	require.Len(t, info.FileFlags, 2)
+	assert.Zero(t, info.FileFlags[0]&configFileFlag)
+	assert.NotZero(t, info.FileFlags[1]&configFileFlag)
+}
+
+func Test_genPackageScriptsAppInstances(t *testing.T) {
+	packCtx := &PackCtx{Name: "env"}
+	opts := &config.CliOpts{App: &config.AppOpts{InstancesEnabled: makeInstancesEnabled(t)}}
+
+	scripts, err := genPackageScripts(packCtx, opts, Deb)
+	require.NoError(t, err)
+	assert.Contains(t, scripts[PostInstScriptName],
+		"systemctl try-restart 'app@*.service'")
+	assert.Contains(t, scripts[PreRmScriptName], "systemctl disable --now 'app.target'")
+	assert.Contains(t, scripts[PreRmScriptName], "systemctl stop 'app@*.service'")
+	assert.NotContains(t, scripts[PreRmScriptName], "single")
+}
@@ -0,0 +1,182 @@
+package pack
+
+import (
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/config"
+	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/util"
+)
+
+const (
+	// selfextractEnvDir is the payload directory with the environment.
+	selfextractEnvDir = "env"
+	// selfextractSystemdDir is the payload directory with the systemd units.
+	selfextractSystemdDir = "systemd"
+	// selfextractPrefixPlaceholder is replaced with the installation prefix
+	// in the systemd units on install.
+	selfextractPrefixPlaceholder = "@TT_PREFIX@"
+	// selfextractOffsetWidth is the width of the space padded payload offset
+	// in the stub, so the stub length does not depend on the offset value.
+	selfextractOffsetWidth = 10
+)
+
+//go:embed templates/selfextract-stub.sh
+var selfextractStubTemplate string
+
+// selfextractPacker is a structure that implements Packer interface
+// with specific self-extracting installer packing behavior.
+type selfextractPacker struct {
+}
+
+// Run packs a bundle into the self-extracting installer.
+func (packer *selfextractPacker) Run(cmdCtx *cmdcontext.CmdCtx, packCtx *PackCtx,
+	opts *config.CliOpts) error {
+	bundlePath, err := prepareBundle(cmdCtx, packCtx, opts, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := os.RemoveAll(bundlePath)
+		if err != nil {
+			log.Warnf("Failed to remove a temporary directory %s: %s",
+				bundlePath, err.Error())
+		}
+	}()
+
+	log.Debugf("The package structure is created in: %s", bundlePath)
+
+	// The payload contains the environment and the systemd units.
+	payloadPath, err := os.MkdirTemp(filepath.Dir(bundlePath), "tt_selfextract")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := os.RemoveAll(payloadPath)
+		if err != nil {
+			log.Warnf("Failed to remove a temporary directory %s: %s",
+				payloadPath, err.Error())
+		}
+	}()
+	if err = os.Rename(bundlePath, filepath.Join(payloadPath, selfextractEnvDir)); err != nil {
+		return err
+	}
+
+	// The units are installed to the systemd directory itself, so they
+	// are generated in the separate payload directory.
+	systemdPath := filepath.Join(payloadPath, selfextractSystemdDir)
+	err = initSystemdDir(packCtx, opts, systemdPath, selfextractPrefixPlaceholder)
+	if err != nil {
+		return err
+	}
+	unitsPath := filepath.Join(systemdPath, "usr", "lib", "systemd", "system")
+	units, err := os.ReadDir(unitsPath)
+	if err != nil {
+		return err
+	}
+	unitNames := make([]string, 0, len(units))
+	for _, unit := range units {
+		unitNames = append(unitNames, unit.Name())
+		err = os.Rename(filepath.Join(unitsPath, unit.Name()),
+			filepath.Join(systemdPath, unit.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	if err = os.RemoveAll(filepath.Join(systemdPath, "usr")); err != nil {
+		return err
+	}
+
+	if packCtx.Reproducible {
+		if err = normalizeTree(payloadPath, packCtx.SourceDate); err != nil {
+			return err
+		}
+	}
+
+	name, err := getPackageName(packCtx, opts, "", false)
+	if err != nil {
+		return err
+	}
+	selfextractSuffix, err := getSelfextractSuffix()
+	if err != nil {
+		return err
+	}
+	installerPath, err := getPackageName(packCtx, opts, selfextractSuffix, true)
+	if err != nil {
+		return err
+	}
+
+	stub, err := genSelfextractStub(name, getVersion(packCtx, opts, defaultLongVersion),
+		filepath.Join("/", defaultEnvPrefix, name), unitNames)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Creating self-extracting installer.")
+	err = writeSelfextract(installerPath, stub, payloadPath, packCtx.Reproducible)
+	if err != nil {
+		os.Remove(installerPath)
+		return fmt.Errorf("failed to create self-extracting installer: %s", err)
+	}
+	log.Infof("Created result self-extracting installer: %s", installerPath)
+	return copySbom(filepath.Join(payloadPath, selfextractEnvDir), installerPath, packCtx)
+}
+
+// getSelfextractSuffix returns suffix for a self-extracting installer.
+func getSelfextractSuffix() (string, error) {
+	arch, err := util.GetArch()
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{"", arch, "run"}, "."), nil
+}
+
+// genSelfextractStub generates the shell script which unpacks the payload
+// appended to it. The payload offset is equal to the script length. units are
+// the names of the systemd units installed with the environment.
+func genSelfextractStub(name, version, prefix string, units []string) (string, error) {
+	params := map[string]interface{}{
+		"Name":              name,
+		"Units":             strings.Join(units, " "),
+		"Version":           version,
+		"Prefix":            prefix,
+		"PrefixPlaceholder": selfextractPrefixPlaceholder,
+		"DataDir":           configure.VarPath,
+		"PayloadOffset":     fmt.Sprintf("%-*d", selfextractOffsetWidth, 0),
+	}
+	stub, err := util.GetTextTemplatedStr(&selfextractStubTemplate, params)
+	if err != nil {
+		return "", err
+	}
+	params["PayloadOffset"] = fmt.Sprintf("%-*d", selfextractOffsetWidth, len(stub))
+	return util.GetTextTemplatedStr(&selfextractStubTemplate, params)
+}
+
+// writeSelfextract writes the executable installer: the stub followed by
+// the gzipped tarball of the payload directory.
+func writeSelfextract(installerPath, stub, payloadPath string, reproducible bool) error {
+	installer, err := os.OpenFile(installerPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer installer.Close()
+
+	if _, err = installer.WriteString(stub); err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(installer)
+	if err = WriteTarArchive(payloadPath, gzipWriter, reproducible); err != nil {
+		return err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return err
+	}
+	return installer.Close()
+}
@@ -0,0 +1,130 @@
+package pack
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenSelfextractStub(t *testing.T) {
+	stub, err := genSelfextractStub("app", "1.2.3.4", "/usr/share/tarantool/app",
+		[]string{"app.service", "app@.service"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stub, "#!/bin/sh\n"))
+	assert.True(t, strings.HasSuffix(stub, "exit 0\n"))
+	assert.Contains(t, stub, "NAME='app'\n")
+	assert.Contains(t, stub, "VERSION='1.2.3.4'\n")
+	assert.Contains(t, stub, "prefix='/usr/share/tarantool/app'\n")
+	assert.Contains(t, stub, "UNITS='app.service app@.service'\n")
+	assert.Contains(t, stub, fmt.Sprintf("PAYLOAD_OFFSET=%-10d\n", len(stub)))
+}
+
+// makeSelfextract creates the installer with the application version.
+func makeSelfextract(t *testing.T, dir, version string) string {
+	payloadPath := t.TempDir()
+	envPath := filepath.Join(payloadPath, selfextractEnvDir)
+	require.NoError(t, os.MkdirAll(filepath.Join(envPath, "app"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(envPath, "var", "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(envPath, "tt.yaml"), []byte("tt:\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(envPath, "app", "init.lua"),
+		[]byte(version), 0644))
+	systemdPath := filepath.Join(payloadPath, selfextractSystemdDir)
+	require.NoError(t, os.MkdirAll(systemdPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(systemdPath, "app.service"),
+		[]byte("ExecStart="+selfextractPrefixPlaceholder+"/bin/tt start\n"), 0644))
+
+	stub, err := genSelfextractStub("app", version, filepath.Join(dir, "default"),
+		[]string{"app.service"})
+	require.NoError(t, err)
+	installerPath := filepath.Join(dir, "app-"+version+".run")
+	require.NoError(t, writeSelfextract(installerPath, stub, payloadPath, false))
+	return installerPath
+}
+
+// runSelfextract runs the installer with the passed arguments.
+func runSelfextract(t *testing.T, installerPath string, args ...string) (string, error) {
+	output, err := exec.Command(installerPath, args...).CombinedOutput()
+	return string(output), err
+}
+
+func TestSelfextract(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not found")
+	}
+	dir := t.TempDir()
+	// The prefix contains the characters special for sed.
+	prefix := filepath.Join(dir, "app|&")
+	unitsDir := filepath.Join(dir, "units")
+	installerV1 := makeSelfextract(t, dir, "1.0.0")
+	installerV2 := makeSelfextract(t, dir, "2.0.0")
+
+	info, err := os.Stat(installerV1)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm()&0755)
+
+	// Install.
+	output, err := runSelfextract(t, installerV1, "--prefix", prefix,
+		"--with-systemd", "--systemd-dir", unitsDir)
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "app 1.0.0 is installed to "+prefix)
+	content, err := os.ReadFile(filepath.Join(prefix, "app", "init.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", string(content))
+	assert.FileExists(t, filepath.Join(prefix, "tt.yaml"))
+	content, err = os.ReadFile(filepath.Join(unitsDir, "app.service"))
+	require.NoError(t, err)
+	assert.Equal(t, "ExecStart="+prefix+"/bin/tt start\n", string(content))
+
+	output, err = runSelfextract(t, installerV1, "--prefix", prefix)
+	require.Error(t, err)
+	assert.Contains(t, output, "app is already installed in "+prefix)
+
+	// Upgrade keeps the data.
+	dataPath := filepath.Join(prefix, "var", "lib", "data.snap")
+	require.NoError(t, os.WriteFile(dataPath, []byte("data"), 0644))
+	output, err = runSelfextract(t, installerV2, "--prefix="+prefix, "--upgrade")
+	require.NoError(t, err, output)
+	assert.Contains(t, output, "app is upgraded to 2.0.0 in "+prefix)
+	content, err = os.ReadFile(filepath.Join(prefix, "app", "init.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", string(content))
+	assert.FileExists(t, dataPath)
+
+	// Uninstall keeps the data unless purge is set and the files that were not
+	// installed.
+	userFiles := []string{filepath.Join(prefix, "notes.txt"),
+		filepath.Join(prefix, "app", "local.lua")}
+	for _, userFile := range userFiles {
+		require.NoError(t, os.WriteFile(userFile, []byte("user"), 0644))
+	}
+	output, err = runSelfextract(t, installerV2, "--prefix", prefix, "--uninstall",
+		"--with-systemd", "--systemd-dir", unitsDir)
+	require.NoError(t, err, output)
+	assert.NoFileExists(t, filepath.Join(unitsDir, "app.service"))
+	assert.NoFileExists(t, filepath.Join(prefix, "app", "init.lua"))
+	assert.NoFileExists(t, filepath.Join(prefix, "tt.yaml"))
+	assert.FileExists(t, dataPath)
+	for _, userFile := range userFiles {
+		assert.FileExists(t, userFile)
+		require.NoError(t, os.Remove(userFile))
+	}
+
+	output, err = runSelfextract(t, installerV2, "--prefix", prefix, "--uninstall")
+	require.Error(t, err)
+	assert.Contains(t, output, "app is not installed in "+prefix)
+	output, err = runSelfextract(t, installerV2, "--prefix", prefix, "--upgrade")
+	require.Error(t, err)
+	assert.Contains(t, output, "app is not installed in "+prefix)
+
+	output, err = runSelfextract(t, installerV2, "--prefix", prefix)
+	require.NoError(t, err, output)
+	output, err = runSelfextract(t, installerV2, "--prefix", prefix, "--uninstall", "--purge")
+	require.NoError(t, err, output)
+	assert.NoDirExists(t, prefix)
+}
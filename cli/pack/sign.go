@@ -0,0 +1,157 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+const (
+	// signPassphraseEnv is the environment variable with the passphrase of
+	// the encrypted signing key.
+	signPassphraseEnv = "TT_SIGN_PASSPHRASE"
+	// defaultSignKeyring is the default keyring file, relative to the home
+	// directory.
+	defaultSignKeyring = ".gnupg/secring.gpg"
+)
+
+// readKeyRing reads the armored or binary OpenPGP keyring file.
+func readKeyRing(keyringPath string) (openpgp.EntityList, error) {
+	data, err := os.ReadFile(keyringPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the keyring: %s", err)
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the keyring %s: %s", keyringPath, err)
+	}
+	return keyring, nil
+}
+
+// matchEntity checks if the key ID, the fingerprint, the e-mail or the whole
+// user ID of the entity is equal to the passed one.
+func matchEntity(entity *openpgp.Entity, id string) bool {
+	hexID := strings.ToUpper(strings.TrimPrefix(strings.ReplaceAll(id, " ", ""), "0x"))
+	fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+	switch len(hexID) {
+	case 8, 16, len(fingerprint):
+		if strings.HasSuffix(fingerprint, hexID) {
+			return true
+		}
+	}
+	email := strings.Trim(id, "<>")
+	for name, identity := range entity.Identities {
+		if name == id || strings.EqualFold(identity.UserId.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// decryptEntity decrypts the private keys of the entity using the passphrase
+// from TT_SIGN_PASSPHRASE.
+func decryptEntity(entity *openpgp.Entity) error {
+	keys := []*packet.PrivateKey{entity.PrivateKey}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil {
+			keys = append(keys, subkey.PrivateKey)
+		}
+	}
+	passphrase, isSet := os.LookupEnv(signPassphraseEnv)
+	for _, key := range keys {
+		if !key.Encrypted {
+			continue
+		}
+		if !isSet {
+			return fmt.Errorf("the signing key is encrypted, set the passphrase in %s",
+				signPassphraseEnv)
+		}
+		if err := key.Decrypt([]byte(passphrase)); err != nil {
+			return fmt.Errorf("failed to decrypt the signing key: %s", err)
+		}
+	}
+	return nil
+}
+
+// loadSignKey loads the signing key. signKey is a path to the key file or an
+// ID of the key in the keyring. The key ID is a hexadecimal key ID or
+// fingerprint, an e-mail or a full user ID matched exactly. If the keyring is
+// not set, ~/.gnupg/secring.gpg is used.
+func loadSignKey(signKey, keyringPath string) (*openpgp.Entity, error) {
+	var keyring openpgp.EntityList
+	var err error
+	keyID := ""
+	if _, statErr := os.Stat(signKey); statErr == nil {
+		keyring, err = readKeyRing(signKey)
+	} else {
+		keyID = signKey
+		if keyringPath == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			keyringPath = filepath.Join(homeDir, defaultSignKeyring)
+		}
+		keyring, err = readKeyRing(keyringPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range keyring {
+		if entity.PrivateKey == nil || (keyID != "" && !matchEntity(entity, keyID)) {
+			continue
+		}
+		if err = decryptEntity(entity); err != nil {
+			return nil, err
+		}
+		return entity, nil
+	}
+	if keyID != "" {
+		return nil, fmt.Errorf("the secret key %q is not found in %s", keyID, keyringPath)
+	}
+	return nil, fmt.Errorf("the secret key is not found in %s", signKey)
+}
+
+// signFiles returns the detached binary OpenPGP signature of the concatenated
+// files content.
+func signFiles(signKey *openpgp.Entity, paths ...string) ([]byte, error) {
+	readers := []io.Reader{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		readers = append(readers, file)
+	}
+
+	signature := bytes.Buffer{}
+	if err := openpgp.DetachSign(&signature, signKey, io.MultiReader(readers...),
+		nil); err != nil {
+		return nil, fmt.Errorf("failed to sign: %s", err)
+	}
+	return signature.Bytes(), nil
+}
+
+// getSignatureAlgo returns the public key algorithm of the signature.
+func getSignatureAlgo(signature []byte) (packet.PublicKeyAlgorithm, error) {
+	pkt, err := packet.Read(bytes.NewReader(signature))
+	if err != nil {
+		return 0, err
+	}
+	sig, ok := pkt.(*packet.Signature)
+	if !ok {
+		return 0, fmt.Errorf("unexpected signature packet")
+	}
+	return sig.PubKeyAlgo, nil
+}
@@ -0,0 +1,148 @@
+package pack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSignKeyFile          = "testdata/sign-key.asc"
+	testSignPublicKeyFile    = "testdata/sign-key.pub.asc"
+	testSignEncryptedKeyFile = "testdata/sign-key-encrypted.asc"
+	testSignKeyring          = "testdata/secring.gpg"
+	testSignKeyID            = "8F9BFCFADF95C700"
+)
+
+// checkSignature verifies the signature of the data with the test public key.
+func checkSignature(t *testing.T, data []byte, signature []byte) {
+	publicKeyFile, err := os.Open(testSignPublicKeyFile)
+	require.NoError(t, err)
+	defer publicKeyFile.Close()
+	keyring, err := openpgp.ReadArmoredKeyRing(publicKeyFile)
+	require.NoError(t, err)
+
+	signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data),
+		bytes.NewReader(signature), nil)
+	require.NoError(t, err)
+	assert.Equal(t, testSignKeyID, signer.PrimaryKey.KeyIdString())
+}
+
+func TestLoadSignKey(t *testing.T) {
+	for _, signKey := range []string{testSignKeyFile, testSignKeyID, "0x" + testSignKeyID,
+		"DF95C700", "tt-test@tarantool.io"} {
+		entity, err := loadSignKey(signKey, testSignKeyring)
+		require.NoError(t, err, signKey)
+		assert.Equal(t, testSignKeyID, entity.PrimaryKey.KeyIdString())
+		assert.NotNil(t, entity.PrivateKey)
+	}
+
+	_, err := loadSignKey("unknown@tarantool.io", testSignKeyring)
+	assert.ErrorContains(t, err, `the secret key "unknown@tarantool.io" is not found`)
+	_, err = loadSignKey(testSignPublicKeyFile, "")
+	assert.ErrorContains(t, err, "the secret key is not found")
+	_, err = loadSignKey(testSignKeyID, filepath.Join(t.TempDir(), "secring.gpg"))
+	assert.ErrorContains(t, err, "failed to read the keyring")
+}
+
+func TestMatchEntity(t *testing.T) {
+	entity, err := openpgp.NewEntity("Bobby", "", "bobby@example.com", nil)
+	require.NoError(t, err)
+	fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+
+	for _, id := range []string{fingerprint, strings.ToLower(fingerprint),
+		entity.PrimaryKey.KeyIdString(), "0x" + entity.PrimaryKey.KeyIdShortString(),
+		"bobby@example.com", "<BOBBY@example.com>", "Bobby <bobby@example.com>"} {
+		assert.True(t, matchEntity(entity, id), id)
+	}
+	for _, id := range []string{"bob", "Bobby", "example.com", "bob@example.com",
+		fingerprint[len(fingerprint)-4:], fingerprint[len(fingerprint)-12:]} {
+		assert.False(t, matchEntity(entity, id), id)
+	}
+}
+
+func TestLoadSignKeyEncrypted(t *testing.T) {
+	t.Setenv(signPassphraseEnv, "")
+	os.Unsetenv(signPassphraseEnv)
+	_, err := loadSignKey(testSignEncryptedKeyFile, "")
+	assert.ErrorContains(t, err, "the signing key is encrypted")
+
+	t.Setenv(signPassphraseEnv, "invalid")
+	_, err = loadSignKey(testSignEncryptedKeyFile, "")
+	assert.ErrorContains(t, err, "failed to decrypt the signing key")
+
+	t.Setenv(signPassphraseEnv, "tt-passphrase")
+	entity, err := loadSignKey(testSignEncryptedKeyFile, "")
+	require.NoError(t, err)
+	assert.False(t, entity.PrivateKey.Encrypted)
+}
+
+func TestGenSignatureSigned(t *testing.T) {
+	signKey, err := loadSignKey(testSignKeyFile, "")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	header := []byte("header")
+	body := []byte("header and payload")
+	headerPath := filepath.Join(dir, "header")
+	bodyPath := filepath.Join(dir, "body")
+	cpioPath := filepath.Join(dir, "cpio")
+	require.NoError(t, os.WriteFile(headerPath, header, 0644))
+	require.NoError(t, os.WriteFile(bodyPath, body, 0644))
+	require.NoError(t, os.WriteFile(cpioPath, []byte("payload"), 0644))
+
+	signature, err := genSignature(bodyPath, headerPath, cpioPath, nil)
+	require.NoError(t, err)
+	assert.Len(t, *signature, 4)
+
+	signature, err = genSignature(bodyPath, headerPath, cpioPath, signKey)
+	require.NoError(t, err)
+	tags := map[int]rpmTagType{}
+	for _, tag := range *signature {
+		tags[tag.ID] = tag
+	}
+	require.Contains(t, tags, signatureTagRSA)
+	require.Contains(t, tags, signatureTagPGP)
+	assert.NotContains(t, tags, signatureTagDSA)
+	assert.NotContains(t, tags, signatureTagGPG)
+	assert.Equal(t, rpmValueType(rpmTypeBin), tags[signatureTagRSA].Type)
+	checkSignature(t, header, tags[signatureTagRSA].Value.([]byte))
+	checkSignature(t, body, tags[signatureTagPGP].Value.([]byte))
+
+	_, err = packTagSet(*signature, headerSignatures)
+	require.NoError(t, err)
+}
+
+func TestCreateDebSignature(t *testing.T) {
+	signKey, err := loadSignKey(testSignKeyFile, "")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	members := []string{}
+	for name, content := range map[string]string{debianBinaryFileName: "2.0\n",
+		controlArchiveName: "control", "data.tar.gz": "data"} {
+		members = append(members, filepath.Join(dir, name))
+		require.NoError(t, os.WriteFile(members[len(members)-1], []byte(content), 0644))
+	}
+
+	signaturePath, err := createDebSignature(dir, members, signKey)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, debSignatureFileName), signaturePath)
+	signature, err := os.ReadFile(signaturePath)
+	require.NoError(t, err)
+
+	data := []byte{}
+	for _, member := range members {
+		content, err := os.ReadFile(member)
+		require.NoError(t, err)
+		data = append(data, content...)
+	}
+	checkSignature(t, data, signature)
+}
@@ -3,8 +3,12 @@ package pack
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/apex/log"
 	"github.com/tarantool/tt/cli/config"
@@ -23,6 +27,32 @@ var appUnitContentTemplate string
 //go:embed templates/app-inst-unit-template.txt
 var appInstUnitContentTemplate string
 
+//go:embed templates/app-instances-unit-template.txt
+var appInstancesUnitContentTemplate string
+
+//go:embed templates/app-target-template.txt
+var appTargetContentTemplate string
+
+var (
+	// memoryMaxRe matches the MemoryMax values: the size in bytes with an optional
+	// K, M, G or T suffix, the percentage of the physical memory or infinity.
+	memoryMaxRe = regexp.MustCompile(`^(infinity|[0-9]+[KMGT]?|[0-9]+(\.[0-9]+)?%)$`)
+
+	// unitParamsSchema describes the supported systemd unit parameters. The
+	// checker validates the value and returns its normalized form.
+	unitParamsSchema = map[string]func(value interface{}) (interface{}, error){
+		"TT":              checkNonEmptyString,
+		"ConfigPath":      checkNonEmptyString,
+		"EnvName":         checkNonEmptyString,
+		"User":            checkNonEmptyString,
+		"Group":           checkNonEmptyString,
+		"FdLimit":         checkFdLimit,
+		"ProtectSystem":   checkProtectSystem,
+		"NoNewPrivileges": checkNoNewPrivileges,
+		"MemoryMax":       checkMemoryMax,
+	}
+)
+
 // initSystemdDir generates systemd unit files for every application in the current bundle.
 // pathToEnv is a path to environment in the target system.
 // baseDirPath is a root of the directory which will get packed.
@@ -63,31 +93,126 @@ func initSystemdDir(packCtx *PackCtx, opts *config.CliOpts,
 		return err
 	}
 
+	appInstances, err := getAppInstances(packCtx, opts)
+	if err != nil {
+		return err
+	}
+	for appName, instances := range appInstances {
+		if err = initAppInstancesUnits(systemdBaseDir, appName, instances,
+			contentParams); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// initAppInstancesUnits generates the <app>@.service template unit instantiated
+// for every instance of the application and the <app>.target unit, which starts
+// all the instances. If the application name is equal to the package name, the
+// template unit replaces the package one.
+func initAppInstancesUnits(systemdBaseDir, appName string, instances []string,
+	contentParams map[string]interface{}) error {
+	appParams := make(map[string]interface{}, len(contentParams)+2)
+	for key, value := range contentParams {
+		appParams[key] = value
+	}
+	appParams["AppName"] = appName
+	appParams["Instances"] = instances
+
+	appInstancesUnitPath := filepath.Join(systemdBaseDir, fmt.Sprintf("%s@.service", appName))
+	err := util.InstantiateFileFromTemplate(appInstancesUnitPath,
+		appInstancesUnitContentTemplate, appParams)
+	if err != nil {
+		return err
+	}
+
+	appTargetPath := filepath.Join(systemdBaseDir, fmt.Sprintf("%s.target", appName))
+	return util.InstantiateFileFromTemplate(appTargetPath, appTargetContentTemplate, appParams)
+}
+
+// getInstanceName returns the name of the systemd unit instance for the
+// instances.yml key. The "<appName>." or "<appName>-" prefix of the key is not
+// a part of the name.
+func getInstanceName(appName string, key string) string {
+	for _, sep := range []string{".", "-"} {
+		if name := strings.TrimPrefix(key, appName+sep); name != key && name != "" {
+			return name
+		}
+	}
+	return key
+}
+
+// getAppInstances returns the instance names of the packed applications
+// described with the instances file, keyed by the application name.
+func getAppInstances(packCtx *PackCtx, opts *config.CliOpts) (map[string][]string, error) {
+	appInstances := map[string][]string{}
+	if opts.App == nil || opts.App.InstancesEnabled == "" {
+		return appInstances, nil
+	}
+	baseDir, err := filepath.Abs(opts.App.InstancesEnabled)
+	if err != nil {
+		return nil, err
+	}
+	appList, err := util.CollectAppList(baseDir, opts.App.InstancesEnabled, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range appList {
+		if packCtx.AppList != nil && util.Find(packCtx.AppList, app.Name) == -1 {
+			continue
+		}
+		instancesFile, err := util.GetYamlFileName(filepath.Join(app.Location,
+			instancesFileName), false)
+		if err != nil {
+			return nil, err
+		}
+		if !util.IsRegularFile(instancesFile) {
+			continue
+		}
+		instParams, err := util.ParseYAML(instancesFile)
+		if err != nil {
+			return nil, err
+		}
+		instances := make([]string, 0, len(instParams))
+		for key := range instParams {
+			instances = append(instances, getInstanceName(app.Name, key))
+		}
+		if len(instances) == 0 {
+			continue
+		}
+		sort.Strings(instances)
+		appInstances[app.Name] = instances
+	}
+	return appInstances, nil
+}
+
 // getUnitParams checks if there is a passed unit params file in context and
 // returns its content. Otherwise, it returns the default params.
 func getUnitParams(packCtx *PackCtx, pathToEnv,
 	envName string) (map[string]interface{}, error) {
 	ttBinary := getTTBinary(packCtx, pathToEnv)
+	user, group := getSystemUser(packCtx)
 
 	referenceParams := map[string]interface{}{
 		"TT":         ttBinary,
 		"ConfigPath": pathToEnv,
 		"FdLimit":    defaultInstanceFdLimit,
 		"EnvName":    envName,
+		"User":       user,
+		"Group":      group,
+		// Hardening directives are not set by default.
+		"ProtectSystem":   "",
+		"NoNewPrivileges": false,
+		"MemoryMax":       "",
 	}
 
 	contentParams := make(map[string]interface{})
 
 	if packCtx.RpmDeb.SystemdUnitParamsFile != "" {
-		unitTemplFile, err := os.Open(packCtx.RpmDeb.SystemdUnitParamsFile)
-		if err != nil {
-			return nil, err
-		}
-
-		err = yaml.NewDecoder(unitTemplFile).Decode(&contentParams)
+		var err error
+		contentParams, err = loadUnitParams(packCtx.RpmDeb.SystemdUnitParamsFile)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +225,93 @@ func getUnitParams(packCtx *PackCtx, pathToEnv,
 	return contentParams, nil
 }
 
+// loadUnitParams reads the systemd unit parameters file and validates it
+// against the parameters schema.
+func loadUnitParams(paramsFile string) (map[string]interface{}, error) {
+	unitParamsFile, err := os.Open(paramsFile)
+	if err != nil {
+		return nil, err
+	}
+	defer unitParamsFile.Close()
+
+	params := make(map[string]interface{})
+	if err = yaml.NewDecoder(unitParamsFile).Decode(&params); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse the systemd unit parameters file %q: %s",
+			paramsFile, err)
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		check, ok := unitParamsSchema[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid systemd unit parameters file %q: "+
+				"unknown parameter %q", paramsFile, key)
+		}
+		if params[key], err = check(params[key]); err != nil {
+			return nil, fmt.Errorf("invalid systemd unit parameters file %q: %s: %s",
+				paramsFile, key, err)
+		}
+	}
+	return params, nil
+}
+
+// checkNonEmptyString checks that the parameter value is a non-empty string.
+func checkNonEmptyString(value interface{}) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok || str == "" {
+		return nil, fmt.Errorf("expected a non-empty string, got %v", value)
+	}
+	return str, nil
+}
+
+// checkFdLimit checks that the file descriptors limit is a positive integer.
+func checkFdLimit(value interface{}) (interface{}, error) {
+	limit, ok := value.(int)
+	if !ok || limit <= 0 {
+		return nil, fmt.Errorf("expected a positive integer, got %v", value)
+	}
+	return limit, nil
+}
+
+// checkProtectSystem checks the ProtectSystem value: a boolean, full or strict.
+// The disabled protection is normalized to the empty string.
+func checkProtectSystem(value interface{}) (interface{}, error) {
+	switch value {
+	case true, "true", "yes":
+		return "true", nil
+	case false, "false", "no":
+		return "", nil
+	case "full", "strict":
+		return value, nil
+	}
+	return nil, fmt.Errorf("expected a boolean, full or strict, got %v", value)
+}
+
+// checkNoNewPrivileges checks that the NoNewPrivileges value is a boolean.
+func checkNoNewPrivileges(value interface{}) (interface{}, error) {
+	if _, ok := value.(bool); !ok {
+		return nil, fmt.Errorf("expected a boolean, got %v", value)
+	}
+	return value, nil
+}
+
+// checkMemoryMax checks the MemoryMax value: the size in bytes with an optional
+// K, M, G or T suffix, the percentage of the physical memory or infinity.
+func checkMemoryMax(value interface{}) (interface{}, error) {
+	memoryMax := fmt.Sprint(value)
+	switch value.(type) {
+	case int, string:
+		if memoryMaxRe.MatchString(memoryMax) {
+			return memoryMax, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a size, a percentage or infinity, got %v", value)
+}
+
 // getTTBinary returns a path to tt binary for the systemd ExecStart command.
 // packagePath is path to the root of the package in the target system,
 // where the package will be installed.
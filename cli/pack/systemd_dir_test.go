@@ -3,6 +3,7 @@ package pack
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -228,6 +229,8 @@ func Test_getUnitParams(t *testing.T) {
 				"ConfigPath": "/path/to/env",
 				"FdLimit":    defaultInstanceFdLimit,
 				"EnvName":    "envName",
+				"User":       "tarantool",
+				"Group":      "tarantool",
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return err != nil
@@ -245,6 +248,7 @@ func Test_getUnitParams(t *testing.T) {
 					WithoutBinaries: true,
 					RpmDeb: RpmDebCtx{
 						SystemdUnitParamsFile: filepath.Join(testDir, "partly-params.yaml"),
+						SystemUser:            "app",
 					},
 				},
 			},
@@ -253,6 +257,8 @@ func Test_getUnitParams(t *testing.T) {
 				"ConfigPath": "/path/to/env",
 				"FdLimit":    1024,
 				"EnvName":    "envName",
+				"User":       "app",
+				"Group":      "app",
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return err != nil
@@ -280,6 +286,8 @@ func Test_getUnitParams(t *testing.T) {
 				"ConfigPath": "/test/path",
 				"FdLimit":    1024,
 				"EnvName":    "testEnv",
+				"User":       "tarantool",
+				"Group":      "tarantool",
 			},
 			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 				return err != nil
@@ -307,3 +315,171 @@ func Test_getUnitParams(t *testing.T) {
 		})
 	}
 }
+
+// makeInstancesEnabled creates the instances enabled directory with the
+// multi-instance application app and the single instance application single.
+func makeInstancesEnabled(t *testing.T) string {
+	instancesEnabled := t.TempDir()
+	for _, app := range []string{"app", "single"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(instancesEnabled, app), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(instancesEnabled, app, "init.lua"),
+			[]byte{}, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(instancesEnabled, "app", "instances.yml"),
+		[]byte("router:\nmaster:\napp.replica:\nstorage-1:\napp-storage-2:\n"), 0644))
+	return instancesEnabled
+}
+
+func Test_getInstanceName(t *testing.T) {
+	for key, name := range map[string]string{
+		"router":           "router",
+		"storage-1":        "storage-1",
+		"app.storage-1":    "storage-1",
+		"app-stateboard":   "stateboard",
+		"app.":             "app.",
+		"apps.storage":     "apps.storage",
+		"other.storage-1":  "other.storage-1",
+		"app.s1-master.a1": "s1-master.a1",
+	} {
+		assert.Equal(t, name, getInstanceName("app", key), key)
+	}
+}
+
+func Test_getAppInstances(t *testing.T) {
+	opts := &config.CliOpts{App: &config.AppOpts{InstancesEnabled: makeInstancesEnabled(t)}}
+
+	appInstances, err := getAppInstances(&PackCtx{}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"app": {"master", "replica", "router", "storage-1",
+		"storage-2"}}, appInstances)
+
+	appInstances, err = getAppInstances(&PackCtx{AppList: []string{"single"}}, opts)
+	require.NoError(t, err)
+	assert.Empty(t, appInstances)
+}
+
+func Test_initSystemdDirAppInstances(t *testing.T) {
+	baseDir := t.TempDir()
+	unitsDir := filepath.Join(baseDir, "usr", "lib", "systemd", "system")
+	paramsFile := filepath.Join(t.TempDir(), "params.yaml")
+	require.NoError(t, os.WriteFile(paramsFile, []byte("ProtectSystem: strict\n"+
+		"NoNewPrivileges: true\nMemoryMax: 2G\nFdLimit: 1024\n"), 0644))
+
+	packCtx := &PackCtx{Name: "pack", WithoutBinaries: true}
+	packCtx.RpmDeb.SystemdUnitParamsFile = paramsFile
+	opts := &config.CliOpts{App: &config.AppOpts{InstancesEnabled: makeInstancesEnabled(t)}}
+	require.NoError(t, initSystemdDir(packCtx, opts, baseDir, "/path/to/env"))
+
+	units, err := os.ReadDir(unitsDir)
+	require.NoError(t, err)
+	unitNames := []string{}
+	for _, unit := range units {
+		unitNames = append(unitNames, unit.Name())
+	}
+	assert.Equal(t, []string{"app.target", "app@.service", "pack.service", "pack@.service"},
+		unitNames)
+
+	content, err := os.ReadFile(filepath.Join(unitsDir, "app@.service"))
+	require.NoError(t, err)
+	for _, line := range []string{
+		"PartOf=app.target\n",
+		"ExecStart=tt -L /path/to/env start --foreground app:%i\n",
+		"ExecStop=tt -L /path/to/env stop app:%i\n",
+		"LimitNOFILE=1024\n",
+		"ProtectSystem=strict\n",
+		"ReadWritePaths=-/path/to/env/var\n",
+		"NoNewPrivileges=yes\n",
+		"MemoryMax=2G\n",
+		"WantedBy=app.target\n",
+	} {
+		assert.Contains(t, string(content), line)
+	}
+
+	content, err = os.ReadFile(filepath.Join(unitsDir, "app.target"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content),
+		"Wants=app@master.service app@replica.service app@router.service "+
+			"app@storage-1.service app@storage-2.service\n")
+
+	content, err = os.ReadFile(filepath.Join(unitsDir, "pack.service"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "ProtectSystem=strict\n")
+	assert.Contains(t, string(content), "MemoryMax=2G\n")
+}
+
+func Test_loadUnitParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    map[string]interface{}{},
+		},
+		{
+			name: "hardening parameters",
+			content: "FdLimit: 1024\nProtectSystem: true\nNoNewPrivileges: false\n" +
+				"MemoryMax: 512M\n",
+			want: map[string]interface{}{
+				"FdLimit":         1024,
+				"ProtectSystem":   "true",
+				"NoNewPrivileges": false,
+				"MemoryMax":       "512M",
+			},
+		},
+		{
+			name:    "disabled protection",
+			content: "ProtectSystem: no\nMemoryMax: 1073741824\n",
+			want: map[string]interface{}{
+				"ProtectSystem": "",
+				"MemoryMax":     "1073741824",
+			},
+		},
+		{
+			name:    "unknown parameter",
+			content: "FdLimits: 1024\n",
+			wantErr: `unknown parameter "FdLimits"`,
+		},
+		{
+			name:    "invalid fd limit",
+			content: "FdLimit: -1\n",
+			wantErr: "FdLimit: expected a positive integer, got -1",
+		},
+		{
+			name:    "invalid protect system",
+			content: "ProtectSystem: ro\n",
+			wantErr: "ProtectSystem: expected a boolean, full or strict, got ro",
+		},
+		{
+			name:    "invalid no new privileges",
+			content: "NoNewPrivileges: 1\n",
+			wantErr: "NoNewPrivileges: expected a boolean, got 1",
+		},
+		{
+			name:    "invalid memory max",
+			content: "MemoryMax: 2X\n",
+			wantErr: "MemoryMax: expected a size, a percentage or infinity, got 2X",
+		},
+		{
+			name:    "invalid tt",
+			content: "TT: \n",
+			wantErr: "TT: expected a non-empty string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paramsFile := filepath.Join(t.TempDir(), "params.yaml")
+			require.NoError(t, os.WriteFile(paramsFile, []byte(tt.content), 0644))
+			got, err := loadUnitParams(paramsFile)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
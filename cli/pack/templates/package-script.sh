@@ -0,0 +1,83 @@
+#!/bin/sh
+# {{ .Script }} script of the {{ .Name }} package generated by tt pack.
+# tt_action is one of: install, upgrade, remove, purge.
+{{- if eq .Format "rpm" }}
+{{- if or (eq .Script "preinst") (eq .Script "postinst") }}
+if [ "$1" -gt 1 ] 2> /dev/null; then tt_action=upgrade; else tt_action=install; fi
+{{- else }}
+if [ "$1" -ge 1 ] 2> /dev/null; then tt_action=upgrade; else tt_action=remove; fi
+{{- end }}
+{{- else }}
+case "$1" in
+    configure)
+        if [ -n "$2" ]; then tt_action=upgrade; else tt_action=install; fi ;;
+    *)
+        tt_action="$1" ;;
+esac
+{{- end }}
+{{- if eq .Script "preinst" }}
+
+if [ "$tt_action" = install ] || [ "$tt_action" = upgrade ]; then
+    getent group '{{ .Group }}' > /dev/null 2>&1 || \
+        groupadd -r '{{ .Group }}' > /dev/null 2>&1 || :
+    getent passwd '{{ .User }}' > /dev/null 2>&1 || \
+        useradd -M -N -g '{{ .Group }}' -r -d '{{ .Home }}' -s /sbin/nologin \
+        -c 'Tarantool Server' '{{ .User }}' > /dev/null 2>&1 || :
+    # The common tarantool directories are kept for the existing setups.
+    mkdir -p -m 0755 /etc/tarantool/conf.d /var/lib/tarantool /var/run/tarantool || :
+    chown '{{ .User }}:{{ .Group }}' /var/lib/tarantool /var/run/tarantool || :
+fi
+{{- else if eq .Script "postinst" }}
+
+if [ "$tt_action" = install ] || [ "$tt_action" = upgrade ]; then
+    if command -v systemd-tmpfiles > /dev/null 2>&1; then
+        systemd-tmpfiles --create '{{ .Tmpfiles }}' || :
+    else
+{{- range .DataDirs }}
+        mkdir -p '{{ . }}'
+        chown '{{ $.User }}:{{ $.Group }}' '{{ . }}' || :
+        chmod 0750 '{{ . }}'
+{{- end }}
+    fi
+    if [ -d /run/systemd/system ]; then
+        systemctl daemon-reload > /dev/null 2>&1 || :
+    fi
+fi
+# The running instances are restarted with the new version.
+if [ "$tt_action" = upgrade ] && [ -d /run/systemd/system ]; then
+    systemctl try-restart '{{ .Name }}.service' '{{ .Name }}@*.service' > /dev/null 2>&1 || :
+{{- range .InstanceApps }}{{ if ne . $.Name }}
+    systemctl try-restart '{{ . }}@*.service' > /dev/null 2>&1 || :
+{{- end }}{{ end }}
+fi
+{{- else if eq .Script "prerm" }}
+
+# The instances are stopped on removal only, an upgrade restarts them.
+if [ "$tt_action" = remove ] && [ -d /run/systemd/system ]; then
+    systemctl disable --now '{{ .Name }}.service' > /dev/null 2>&1 || :
+    systemctl stop '{{ .Name }}@*.service' > /dev/null 2>&1 || :
+{{- range .InstanceApps }}
+    systemctl disable --now '{{ . }}.target' > /dev/null 2>&1 || :
+{{- if ne . $.Name }}
+    systemctl stop '{{ . }}@*.service' > /dev/null 2>&1 || :
+{{- end }}
+{{- end }}
+fi
+{{- else if eq .Script "postrm" }}
+
+if [ "$tt_action" = remove ] || [ "$tt_action" = purge ]; then
+    if [ -d /run/systemd/system ]; then
+        systemctl daemon-reload > /dev/null 2>&1 || :
+    fi
+fi
+# The data directories are kept unless the package is purged.
+if [ "$tt_action" = purge ]; then
+{{- range .DataDirs }}
+    rm -rf '{{ . }}'
+{{- end }}
+fi
+{{- end }}
+{{- if .UserScript }}
+
+{{ .UserScript }}
+{{- end }}
@@ -0,0 +1,172 @@
+#!/bin/sh
+# Self-extracting installer of {{ .Name }} {{ .Version }} generated by tt pack.
+# The gzipped tarball with the environment is appended to this script.
+set -eu
+
+NAME='{{ .Name }}'
+VERSION='{{ .Version }}'
+PAYLOAD_OFFSET={{ .PayloadOffset }}
+PREFIX_PLACEHOLDER='{{ .PrefixPlaceholder }}'
+DATA_DIR='{{ .DataDir }}'
+UNITS='{{ .Units }}'
+# MANIFEST lists the installed files relative to the installation prefix.
+MANIFEST='.tt-installed-files'
+
+prefix='{{ .Prefix }}'
+systemd_dir='/etc/systemd/system'
+mode='install'
+with_systemd=0
+purge=0
+
+usage() {
+    cat <<EOF
+Usage: $0 [OPTIONS]
+
+Install $NAME $VERSION.
+
+Options:
+  --prefix DIR       Installation directory. Default: $prefix
+  --upgrade          Upgrade the existing installation keeping the $DATA_DIR directory.
+  --uninstall        Remove the existing installation keeping the $DATA_DIR directory.
+  --purge            Remove the $DATA_DIR directory too on uninstall.
+  --with-systemd     Install or remove the systemd units.
+  --systemd-dir DIR  Directory for the systemd units. Default: $systemd_dir
+  -h, --help         Show this help.
+EOF
+}
+
+die() {
+    echo "$0: $*" >&2
+    exit 1
+}
+
+while [ $# -gt 0 ]; do
+    case "$1" in
+        --prefix)
+            [ $# -gt 1 ] || die "--prefix requires an argument"
+            prefix="$2"
+            shift
+            ;;
+        --prefix=*) prefix="${1#*=}" ;;
+        --systemd-dir)
+            [ $# -gt 1 ] || die "--systemd-dir requires an argument"
+            systemd_dir="$2"
+            shift
+            ;;
+        --systemd-dir=*) systemd_dir="${1#*=}" ;;
+        --upgrade) mode='upgrade' ;;
+        --uninstall) mode='uninstall' ;;
+        --purge) purge=1 ;;
+        --with-systemd) with_systemd=1 ;;
+        -h|--help)
+            usage
+            exit 0
+            ;;
+        *) die "unknown option: $1" ;;
+    esac
+    shift
+done
+
+[ -n "$prefix" ] || die "the installation prefix is empty"
+case "$prefix" in
+    /*) ;;
+    *) prefix="$(pwd)/$prefix" ;;
+esac
+prefix="${prefix%/}"
+
+# remove_env removes the installed files listed in the manifest, the data
+# directory is removed only if the first argument is 1. Directories are kept
+# if they contain files that were not installed.
+remove_env() {
+    [ -f "$prefix/$MANIFEST" ] || die "the list of installed files $prefix/$MANIFEST is not found"
+    sort -r "$prefix/$MANIFEST" | while IFS= read -r entry; do
+        entry="$prefix/${entry#./}"
+        if [ -d "$entry" ] && [ ! -L "$entry" ]; then
+            rmdir "$entry" 2>/dev/null || true
+        elif [ -e "$entry" ] || [ -L "$entry" ]; then
+            rm -f "$entry"
+        fi
+    done
+    rm -f "$prefix/$MANIFEST"
+    if [ "$1" -eq 1 ]; then
+        rm -rf "$prefix/$DATA_DIR"
+    fi
+}
+
+# write_manifest lists the files of the environment except the data directory.
+write_manifest() {
+    (cd "$staging/env" && find . -mindepth 1 -path "./$DATA_DIR" -prune -o -print) \
+        > "$prefix/$MANIFEST"
+}
+
+reload_systemd() {
+    if command -v systemctl >/dev/null 2>&1 && [ -d /run/systemd/system ]; then
+        systemctl daemon-reload
+    fi
+}
+
+install_units() {
+    mkdir -p "$systemd_dir"
+    # The prefix is escaped to be used as the sed replacement.
+    sed_prefix="$(printf '%s\n' "$prefix" | sed 's/[\\|&]/\\&/g')"
+    for unit in "$staging/systemd/"*.service "$staging/systemd/"*.target; do
+        [ -f "$unit" ] || continue
+        sed "s|$PREFIX_PLACEHOLDER|$sed_prefix|g" "$unit" > "$systemd_dir/${unit##*/}"
+        echo "Installed systemd unit $systemd_dir/${unit##*/}"
+    done
+    reload_systemd
+}
+
+remove_units() {
+    for unit in $UNITS; do
+        unit="$systemd_dir/$unit"
+        if [ -f "$unit" ]; then
+            rm -f "$unit"
+            echo "Removed systemd unit $unit"
+        fi
+    done
+    reload_systemd
+}
+
+if [ "$mode" = 'uninstall' ]; then
+    [ -f "$prefix/tt.yaml" ] || die "$NAME is not installed in $prefix"
+    if [ "$with_systemd" -eq 1 ]; then
+        remove_units
+    fi
+    remove_env "$purge"
+    rmdir "$prefix" 2>/dev/null || true
+    echo "$NAME is uninstalled from $prefix"
+    exit 0
+fi
+
+if [ "$mode" = 'install' ] && [ -f "$prefix/tt.yaml" ]; then
+    die "$NAME is already installed in $prefix, use --upgrade to upgrade it"
+fi
+if [ "$mode" = 'upgrade' ] && [ ! -f "$prefix/tt.yaml" ]; then
+    die "$NAME is not installed in $prefix"
+fi
+
+staging="$(mktemp -d)"
+trap 'rm -rf "$staging"' EXIT
+tail -c +$((PAYLOAD_OFFSET + 1)) "$0" | tar -xzf - -C "$staging"
+
+mkdir -p "$prefix"
+if [ "$mode" = 'upgrade' ]; then
+    remove_env 0
+    if [ -d "$prefix/$DATA_DIR" ]; then
+        rm -rf "$staging/env/$DATA_DIR"
+    fi
+fi
+(cd "$staging/env" && tar -cf - .) | (cd "$prefix" && tar -xpf -)
+write_manifest
+
+if [ "$with_systemd" -eq 1 ]; then
+    install_units
+fi
+
+if [ "$mode" = 'upgrade' ]; then
+    echo "$NAME is upgraded to $VERSION in $prefix"
+else
+    echo "$NAME $VERSION is installed to $prefix"
+fi
+exit 0
@@ -10,29 +10,48 @@ import (
 	"strings"
 
 	"github.com/tarantool/tt/cli/configure"
+	"github.com/tarantool/tt/cli/util"
 )
 
-// WriteTgzArchive creates TGZ archive of specified path.
-func WriteTgzArchive(srcDirPath string, destFilePath string) error {
+// WriteTgzArchive creates TGZ archive of specified path. If reproducible is set,
+// the owner of the archived items is set to root.
+func WriteTgzArchive(srcDirPath string, destFilePath string, reproducible bool) error {
+	return WriteCompressedTarArchive(srcDirPath, destFilePath, util.CompressionOpts{},
+		reproducible)
+}
+
+// WriteCompressedTarArchive creates the tar archive of specified path compressed
+// with gzip, xz or zstd. If reproducible is set, the owner of the archived items
+// is set to root.
+func WriteCompressedTarArchive(srcDirPath string, destFilePath string,
+	compression util.CompressionOpts, reproducible bool) error {
 	destFile, err := os.Create(destFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to create result TGZ file %s: %s", destFilePath, err)
+		return fmt.Errorf("failed to create result archive %s: %s", destFilePath, err)
 	}
+	defer destFile.Close()
 
-	gzipWriter := gzip.NewWriter(destFile)
-	defer gzipWriter.Close()
-
-	err = WriteTarArchive(srcDirPath, gzipWriter)
+	compressWriter, err := util.NewCompressWriter(destFile, compression)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = WriteTarArchive(srcDirPath, compressWriter, reproducible)
+	// Close flushes the compressed data. It must be called once: the zstd
+	// writer writes the frame checksum on every call.
+	if closeErr := compressWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return destFile.Close()
 }
 
 // WriteTarArchive creates Tar archive of specified path
-// using specified writer
-func WriteTarArchive(srcDirPath string, compressWriter io.Writer) error {
+// using specified writer. If reproducible is set, the owner
+// of the archived items is set to root.
+func WriteTarArchive(srcDirPath string, compressWriter io.Writer, reproducible bool) error {
 	tarWriter := tar.NewWriter(compressWriter)
 	defer tarWriter.Close()
 
@@ -55,7 +74,13 @@ func WriteTarArchive(srcDirPath string, compressWriter io.Writer) error {
 				return err
 			}
 		} else {
-			tarHeader, err = tar.FileInfoHeader(fileInfo, filePath)
+			link := ""
+			if fileInfo.Mode().Type() == os.ModeSymlink {
+				if link, err = os.Readlink(filePath); err != nil {
+					return err
+				}
+			}
+			tarHeader, err = tar.FileInfoHeader(fileInfo, link)
 			if err != nil {
 				return err
 			}
@@ -65,6 +90,9 @@ func WriteTarArchive(srcDirPath string, compressWriter io.Writer) error {
 		if err != nil {
 			return err
 		}
+		if reproducible {
+			normalizeTarHeader(tarHeader)
+		}
 
 		if err := tarWriter.WriteHeader(tarHeader); err != nil {
 			return err
@@ -85,6 +113,13 @@ func WriteTarArchive(srcDirPath string, compressWriter io.Writer) error {
 
 // CompressGzip compresses specified file with gzip.BestCompression level.
 func CompressGzip(srcFilePath string, destFilePath string) error {
+	return CompressFile(srcFilePath, destFilePath,
+		util.CompressionOpts{Type: util.CompressionGzip, Level: gzip.BestCompression})
+}
+
+// CompressFile compresses specified file with gzip, xz or zstd.
+func CompressFile(srcFilePath string, destFilePath string,
+	compression util.CompressionOpts) error {
 	// Src file reader.
 	srcFileReader, err := os.Open(srcFilePath)
 	if err != nil {
@@ -95,24 +130,29 @@ func CompressGzip(srcFilePath string, destFilePath string) error {
 	// Dest file writer.
 	destFile, err := os.Create(destFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to create result GZIP file %s: %s", destFilePath, err)
+		return fmt.Errorf("failed to create result %s file %s: %s", compression.GetType(),
+			destFilePath, err)
 	}
 	defer destFile.Close()
 
-	// Dest file GZIP writer.
-	gzipWriter, err := gzip.NewWriterLevel(destFile, gzip.BestCompression)
+	// Dest file compressing writer.
+	compressWriter, err := util.NewCompressWriter(destFile, compression)
 	if err != nil {
 		_ = os.Remove(destFilePath)
-		return fmt.Errorf("failed to create GZIP writer %s: %s", destFilePath, err)
+		return fmt.Errorf("failed to create %s writer %s: %s", compression.GetType(),
+			destFilePath, err)
 	}
-	defer gzipWriter.Flush()
 
 	// Compressing itself.
-	if _, err := io.Copy(gzipWriter, srcFileReader); err != nil {
+	_, err = io.Copy(compressWriter, srcFileReader)
+	// Close writes the footer with the checksum and the size.
+	if closeErr := compressWriter.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		_ = os.Remove(destFilePath)
 		return err
 	}
-
 	return nil
 }
 
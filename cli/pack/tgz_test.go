@@ -0,0 +1,102 @@
+package pack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/util"
+)
+
+func TestWriteTgzArchiveSymlinks(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "app", "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "app", "init.lua"), []byte{}, 0644))
+	require.NoError(t, os.Symlink("init.lua", filepath.Join(srcDir, "app", "main.lua")))
+	require.NoError(t, os.Symlink(filepath.Join("..", "init.lua"),
+		filepath.Join(srcDir, "app", "lib", "init.lua")))
+
+	tgzPath := filepath.Join(t.TempDir(), "package.tar.gz")
+	require.NoError(t, WriteTgzArchive(srcDir, tgzPath, false))
+
+	tgzFile, err := os.Open(tgzPath)
+	require.NoError(t, err)
+	defer tgzFile.Close()
+	gzipReader, err := gzip.NewReader(tgzFile)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	links := map[string]string{}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeSymlink {
+			links[header.Name] = header.Linkname
+		}
+	}
+	// The link targets are kept as is, not replaced with the link paths.
+	assert.Equal(t, map[string]string{
+		filepath.Join("app", "main.lua"):        "init.lua",
+		filepath.Join("app", "lib", "init.lua"): filepath.Join("..", "init.lua"),
+	}, links)
+}
+
+func TestCompressGzip(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "cpio")
+	data := []byte("payload")
+	require.NoError(t, os.WriteFile(srcPath, data, 0644))
+
+	destPath := filepath.Join(dir, "cpio.gz")
+	require.NoError(t, CompressGzip(srcPath, destPath))
+	destFile, err := os.Open(destPath)
+	require.NoError(t, err)
+	defer destFile.Close()
+	gzipReader, err := gzip.NewReader(destFile)
+	require.NoError(t, err)
+	// The whole stream is read to check the footer with the checksum.
+	decompressed, err := io.ReadAll(gzipReader)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+}
+
+func TestCompressFile(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "cpio")
+	// The large file is compressed in several blocks.
+	data := make([]byte, 4<<20)
+	_, err := rand.Read(data[:1<<20])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(srcPath, data, 0644))
+
+	for _, compression := range []util.CompressionOpts{
+		{Type: util.CompressionGzip, Level: 9},
+		{Type: util.CompressionXz},
+		{Type: util.CompressionZstd},
+	} {
+		destPath := filepath.Join(dir, "cpio"+compression.Ext())
+		require.NoError(t, CompressFile(srcPath, destPath, compression))
+
+		destFile, err := os.Open(destPath)
+		require.NoError(t, err)
+		reader, err := util.NewDecompressReader(destFile)
+		require.NoError(t, err)
+		// The whole stream is read to check there is no trailing data.
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err, compression.Type)
+		assert.Equal(t, data, decompressed)
+		reader.Close()
+		destFile.Close()
+	}
+
+	assert.Error(t, CompressFile(srcPath, filepath.Join(dir, "cpio.bz2"),
+		util.CompressionOpts{Type: "bzip2"}))
+}
@@ -0,0 +1,189 @@
+package process_utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// procDir is the mount point of the proc file system.
+	procDir = "/proc"
+	// identitySuffix is added to the PID file name to get the name of the file
+	// with the process identity.
+	identitySuffix = ".identity"
+	// startTimeKey is the identity file key of the process start time.
+	startTimeKey = "start_time"
+	// exeKey is the identity file key of the process executable.
+	exeKey = "exe"
+	// cmdlineKey is the identity file key of the process command line.
+	cmdlineKey = "cmdline"
+	// deletedSuffix is added to the executable link if the file is removed,
+	// for example, on the package upgrade.
+	deletedSuffix = " (deleted)"
+)
+
+// ProcessIdentity describes the process to tell it from another process that
+// got the same PID later.
+type ProcessIdentity struct {
+	// StartTime is the time the process started after the system boot
+	// in clock ticks.
+	StartTime uint64
+	// Executable is the path to the process executable.
+	Executable string
+	// Cmdline is the process command line.
+	Cmdline string
+}
+
+// isProcfsAvailable checks if the proc file system is mounted.
+func isProcfsAvailable() bool {
+	_, err := os.Stat(filepath.Join(procDir, "self", "stat"))
+	return err == nil
+}
+
+// readStartTime reads the process start time from /proc/<pid>/stat.
+func readStartTime(pid int) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	// The command name in the second field may contain spaces and parentheses,
+	// so the fields are counted after the last parenthesis.
+	stat := string(data)
+	closing := strings.LastIndexByte(stat, ')')
+	if closing == -1 {
+		return 0, fmt.Errorf("unexpected format of the process stat")
+	}
+	// The start time is the 22nd field, the first field after the command name
+	// is the 3rd one.
+	fields := strings.Fields(stat[closing+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("unexpected format of the process stat")
+	}
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// GetProcessIdentity returns the identity of the running process. The
+// executable and the command line are left empty if they can't be read, for
+// example, due to the lack of permissions.
+func GetProcessIdentity(pid int) (ProcessIdentity, error) {
+	startTime, err := readStartTime(pid)
+	if err != nil {
+		return ProcessIdentity{}, err
+	}
+	identity := ProcessIdentity{StartTime: startTime}
+	procPidDir := filepath.Join(procDir, strconv.Itoa(pid))
+	if exe, err := os.Readlink(filepath.Join(procPidDir, "exe")); err == nil {
+		identity.Executable = strings.TrimSuffix(exe, deletedSuffix)
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(procPidDir, "cmdline")); err == nil {
+		// Arguments are joined with single spaces: the command line is stored
+		// on a single line of the identity file.
+		identity.Cmdline = strings.Join(strings.Fields(
+			strings.ReplaceAll(string(cmdline), "\x00", " ")), " ")
+	}
+	return identity, nil
+}
+
+// Matches checks if the running process identity matches the recorded one.
+// Fields that are unknown in any of the identities are not compared.
+func (identity ProcessIdentity) Matches(running ProcessIdentity) bool {
+	if identity.StartTime != 0 && running.StartTime != 0 &&
+		identity.StartTime != running.StartTime {
+		return false
+	}
+	if identity.Executable != "" && running.Executable != "" &&
+		identity.Executable != running.Executable {
+		return false
+	}
+	if identity.Cmdline != "" && running.Cmdline != "" &&
+		identity.Cmdline != running.Cmdline {
+		return false
+	}
+	return true
+}
+
+// identityFileName returns the name of the file with the identity of the
+// process that created the PID file.
+func identityFileName(pidFileName string) string {
+	return pidFileName + identitySuffix
+}
+
+// formatIdentity formats the identity as the identity file lines.
+func formatIdentity(identity ProcessIdentity) string {
+	return fmt.Sprintf("%s: %d\n%s: %s\n%s: %s\n", startTimeKey, identity.StartTime,
+		exeKey, identity.Executable, cmdlineKey, identity.Cmdline)
+}
+
+// parseIdentity parses the identity file content: the "key: value" lines.
+func parseIdentity(content string) (ProcessIdentity, error) {
+	var identity ProcessIdentity
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case startTimeKey:
+			startTime, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return ProcessIdentity{}, fmt.Errorf("invalid %s: %s", startTimeKey, err)
+			}
+			identity.StartTime = startTime
+		case exeKey:
+			identity.Executable = value
+		case cmdlineKey:
+			identity.Cmdline = value
+		}
+	}
+	return identity, scanner.Err()
+}
+
+// readIdentity reads the identity of the process that created the PID file.
+// Nil is returned if the identity file does not exist.
+func readIdentity(pidFileName string) (*ProcessIdentity, error) {
+	content, err := os.ReadFile(identityFileName(pidFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	identity, err := parseIdentity(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// writeIdentity writes the identity of the current process next to the PID
+// file. The file is renamed into place, so it is never read partially.
+func writeIdentity(pidFileName string) error {
+	identity, err := GetProcessIdentity(os.Getpid())
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(pidFileName),
+		"."+filepath.Base(identityFileName(pidFileName))+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = tmpFile.WriteString(formatIdentity(identity)); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Chmod(0644); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), identityFileName(pidFileName))
+}
@@ -56,28 +56,95 @@ func (procState ProcessState) String() string {
 	return procState.Status
 }
 
-// GetPIDFromFile returns PID from the PIDFile.
-func GetPIDFromFile(pidFileName string) (int, error) {
+// readPIDFile reads PID from the PIDFile and the process identity from the
+// identity file next to it. The identity is nil if there is no identity file.
+func readPIDFile(pidFileName string) (int, *ProcessIdentity, error) {
 	if _, err := os.Stat(pidFileName); err != nil {
-		return 0, fmt.Errorf(`can't "stat" the PID file. Error: "%v"`, err)
+		return 0, nil, fmt.Errorf(`can't "stat" the PID file. Error: "%v"`, err)
 	}
 
 	pidFile, err := os.Open(pidFileName)
 	if err != nil {
-		return 0, fmt.Errorf(`can't open the PID file. Error: "%v"`, err)
+		return 0, nil, fmt.Errorf(`can't open the PID file. Error: "%v"`, err)
 	}
+	defer pidFile.Close()
 
 	pidBytes, err := ioutil.ReadAll(pidFile)
 	if err != nil {
-		return 0, fmt.Errorf(`can't read the PID file. Error: "%v"`, err)
+		return 0, nil, fmt.Errorf(`can't read the PID file. Error: "%v"`, err)
 	}
 
 	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
 	if err != nil {
-		return 0,
+		return 0, nil,
 			fmt.Errorf(`pID file exists with unknown format. Error: "%s"`, err)
 	}
 
+	identity, err := readIdentity(pidFileName)
+	if err != nil {
+		return 0, nil, fmt.Errorf(`can't read the process identity. Error: "%v"`, err)
+	}
+
+	return pid, identity, nil
+}
+
+// GetPIDFromFile returns PID from the PIDFile.
+func GetPIDFromFile(pidFileName string) (int, error) {
+	pid, _, err := readPIDFile(pidFileName)
+	return pid, err
+}
+
+// isPIDFileLocked checks if the PID file is locked by a process.
+func isPIDFileLocked(pidFileName string) (bool, error) {
+	pidFile, err := os.Open(pidFileName)
+	if err != nil {
+		return false, err
+	}
+	defer pidFile.Close()
+
+	err = syscall.Flock(int(pidFile.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	syscall.Flock(int(pidFile.Fd()), syscall.LOCK_UN)
+	return false, nil
+}
+
+// GetVerifiedPID returns PID from the PIDFile if the process is alive and it
+// is the process that created the PID file. The PID file created by
+// CreatePIDFile is locked by the process and its identity is stored next to
+// it: after the process termination the PID can be reused by an unrelated
+// process.
+func GetVerifiedPID(pidFileName string) (int, error) {
+	pid, identity, err := readPIDFile(pidFileName)
+	if err != nil {
+		return 0, err
+	}
+
+	if alive, err := IsProcessAlive(pid); !alive {
+		return pid, fmt.Errorf(`the process is dead. Error: "%v"`, err)
+	}
+
+	if identity == nil {
+		// There is no identity of the process next to the PID file.
+		return pid, nil
+	}
+
+	if locked, err := isPIDFileLocked(pidFileName); err == nil && !locked {
+		return pid, fmt.Errorf("the PID file is not locked, PID %d belongs to "+
+			"another process", pid)
+	}
+
+	if isProcfsAvailable() {
+		running, err := GetProcessIdentity(pid)
+		if err == nil && !identity.Matches(running) {
+			return pid, fmt.Errorf("PID %d belongs to another process", pid)
+		}
+	}
+
 	return pid, nil
 }
 
@@ -87,14 +154,13 @@ func GetPIDFromFile(pidFileName string) (int, error) {
 func CheckPIDFile(pidFileName string) error {
 	if _, err := os.Stat(pidFileName); err == nil {
 		// The PID file already exists. We have to check if the process is alive.
-		pid, err := GetPIDFromFile(pidFileName)
-		if err != nil {
+		if _, _, err := readPIDFile(pidFileName); err != nil {
 			return fmt.Errorf(`pID file exists, but PID can't be read. Error: "%v"`, err)
 		}
-		if res, _ := IsProcessAlive(pid); res {
+		if pid, err := GetVerifiedPID(pidFileName); err == nil {
 			return fmt.Errorf("the process already exists. PID: %d", pid)
 		} else {
-			os.Remove(pidFileName)
+			RemovePIDFile(pidFileName)
 		}
 	} else if !os.IsNotExist(err) {
 		return fmt.Errorf(`something went wrong while trying to read the PID file. Error: "%v"`,
@@ -105,10 +171,13 @@ func CheckPIDFile(pidFileName string) error {
 }
 
 // CreatePIDFile checks that the instance PID file is absent or
-// deprecated and creates a new one. Returns an error on failure.
-func CreatePIDFile(pidFileName string) error {
+// deprecated and creates a new one. The PID file contains the PID of the
+// current process, its identity is written to the identity file next to the
+// PID file. The returned file holds an exclusive lock on the PID file and must
+// be kept open while the process is running.
+func CreatePIDFile(pidFileName string) (*os.File, error) {
 	if err := CheckPIDFile(pidFileName); err != nil {
-		return err
+		return nil, err
 	}
 
 	pidAbsDir := filepath.Dir(pidFileName)
@@ -116,41 +185,73 @@ func CreatePIDFile(pidFileName string) error {
 		if os.IsNotExist(err) {
 			err = os.MkdirAll(pidAbsDir, defaultDirPerms)
 			if err != nil {
-				return fmt.Errorf(`can't crete PID file directory. Error: "%v"`, err)
+				return nil, fmt.Errorf(`can't crete PID file directory. Error: "%v"`, err)
 			}
 		} else {
-			return fmt.Errorf(`can't stat PID file directory. Error: "%v"`, err)
+			return nil, fmt.Errorf(`can't stat PID file directory. Error: "%v"`, err)
 		}
 	}
 
-	// Create a new PID file.
+	// The PID file is written and locked under a temporary name and then
+	// linked to the PID file name, so a concurrent check never sees an
+	// incomplete PID file. Unlike rename, the link fails if the PID file has
+	// been created by another process meanwhile.
+	pidFile, err := os.CreateTemp(pidAbsDir, "."+filepath.Base(pidFileName)+".*")
+	if err != nil {
+		return nil, fmt.Errorf(`can't create a new PID file. Error: "%v"`, err)
+	}
+	tmpName := pidFile.Name()
+	defer os.Remove(tmpName)
+
+	if err = syscall.Flock(int(pidFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pidFile.Close()
+		return nil, fmt.Errorf(`can't lock the PID file. Error: "%v"`, err)
+	}
+
+	if _, err = pidFile.WriteString(strconv.Itoa(os.Getpid())); err != nil {
+		pidFile.Close()
+		return nil, err
+	}
 	// 0644:
 	//    user:   read/write
 	//    group:  read
 	//    others: read
-	pidFile, err := os.OpenFile(pidFileName,
-		syscall.O_EXCL|syscall.O_CREAT|syscall.O_RDWR, 0644)
-	if err != nil {
-		return fmt.Errorf(`can't create a new PID file. Error: "%v"`, err)
+	if err = pidFile.Chmod(0644); err != nil {
+		pidFile.Close()
+		return nil, err
 	}
-	defer pidFile.Close()
 
-	if _, err = pidFile.WriteString(strconv.Itoa(os.Getpid())); err != nil {
-		return err
+	if err = os.Link(tmpName, pidFileName); err != nil {
+		pidFile.Close()
+		return nil, fmt.Errorf(`can't create a new PID file. Error: "%v"`, err)
+	}
+	// The identity is written by the process that has created the PID file only.
+	// The PID file without the identity is trusted if it is locked.
+	if isProcfsAvailable() {
+		if err = writeIdentity(pidFileName); err != nil {
+			RemovePIDFile(pidFileName)
+			pidFile.Close()
+			return nil, fmt.Errorf(`can't write the process identity. Error: "%v"`, err)
+		}
 	}
 
-	return nil
+	return pidFile, nil
+}
+
+// RemovePIDFile removes the PID file and the process identity file.
+func RemovePIDFile(pidFileName string) {
+	os.Remove(pidFileName)
+	os.Remove(identityFileName(pidFileName))
 }
 
 // StopProcess stops the process by pidFile.
 func StopProcess(pidFile string) (int, error) {
-	pid, err := GetPIDFromFile(pidFile)
-	if err != nil {
+	if _, err := GetPIDFromFile(pidFile); err != nil {
 		return 0, err
 	}
 
-	alive, err := IsProcessAlive(pid)
-	if !alive {
+	pid, err := GetVerifiedPID(pidFile)
+	if err != nil {
 		return 0, fmt.Errorf(`the process is already dead. Error: "%v"`, err)
 	}
 
@@ -167,13 +268,12 @@ func StopProcess(pidFile string) (int, error) {
 
 // ProcessStatus returns the status of the process.
 func ProcessStatus(pidFile string) ProcessState {
-	pid, err := GetPIDFromFile(pidFile)
-	if err != nil {
+	if _, err := GetPIDFromFile(pidFile); err != nil {
 		return ProcStateStopped
 	}
 
-	alive, _ := IsProcessAlive(pid)
-	if !alive {
+	pid, err := GetVerifiedPID(pidFile)
+	if err != nil {
 		return ProcStateDead
 	}
 
@@ -0,0 +1,162 @@
+package process_utils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIdentity(t *testing.T) {
+	identity, err := parseIdentity("")
+	require.NoError(t, err)
+	assert.Equal(t, ProcessIdentity{}, identity)
+
+	identity, err = parseIdentity(
+		"start_time: 789\nexe: /usr/bin/tt\ncmdline: tt start --watchdog app\n")
+	require.NoError(t, err)
+	assert.Equal(t, ProcessIdentity{StartTime: 789, Executable: "/usr/bin/tt",
+		Cmdline: "tt start --watchdog app"}, identity)
+
+	_, err = parseIdentity("start_time: soon\n")
+	assert.Error(t, err)
+}
+
+func TestProcessIdentityMatches(t *testing.T) {
+	identity := ProcessIdentity{StartTime: 100, Executable: "/usr/bin/tt"}
+	assert.True(t, identity.Matches(identity))
+	assert.True(t, identity.Matches(ProcessIdentity{StartTime: 100}))
+	assert.False(t, identity.Matches(ProcessIdentity{StartTime: 101,
+		Executable: "/usr/bin/tt"}))
+	assert.False(t, identity.Matches(ProcessIdentity{StartTime: 100,
+		Executable: "/usr/bin/sleep"}))
+
+	identity.Cmdline = "tt start --watchdog app"
+	assert.True(t, identity.Matches(ProcessIdentity{StartTime: 100}))
+	assert.True(t, identity.Matches(ProcessIdentity{StartTime: 100,
+		Cmdline: "tt start --watchdog app"}))
+	assert.False(t, identity.Matches(ProcessIdentity{StartTime: 100,
+		Cmdline: "tt start --watchdog app2"}))
+}
+
+func TestCreatePIDFile(t *testing.T) {
+	pidFileName := filepath.Join(t.TempDir(), "run", "inst.pid")
+	pidFile, err := CreatePIDFile(pidFileName)
+	require.NoError(t, err)
+	defer pidFile.Close()
+
+	// The PID file contains the PID only.
+	content, err := os.ReadFile(pidFileName)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid()), string(content))
+
+	pid, identity, err := readPIDFile(pidFileName)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	if isProcfsAvailable() {
+		assert.FileExists(t, identityFileName(pidFileName))
+		require.NotNil(t, identity)
+		assert.NotZero(t, identity.StartTime)
+		assert.NotEmpty(t, identity.Executable)
+		assert.NotEmpty(t, identity.Cmdline)
+	}
+
+	locked, err := isPIDFileLocked(pidFileName)
+	require.NoError(t, err)
+	assert.True(t, locked)
+
+	verifiedPid, err := GetVerifiedPID(pidFileName)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), verifiedPid)
+	assert.Equal(t, ProcessRunningCode, ProcessStatus(pidFileName).Code)
+	assert.Error(t, CheckPIDFile(pidFileName))
+
+	// The PID is alive, but the lock is released: the PID file is stale.
+	pidFile.Close()
+	locked, err = isPIDFileLocked(pidFileName)
+	require.NoError(t, err)
+	assert.False(t, locked)
+	if identity != nil {
+		_, err = GetVerifiedPID(pidFileName)
+		assert.Error(t, err)
+		assert.Equal(t, ProcessDeadCode, ProcessStatus(pidFileName).Code)
+		require.NoError(t, CheckPIDFile(pidFileName))
+		assert.NoFileExists(t, pidFileName)
+		assert.NoFileExists(t, identityFileName(pidFileName))
+	}
+}
+
+func TestCreatePIDFileConcurrent(t *testing.T) {
+	pidDir := t.TempDir()
+	pidFileName := filepath.Join(pidDir, "inst.pid")
+	const creators = 50
+	files := make(chan *os.File, creators)
+	var wg sync.WaitGroup
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Concurrent checks must not remove the PID file being created.
+			if pidFile, err := CreatePIDFile(pidFileName); err == nil {
+				files <- pidFile
+			}
+		}()
+	}
+	wg.Wait()
+	close(files)
+
+	created := 0
+	for pidFile := range files {
+		defer pidFile.Close()
+		created++
+	}
+	assert.Equal(t, 1, created)
+	locked, err := isPIDFileLocked(pidFileName)
+	require.NoError(t, err)
+	assert.True(t, locked)
+
+	// Temporary files are removed.
+	entries, err := os.ReadDir(pidDir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if isProcfsAvailable() {
+		assert.Equal(t, []string{"inst.pid", "inst.pid.identity"}, names)
+	} else {
+		assert.Equal(t, []string{"inst.pid"}, names)
+	}
+}
+
+func TestGetVerifiedPIDAnotherProcess(t *testing.T) {
+	if !isProcfsAvailable() {
+		t.Skip("the proc file system is not available")
+	}
+	pidFileName := filepath.Join(t.TempDir(), "inst.pid")
+	pidFile, err := CreatePIDFile(pidFileName)
+	require.NoError(t, err)
+	defer pidFile.Close()
+
+	// The PID file is locked, but the recorded identity differs from the
+	// identity of the process with the PID.
+	content := strings.Join([]string{"start_time: 1", "exe: /usr/bin/tt", ""}, "\n")
+	require.NoError(t, os.WriteFile(identityFileName(pidFileName), []byte(content), 0644))
+
+	_, err = GetVerifiedPID(pidFileName)
+	assert.ErrorContains(t, err, "belongs to another process")
+	assert.Equal(t, ProcessDeadCode, ProcessStatus(pidFileName).Code)
+}
+
+func TestGetVerifiedPIDLegacy(t *testing.T) {
+	pidFileName := filepath.Join(t.TempDir(), "inst.pid")
+	require.NoError(t, os.WriteFile(pidFileName, []byte(strconv.Itoa(os.Getpid())), 0644))
+	pid, err := GetVerifiedPID(pidFileName)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
@@ -0,0 +1,318 @@
+package running
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/tarantool/tt/cli/util"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// crashRecordFile is the name of the crash record file in the crash directory.
+	crashRecordFile = "crash.yml"
+	// crashLogFile is the name of the file with the last instance log lines.
+	crashLogFile = "tarantool.log"
+	// crashLogLines is the number of the last log lines saved on crash.
+	crashLogLines = 100
+	// crashDirTimeLayout is the time layout of the crash directory names.
+	crashDirTimeLayout = "20060102-150405"
+	// corePatternFile contains the kernel core file name pattern.
+	corePatternFile = "/proc/sys/kernel/core_pattern"
+	// coreUsesPidFile contains 1 if the PID is added to the core file name.
+	coreUsesPidFile = "/proc/sys/kernel/core_uses_pid"
+	// crashDirsToKeep is the number of the last crash directories kept for an
+	// instance, older ones are removed.
+	crashDirsToKeep = 5
+	// maxCoreCopySize is the maximum size of the core file copied to the crash
+	// directory from another file system. A larger core file is left in place.
+	maxCoreCopySize = 1 << 30
+)
+
+// CrashConfig describes the instance configuration at the moment of a crash.
+type CrashConfig struct {
+	// AppPath is a path to the application init file.
+	AppPath string `yaml:"app_path"`
+	// WalDir is a directory where write-ahead log (.xlog) files are stored.
+	WalDir string `yaml:"wal_dir"`
+	// MemtxDir is a directory where memtx stores snapshot (.snap) files.
+	MemtxDir string `yaml:"memtx_dir"`
+	// VinylDir is a directory where vinyl files or subdirectories are stored.
+	VinylDir string `yaml:"vinyl_dir"`
+	// Env contains environment variables passed to the instance.
+	Env map[string]string `yaml:"env,omitempty"`
+	// EnvFile is a path to the file with environment variables.
+	EnvFile string `yaml:"env_file,omitempty"`
+	// Rlimits contains resource limits of the instance process.
+	Rlimits []string `yaml:"rlimits,omitempty"`
+	// Tags contains the instance tags.
+	Tags map[string]string `yaml:"tags,omitempty"`
+}
+
+// CrashRecord describes a crash of the instance.
+type CrashRecord struct {
+	// Instance is the full instance name.
+	Instance string `yaml:"instance"`
+	// PID is the PID of the crashed instance process.
+	PID int `yaml:"pid"`
+	// Signal is the name of the signal the instance was terminated by.
+	Signal string `yaml:"signal"`
+	// SignalNumber is the number of the signal.
+	SignalNumber int `yaml:"signal_number"`
+	// ExitCode is the exit code in the shell convention: 128 + signal number.
+	ExitCode int `yaml:"exit_code"`
+	// CoreDumped is set if the kernel has dumped the core.
+	CoreDumped bool `yaml:"core_dumped"`
+	// CoreFile is a path to the core file if it has been found.
+	CoreFile string `yaml:"core_file,omitempty"`
+	// CoreHandler is a program the core is piped to, for example,
+	// systemd-coredump.
+	CoreHandler string `yaml:"core_handler,omitempty"`
+	// StartTime is the time the instance was started.
+	StartTime time.Time `yaml:"start_time"`
+	// CrashTime is the time the crash was detected.
+	CrashTime time.Time `yaml:"crash_time"`
+	// TarantoolExecutable is a path to the tarantool executable.
+	TarantoolExecutable string `yaml:"tarantool_executable,omitempty"`
+	// TarantoolVersion is the tarantool version.
+	TarantoolVersion string `yaml:"tarantool_version,omitempty"`
+	// Config is the instance configuration.
+	Config CrashConfig `yaml:"config"`
+}
+
+// newCrashRecord creates a crash record of the instance terminated by a signal.
+func newCrashRecord(run *InstanceCtx, pid int, status syscall.WaitStatus,
+	startTime time.Time) CrashRecord {
+	record := CrashRecord{
+		Instance:     GetAppInstanceName(*run),
+		PID:          pid,
+		Signal:       signalName(status.Signal()),
+		SignalNumber: int(status.Signal()),
+		ExitCode:     128 + int(status.Signal()),
+		CoreDumped:   status.CoreDump(),
+		StartTime:    startTime,
+		CrashTime:    time.Now(),
+		Config: CrashConfig{
+			AppPath:  run.AppPath,
+			WalDir:   run.WalDir,
+			MemtxDir: run.MemtxDir,
+			VinylDir: run.VinylDir,
+			Env:      run.Env,
+			EnvFile:  run.EnvFile,
+			Tags:     run.Tags,
+		},
+	}
+	for _, rlimit := range run.Rlimits {
+		record.Config.Rlimits = append(record.Config.Rlimits, rlimit.String())
+	}
+	return record
+}
+
+// corePatternToGlob converts the kernel core file name pattern to a shell
+// pattern matching the core file of the process with the passed PID.
+func corePatternToGlob(pattern string, usesPid bool, pid int) string {
+	var glob strings.Builder
+	hasPid := false
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+		if char != '%' || i == len(pattern)-1 {
+			if strings.IndexByte(`*?[\`, char) != -1 {
+				glob.WriteByte('\\')
+			}
+			glob.WriteByte(char)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case '%':
+			glob.WriteByte('%')
+		case 'p', 'P':
+			hasPid = true
+			glob.WriteString(strconv.Itoa(pid))
+		default:
+			glob.WriteByte('*')
+		}
+	}
+	if usesPid && !hasPid {
+		glob.WriteString("." + strconv.Itoa(pid))
+	}
+	return glob.String()
+}
+
+// findCoreFile searches for the core file matching the pattern that is
+// modified after the passed time. A relative pattern is searched in the dirs.
+func findCoreFile(glob string, dirs []string, since time.Time) string {
+	if filepath.IsAbs(glob) {
+		dirs = []string{""}
+	}
+	found := ""
+	var foundTime time.Time
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, glob))
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || !info.Mode().IsRegular() || info.ModTime().Before(since) {
+				continue
+			}
+			if found == "" || info.ModTime().After(foundTime) {
+				found, foundTime = match, info.ModTime()
+			}
+		}
+	}
+	return found
+}
+
+// moveFile moves the file to the destination falling back to copying if the
+// destination is on another file system. Files larger than maxCopySize are not
+// copied.
+func moveFile(src string, dst string, maxCopySize int64) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxCopySize {
+		return fmt.Errorf("the file is on another file system and its size %d exceeds "+
+			"the limit %d", info.Size(), maxCopySize)
+	}
+	if err := util.CopyFilePreserve(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// collectCoreFile finds the core file of the crashed process and moves it
+// to the crash directory. If the core is piped to a handler, the handler
+// is recorded instead.
+func collectCoreFile(record *CrashRecord, crashDir string, searchDirs []string) error {
+	rawPattern, err := os.ReadFile(corePatternFile)
+	if err != nil {
+		return err
+	}
+	pattern := strings.TrimSpace(string(rawPattern))
+	if strings.HasPrefix(pattern, "|") {
+		record.CoreHandler = strings.TrimSpace(strings.TrimPrefix(pattern, "|"))
+		return nil
+	}
+	usesPid := false
+	if rawUsesPid, err := os.ReadFile(coreUsesPidFile); err == nil {
+		usesPid = strings.TrimSpace(string(rawUsesPid)) == "1"
+	}
+
+	glob := corePatternToGlob(pattern, usesPid, record.PID)
+	// The time is truncated because of the file system timestamps precision.
+	corePath := findCoreFile(glob, searchDirs, record.StartTime.Truncate(time.Second))
+	if corePath == "" {
+		return nil
+	}
+	record.CoreFile = filepath.Join(crashDir, filepath.Base(corePath))
+	if err = moveFile(corePath, record.CoreFile, maxCoreCopySize); err != nil {
+		record.CoreFile = corePath
+		return fmt.Errorf("can't move the core file %s: %s", corePath, err)
+	}
+	return nil
+}
+
+// saveCrash saves the crash record, the last log lines and the core file to
+// a new directory in the instance crash directory. The core file is searched
+// in the searchDirs if the core pattern is relative. The path to the crash
+// directory is returned. A core file error does not prevent the crash record
+// from being saved, it is returned along with the crash directory.
+func saveCrash(run *InstanceCtx, record *CrashRecord, searchDirs []string) (string, error) {
+	crashDir := filepath.Join(run.CrashDir, fmt.Sprintf("%s-%d",
+		record.CrashTime.Format(crashDirTimeLayout), record.PID))
+	if err := os.MkdirAll(crashDir, defaultDirPerms); err != nil {
+		return "", err
+	}
+
+	if run.LogSink == "" || run.LogSink == "file" {
+		if lines, err := util.GetLastNLines(run.Log, crashLogLines); err == nil {
+			content := strings.Join(lines, "\n") + "\n"
+			if err = os.WriteFile(filepath.Join(crashDir, crashLogFile), []byte(content),
+				0640); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	var coreErr error
+	if record.CoreDumped {
+		coreErr = collectCoreFile(record, crashDir, searchDirs)
+	}
+	// The record is readable by the owner only: the environment of the
+	// instance may contain secrets.
+	content, err := yaml.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(filepath.Join(crashDir, crashRecordFile), content,
+		0600); err != nil {
+		return "", err
+	}
+	removeOldCrashes(run.CrashDir, crashDirsToKeep)
+	return crashDir, coreErr
+}
+
+// listCrashDirs returns the names of the crash directories from the newest to
+// the oldest one.
+func listCrashDirs(crashDir string) ([]string, error) {
+	entries, err := os.ReadDir(crashDir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	// The directory names start with the crash time.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// removeOldCrashes removes the crash directories except the last keep ones.
+// Errors are ignored: the removal is retried on the next crash.
+func removeOldCrashes(crashDir string, keep int) {
+	names, err := listCrashDirs(crashDir)
+	if err != nil || len(names) <= keep {
+		return
+	}
+	for _, name := range names[keep:] {
+		os.RemoveAll(filepath.Join(crashDir, name))
+	}
+}
+
+// LastCrash returns the record of the last crash of the instance. Nil is
+// returned if the instance has not crashed.
+func LastCrash(run *InstanceCtx) (*CrashRecord, error) {
+	names, err := listCrashDirs(run.CrashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(run.CrashDir, name, crashRecordFile))
+		if err != nil {
+			continue
+		}
+		record := CrashRecord{}
+		if err = yaml.Unmarshal(content, &record); err != nil {
+			return nil, fmt.Errorf("can't parse the crash record %s: %s",
+				filepath.Join(run.CrashDir, name, crashRecordFile), err)
+		}
+		return &record, nil
+	}
+	return nil, nil
+}
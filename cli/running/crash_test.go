@@ -0,0 +1,169 @@
+package running
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorePatternToGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		usesPid  bool
+		expected string
+	}{
+		{"core", false, "core"},
+		{"core", true, "core.42"},
+		{"core.%p", true, "core.42"},
+		{"/var/crash/core.%e.%p.%t", false, "/var/crash/core.*.42.*"},
+		{"core-%P-%%", false, "core-42-%"},
+		{"core[1]*", false, `core\[1]\*`},
+		{"core%", false, "core%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.expected, corePatternToGlob(tt.pattern, tt.usesPid, 42))
+		})
+	}
+}
+
+func TestFindCoreFile(t *testing.T) {
+	dir := t.TempDir()
+	otherDir := t.TempDir()
+	since := time.Now().Add(-time.Minute)
+
+	oldCore := filepath.Join(dir, "core.1.42")
+	require.NoError(t, os.WriteFile(oldCore, []byte("old"), 0644))
+	require.NoError(t, os.Chtimes(oldCore, since.Add(-time.Hour), since.Add(-time.Hour)))
+	assert.Equal(t, "", findCoreFile("core.*.42", []string{dir}, since))
+
+	newCore := filepath.Join(otherDir, "core.2.42")
+	require.NoError(t, os.WriteFile(newCore, []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "core.2.43"), []byte("x"), 0644))
+	assert.Equal(t, newCore, findCoreFile("core.*.42", []string{dir, otherDir}, since))
+	assert.Equal(t, newCore, findCoreFile(filepath.Join(otherDir, "core.*.42"), nil, since))
+}
+
+// crashTestProcess runs a process killed by the signal and returns its status.
+func crashTestProcess(t *testing.T, signal string) (int, syscall.WaitStatus) {
+	cmd := exec.Command("sh", "-c", "kill -"+signal+" $$")
+	// A possible core file is dumped to the temporary directory.
+	cmd.Dir = t.TempDir()
+	require.Error(t, cmd.Run())
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	require.True(t, ok)
+	require.True(t, status.Signaled())
+	return cmd.ProcessState.Pid(), status
+}
+
+func TestSaveCrash(t *testing.T) {
+	dir := t.TempDir()
+	run := InstanceCtx{
+		AppName:  "app",
+		InstName: "storage",
+		Log:      filepath.Join(dir, "storage.log"),
+		CrashDir: filepath.Join(dir, "crash"),
+		WalDir:   filepath.Join(dir, "lib"),
+		Tags:     map[string]string{"role": "storage"},
+		Env:      map[string]string{"TT_LISTEN": "3301"},
+	}
+	require.NoError(t, os.WriteFile(run.Log, []byte("line 1\nline 2\n"), 0644))
+
+	crash, err := LastCrash(&run)
+	require.NoError(t, err)
+	assert.Nil(t, crash)
+
+	startTime := time.Now().Add(-time.Second)
+	pid, status := crashTestProcess(t, "SEGV")
+	record := newCrashRecord(&run, pid, status, startTime)
+	// The core file search depends on the system settings.
+	record.CoreDumped = false
+	crashDir, err := saveCrash(&run, &record, []string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, run.CrashDir, filepath.Dir(crashDir))
+
+	// The record may contain secrets in the environment.
+	info, err := os.Stat(filepath.Join(crashDir, crashRecordFile))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	logTail, err := os.ReadFile(filepath.Join(crashDir, crashLogFile))
+	require.NoError(t, err)
+	assert.Equal(t, "line 1\nline 2\n", string(logTail))
+
+	crash, err = LastCrash(&run)
+	require.NoError(t, err)
+	require.NotNil(t, crash)
+	assert.Equal(t, "app:storage", crash.Instance)
+	assert.Equal(t, pid, crash.PID)
+	assert.Equal(t, "SIGSEGV", crash.Signal)
+	assert.Equal(t, int(syscall.SIGSEGV), crash.SignalNumber)
+	assert.Equal(t, 128+int(syscall.SIGSEGV), crash.ExitCode)
+	assert.True(t, crash.CrashTime.Equal(record.CrashTime))
+	assert.True(t, crash.StartTime.Equal(startTime))
+	assert.Equal(t, run.Tags, crash.Config.Tags)
+	assert.Equal(t, run.Env, crash.Config.Env)
+
+	// The last crash is returned.
+	pid, status = crashTestProcess(t, "ABRT")
+	record = newCrashRecord(&run, pid, status, startTime)
+	record.CoreDumped = false
+	record.CrashTime = record.CrashTime.Add(time.Second)
+	_, err = saveCrash(&run, &record, []string{dir})
+	require.NoError(t, err)
+
+	crash, err = LastCrash(&run)
+	require.NoError(t, err)
+	require.NotNil(t, crash)
+	assert.Equal(t, "SIGABRT", crash.Signal)
+}
+
+func TestSaveCrashRetention(t *testing.T) {
+	dir := t.TempDir()
+	run := InstanceCtx{AppName: "app", CrashDir: filepath.Join(dir, "crash")}
+	crashTime := time.Now()
+	pid, status := crashTestProcess(t, "SEGV")
+	for i := 0; i < crashDirsToKeep+2; i++ {
+		record := newCrashRecord(&run, pid+i, status, crashTime)
+		record.CoreDumped = false
+		record.CrashTime = crashTime.Add(time.Duration(i) * time.Second)
+		_, err := saveCrash(&run, &record, []string{dir})
+		require.NoError(t, err)
+	}
+
+	names, err := listCrashDirs(run.CrashDir)
+	require.NoError(t, err)
+	require.Len(t, names, crashDirsToKeep)
+	// The oldest crashes are removed.
+	crash, err := LastCrash(&run)
+	require.NoError(t, err)
+	require.NotNil(t, crash)
+	assert.Equal(t, pid+crashDirsToKeep+1, crash.PID)
+	for _, name := range names {
+		assert.Greater(t, name, crashTime.Add(time.Second).Format(crashDirTimeLayout))
+	}
+}
+
+func TestMoveFileCopyLimit(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "core")
+	require.NoError(t, os.WriteFile(src, []byte("core content"), 0600))
+
+	// The rename fails: the destination directory does not exist. Such a
+	// file would be copied if it was on another file system.
+	dst := filepath.Join(dir, "missing", "core")
+	err := moveFile(src, dst, 4)
+	assert.ErrorContains(t, err, "exceeds the limit 4")
+	assert.FileExists(t, src)
+
+	dst = filepath.Join(dir, "moved")
+	require.NoError(t, moveFile(src, dst, 4))
+	assert.NoFileExists(t, src)
+	assert.FileExists(t, dst)
+}
@@ -0,0 +1,246 @@
+package running
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// envKey is a key of the environment variables map in the instance config.
+	envKey = "env"
+	// envFileKey is a key of the environment file path in the instance config.
+	envFileKey = "env_file"
+	// rlimitsKey is a key of the resource limits map in the instance config.
+	rlimitsKey = "rlimits"
+	// rlimitUnlimited is a value of the resource limit without a limit.
+	rlimitUnlimited = "unlimited"
+)
+
+// rlimitResources maps supported resource names to the resource codes.
+var rlimitResources = map[string]int{
+	"nofile":  unix.RLIMIT_NOFILE,
+	"core":    unix.RLIMIT_CORE,
+	"memlock": unix.RLIMIT_MEMLOCK,
+	"as":      unix.RLIMIT_AS,
+}
+
+// Rlimit describes a resource limit of the instance process.
+type Rlimit struct {
+	// Resource is a resource name: nofile, core, memlock or as.
+	Resource string
+	// Value is a soft limit value. unix.RLIM_INFINITY means no limit.
+	Value uint64
+}
+
+// String returns the resource limit as "resource: value".
+func (rlimit Rlimit) String() string {
+	if rlimit.Value == unix.RLIM_INFINITY {
+		return fmt.Sprintf("%s: %s", rlimit.Resource, rlimitUnlimited)
+	}
+	return fmt.Sprintf("%s: %d", rlimit.Resource, rlimit.Value)
+}
+
+// toStringMap converts the parsed YAML map to a map with string keys.
+func toStringMap(raw interface{}) (map[string]interface{}, error) {
+	switch value := raw.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		return value, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = item
+		}
+		return converted, nil
+	}
+	return nil, fmt.Errorf("a map is expected, got %v", raw)
+}
+
+// parseEnv parses the environment variables map.
+func parseEnv(raw interface{}) (map[string]string, error) {
+	envMap, err := toStringMap(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", envKey, err)
+	}
+	env := make(map[string]string, len(envMap))
+	for name, value := range envMap {
+		if name == "" || strings.ContainsRune(name, '=') {
+			return nil, fmt.Errorf("invalid %s: bad variable name %q", envKey, name)
+		}
+		if value == nil {
+			env[name] = ""
+			continue
+		}
+		env[name] = fmt.Sprint(value)
+	}
+	return env, nil
+}
+
+// parseRlimits parses the resource limits map. The limits are sorted by the
+// resource name.
+func parseRlimits(raw interface{}) ([]Rlimit, error) {
+	rlimitsMap, err := toStringMap(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", rlimitsKey, err)
+	}
+	rlimits := make([]Rlimit, 0, len(rlimitsMap))
+	for resource, value := range rlimitsMap {
+		if _, found := rlimitResources[resource]; !found {
+			return nil, fmt.Errorf("invalid %s: unknown resource %q", rlimitsKey, resource)
+		}
+		rlimit := Rlimit{Resource: resource}
+		switch value := value.(type) {
+		case int:
+			if value < 0 {
+				return nil, fmt.Errorf("invalid %s: %s must be non-negative", rlimitsKey,
+					resource)
+			}
+			rlimit.Value = uint64(value)
+		case uint64:
+			rlimit.Value = value
+		case string:
+			if value == rlimitUnlimited {
+				rlimit.Value = unix.RLIM_INFINITY
+			} else if rlimit.Value, err = strconv.ParseUint(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid %s: %s must be a number or %q", rlimitsKey,
+					resource, rlimitUnlimited)
+			}
+		default:
+			return nil, fmt.Errorf("invalid %s: %s must be a number or %q", rlimitsKey,
+				resource, rlimitUnlimited)
+		}
+		rlimits = append(rlimits, rlimit)
+	}
+	sort.Slice(rlimits, func(i, j int) bool {
+		return rlimits[i].Resource < rlimits[j].Resource
+	})
+	return rlimits, nil
+}
+
+// mergeRlimits returns the base limits overridden by the limits of the same
+// resources from overrides.
+func mergeRlimits(base []Rlimit, overrides []Rlimit) []Rlimit {
+	merged := make(map[string]uint64, len(base)+len(overrides))
+	for _, rlimit := range base {
+		merged[rlimit.Resource] = rlimit.Value
+	}
+	for _, rlimit := range overrides {
+		merged[rlimit.Resource] = rlimit.Value
+	}
+	rlimits := make([]Rlimit, 0, len(merged))
+	for resource, value := range merged {
+		rlimits = append(rlimits, Rlimit{Resource: resource, Value: value})
+	}
+	sort.Slice(rlimits, func(i, j int) bool {
+		return rlimits[i].Resource < rlimits[j].Resource
+	})
+	return rlimits
+}
+
+// parseEnvParams parses the environment and resource limits parameters of the
+// instance config. Relative env_file path is built from baseDir.
+func parseEnvParams(params map[string]interface{}, baseDir string) (map[string]string,
+	string, []Rlimit, error) {
+	env, err := parseEnv(params[envKey])
+	if err != nil {
+		return nil, "", nil, err
+	}
+	rlimits, err := parseRlimits(params[rlimitsKey])
+	if err != nil {
+		return nil, "", nil, err
+	}
+	envFile := ""
+	if rawEnvFile, found := params[envFileKey]; found && rawEnvFile != nil {
+		var ok bool
+		if envFile, ok = rawEnvFile.(string); !ok {
+			return nil, "", nil, fmt.Errorf("invalid %s: a string is expected", envFileKey)
+		}
+		if envFile != "" && !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(baseDir, envFile)
+		}
+	}
+	return env, envFile, rlimits, nil
+}
+
+// unquoteEnvValue removes quotes around the value of the environment file variable.
+func unquoteEnvValue(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+	switch value[0] {
+	case '"':
+		return strconv.Unquote(value)
+	case '\'':
+		if value[len(value)-1] != '\'' {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+// ReadEnvFile reads environment variables from the dotenv file. Each line
+// has the form NAME=VALUE with optional "export " prefix. The value can be
+// quoted. Empty lines and lines starting with # are skipped.
+func ReadEnvFile(envFile string) ([]string, error) {
+	file, err := os.Open(envFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't open environment file: %s", err)
+	}
+	defer file.Close()
+
+	env := []string{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, found := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("%s:%d: NAME=VALUE is expected", envFile, lineNumber)
+		}
+		if value, err = unquoteEnvValue(strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", envFile, lineNumber, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't read environment file: %s", err)
+	}
+	return env, nil
+}
+
+// buildEnv returns the instance environment variables: the variables from the
+// environment file followed by the variables from the env map sorted by name.
+func buildEnv(env map[string]string, envFile string) ([]string, error) {
+	result := []string{}
+	if envFile != "" {
+		fileEnv, err := ReadEnvFile(envFile)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fileEnv...)
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, name+"="+env[name])
+	}
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package running
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sys/unix"
+)
+
+func TestGetInstancesFromYMLEnv(t *testing.T) {
+	appDir := filepath.Join("testdata", "app_env")
+	instances, err := getInstancesFromYML(appDir, "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(instances))
+
+	masterIdx := slices.IndexFunc(instances, func(instanceCtx InstanceCtx) bool {
+		return instanceCtx.InstName == "master"
+	})
+	require.NotEqual(t, -1, masterIdx)
+	master := instances[masterIdx]
+	assert.Equal(t, map[string]string{"TT_TEST_PORT": "3301", "TT_TEST_ROLE": "master"},
+		master.Env)
+	assert.Equal(t, filepath.Join(appDir, "master.env"), master.EnvFile)
+	assert.Equal(t, []Rlimit{{"core", unix.RLIM_INFINITY}, {"nofile", 65536}}, master.Rlimits)
+
+	env, err := buildEnv(master.Env, master.EnvFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"TT_TEST_USER=admin",
+		"TT_TEST_PASSWORD=secret # not a comment",
+		"TT_TEST_DIR=/var/lib/app",
+		"TT_TEST_EMPTY=",
+		"TT_TEST_PORT=3301",
+		"TT_TEST_ROLE=master",
+	}, env)
+
+	replicaIdx := slices.IndexFunc(instances, func(instanceCtx InstanceCtx) bool {
+		return instanceCtx.InstName == "replica"
+	})
+	require.NotEqual(t, -1, replicaIdx)
+	assert.Empty(t, instances[replicaIdx].Env)
+	assert.Empty(t, instances[replicaIdx].EnvFile)
+	assert.Empty(t, instances[replicaIdx].Rlimits)
+}
+
+func TestParseRlimits(t *testing.T) {
+	rlimits, err := parseRlimits(map[interface{}]interface{}{"memlock": "1024", "as": 0})
+	require.NoError(t, err)
+	assert.Equal(t, []Rlimit{{"as", 0}, {"memlock", 1024}}, rlimits)
+	assert.Equal(t, []Rlimit{{"as", 0}, {"memlock", 1}, {"nofile", 10}},
+		mergeRlimits(rlimits, []Rlimit{{"memlock", 1}, {"nofile", 10}}))
+
+	for _, invalid := range []interface{}{
+		map[string]interface{}{"cpu": 1},
+		map[string]interface{}{"nofile": -1},
+		map[string]interface{}{"nofile": "many"},
+		map[string]interface{}{"nofile": 1.5},
+		"nofile",
+	} {
+		_, err = parseRlimits(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestReadEnvFileErrors(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(envFile, []byte("A=1\nnot a variable\n"), 0644))
+	_, err := ReadEnvFile(envFile)
+	assert.ErrorContains(t, err, "app.env:2: NAME=VALUE is expected")
+
+	require.NoError(t, os.WriteFile(envFile, []byte("A='unterminated\n"), 0644))
+	_, err = ReadEnvFile(envFile)
+	assert.ErrorContains(t, err, "app.env:1:")
+
+	_, err = ReadEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+	assert.Error(t, err)
+}
@@ -45,6 +45,8 @@ type Instance struct {
 	vinylDir string `mapstructure:"vinyl_dir" yaml:"vinyl_dir"`
 	// env describes the environment settled by a client.
 	env []string
+	// rlimits contains resource limits of the instance process.
+	rlimits []Rlimit
 	// consoleSocket is a Unix domain socket to be used as "admin port".
 	consoleSocket string
 	// waitMutex is used to prevent several invokes of the "Wait"
@@ -53,6 +55,8 @@ type Instance struct {
 	waitMutex sync.Mutex
 	// done represent whether the instance was stopped.
 	done bool
+	// startTime is the time the instance process was started.
+	startTime time.Time
 }
 
 //go:embed lua/launcher.lua
@@ -78,6 +82,7 @@ func NewInstance(tarantoolPath string, instanceCtx *InstanceCtx, env []string,
 		instName:      instanceCtx.InstName,
 		consoleSocket: instanceCtx.ConsoleSocket,
 		env:           env,
+		rlimits:       instanceCtx.Rlimits,
 		logger:        logger,
 		walDir:        instanceCtx.WalDir,
 		vinylDir:      instanceCtx.VinylDir,
@@ -115,6 +120,19 @@ func (inst *Instance) Wait() error {
 	return err
 }
 
+// killedBy returns the signal that terminated the Instance process. False is
+// returned if the process has not finished yet or exited normally.
+func (inst *Instance) killedBy() (syscall.WaitStatus, bool) {
+	if inst.Cmd == nil || inst.Cmd.ProcessState == nil {
+		return 0, false
+	}
+	status, ok := inst.Cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return status, true
+}
+
 // Start starts the Instance with the specified parameters.
 func (inst *Instance) Start() error {
 	inst.Cmd = exec.Command(inst.tarantoolPath, "-")
@@ -124,7 +142,7 @@ func (inst *Instance) Start() error {
 	if err != nil {
 		return err
 	}
-	inst.Cmd.Env = append(os.Environ(), "TT_CLI_INSTANCE="+inst.appPath)
+	inst.Cmd.Env = append(inst.env, "TT_CLI_INSTANCE="+inst.appPath)
 
 	// It became common that console socket path is longer than 108/106 (on linux/macOs).
 	// To reduce length of path we use relative path
@@ -177,6 +195,16 @@ func (inst *Instance) Start() error {
 	if err := inst.Cmd.Start(); err != nil {
 		return err
 	}
+	// The limits are set before the launcher is written, so the instance code
+	// runs with them. The watchdog keeps its own limits.
+	if err := applyRlimits(inst.Cmd.Process.Pid, inst.rlimits); err != nil {
+		StdinPipe.Close()
+		inst.Cmd.Process.Kill()
+		inst.Cmd.Wait()
+		return err
+	}
+	inst.startTime = time.Now()
+	inst.logger.SetPID(inst.Cmd.Process.Pid)
 	StdinPipe.Write([]byte(instanceLauncher))
 	StdinPipe.Close()
 	inst.done = false
@@ -239,6 +267,38 @@ func (inst *Instance) Run(flags RunFlags) error {
 	return nil
 }
 
+// startChild starts the script in a child process with the same stdin, stdout
+// and stderr. Unlike Run, the current process is not replaced, so the script
+// can be restarted.
+func (inst *Instance) startChild(flags RunFlags) error {
+	args := convertFlagsToTarantoolOpts(flags)
+	args = append(args, "-")
+	args = append(args, flags.RunArgs...)
+	inst.Cmd = exec.Command(inst.tarantoolPath, args...)
+	inst.Cmd.Stdout = os.Stdout
+	inst.Cmd.Stderr = os.Stderr
+	// The launcher is written to the stdin pipe. The original stdin is passed
+	// as the file descriptor 3 and is restored by the launcher.
+	inst.Cmd.ExtraFiles = []*os.File{os.Stdin}
+	inst.Cmd.Env = append(inst.env,
+		"TT_CLI_INSTANCE="+inst.appPath,
+		"TT_CLI=true",
+		"TT_CLI_RUN_STDIN_FD=3",
+	)
+	stdinPipe, err := inst.Cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	log.Debugf("Running Tarantool with args: %s", strings.Join(args, " "))
+	if err := inst.Cmd.Start(); err != nil {
+		return err
+	}
+	stdinPipe.Write([]byte(instanceLauncher))
+	stdinPipe.Close()
+	inst.done = false
+	return nil
+}
+
 // Stop terminates the Instance.
 //
 // timeout - the time that was provided to the process
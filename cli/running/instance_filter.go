@@ -1,6 +1,8 @@
 package running
 
 import (
+	"sort"
+
 	"github.com/tarantool/tt/cli/process_utils"
 )
 
@@ -84,3 +86,19 @@ func ExtractAppNames(instances []InstanceCtx) []string {
 		return true
 	})
 }
+
+// ExtractTags returns the sorted list of the instances tags in the key=value form.
+func ExtractTags(instances []InstanceCtx) []string {
+	seen := map[string]bool{}
+	tags := make([]string, 0)
+	for _, instance := range instances {
+		for _, tag := range TagsList(instance.Tags) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,123 @@
+package running
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/apex/log"
+	"github.com/mattn/go-isatty"
+)
+
+// StopProgress displays the stop phases of the instances. If the output is a
+// terminal, the phases are redrawn in place. Otherwise, the stop results are
+// logged.
+type StopProgress struct {
+	// mutex protects the phases and the output.
+	mutex sync.Mutex
+	// out is the progress output.
+	out io.Writer
+	// live enables redrawing of the phases in place.
+	live bool
+	// names contains the instances names.
+	names []string
+	// phases contains the current phases of the instances.
+	phases []StopPhase
+	// drawn is the number of lines drawn by the last redraw.
+	drawn int
+}
+
+// NewStopProgress creates a progress display of the instances stopping.
+func NewStopProgress(out *os.File, instances []InstanceCtx) *StopProgress {
+	progress := StopProgress{
+		out:    out,
+		live:   isatty.IsTerminal(out.Fd()),
+		names:  make([]string, len(instances)),
+		phases: make([]StopPhase, len(instances)),
+	}
+	for i, instance := range instances {
+		progress.names[i] = GetAppInstanceName(instance)
+	}
+	return &progress
+}
+
+// redraw prints the phases of all the instances over the previous ones.
+func (progress *StopProgress) redraw() {
+	if progress.drawn > 0 {
+		fmt.Fprintf(progress.out, "\033[%dA", progress.drawn)
+	}
+	for i, name := range progress.names {
+		phase := progress.phases[i]
+		if phase == "" {
+			phase = "pending"
+		}
+		fmt.Fprintf(progress.out, "\033[2K%s: %s\n", name, phase)
+	}
+	progress.drawn = len(progress.names)
+}
+
+// SetPhase updates the phase of the i-th instance.
+func (progress *StopProgress) SetPhase(i int, phase StopPhase) {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	progress.phases[i] = phase
+	if progress.live {
+		progress.redraw()
+	} else if phase == StopPhaseKilling {
+		log.Warnf("The Instance %s has not stopped in time, the watchdog kills it.",
+			progress.names[i])
+	}
+}
+
+// Finish reports the stop result of the i-th instance.
+func (progress *StopProgress) Finish(i int, result StopResult) {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	if progress.live {
+		return
+	}
+	switch {
+	case result.Err != nil:
+		log.Errorf("The Instance %s can't be stopped: %s.", progress.names[i], result.Err)
+	case result.Phase == StopPhaseNotRunning:
+		log.Infof("The Instance %s is not running.", progress.names[i])
+	default:
+		log.Infof("The Instance %s (PID = %v) has been terminated.", progress.names[i],
+			result.PID)
+	}
+}
+
+// PrintSummary prints the result of stopping of every instance and the number
+// of failures.
+func (progress *StopProgress) PrintSummary(results []StopResult) int {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	failures := 0
+	fmt.Fprintln(progress.out)
+	writer := tabwriter.NewWriter(progress.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "INSTANCE\tRESULT\tSIGNAL\tPID")
+	for i, result := range results {
+		signal, pid := "-", "-"
+		if result.Signal != 0 {
+			signal = signalName(result.Signal)
+		}
+		if result.PID != 0 {
+			pid = fmt.Sprint(result.PID)
+		}
+		status := string(result.Phase)
+		if result.Err != nil {
+			failures++
+			status = fmt.Sprintf("%s: %s", result.Phase, result.Err)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", progress.names[i], status, signal, pid)
+	}
+	writer.Flush()
+	if failures > 0 {
+		fmt.Fprintf(progress.out, "%d of %d instance(s) failed to stop.\n", failures,
+			len(results))
+	}
+	return failures
+}
@@ -0,0 +1,263 @@
+package running
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/tarantool/tt/cli/connector"
+	"github.com/tarantool/tt/cli/process_utils"
+)
+
+// boxCfgEnvPrefix is a prefix of the environment variables used by the
+// launcher to pass box.cfg options to the instance.
+const boxCfgEnvPrefix = "TT_"
+
+// dynamicBoxCfgOptions contains box.cfg options that can be changed on the
+// running instance.
+var dynamicBoxCfgOptions = map[string]bool{
+	"listen":                      true,
+	"replication":                 true,
+	"log_level":                   true,
+	"log_format":                  true,
+	"io_collect_interval":         true,
+	"readahead":                   true,
+	"too_long_threshold":          true,
+	"snap_io_rate_limit":          true,
+	"checkpoint_interval":         true,
+	"checkpoint_count":            true,
+	"checkpoint_wal_threshold":    true,
+	"read_only":                   true,
+	"memtx_memory":                true,
+	"vinyl_memory":                true,
+	"vinyl_cache":                 true,
+	"vinyl_timeout":               true,
+	"replication_timeout":         true,
+	"replication_sync_lag":        true,
+	"replication_sync_timeout":    true,
+	"replication_synchro_quorum":  true,
+	"replication_synchro_timeout": true,
+	"replication_connect_timeout": true,
+	"replication_connect_quorum":  true,
+	"replication_skip_conflict":   true,
+	"replication_anon":            true,
+	"election_mode":               true,
+	"election_timeout":            true,
+	"net_msg_max":                 true,
+	"sql_cache_size":              true,
+	"feedback_enabled":            true,
+	"feedback_crashinfo":          true,
+	"feedback_host":               true,
+	"feedback_interval":           true,
+	"wal_queue_max_size":          true,
+	"wal_cleanup_delay":           true,
+	"worker_pool_threads":         true,
+	"custom_proc_title":           true,
+	"force_recovery":              true,
+}
+
+// staticBoxCfgOptions contains box.cfg options supported by the launcher that
+// can be set only on the instance start.
+var staticBoxCfgOptions = map[string]bool{
+	"strip_core":                true,
+	"memtx_min_tuple_size":      true,
+	"memtx_max_tuple_size":      true,
+	"slab_alloc_granularity":    true,
+	"slab_alloc_factor":         true,
+	"iproto_threads":            true,
+	"work_dir":                  true,
+	"memtx_dir":                 true,
+	"wal_dir":                   true,
+	"vinyl_dir":                 true,
+	"vinyl_max_tuple_size":      true,
+	"vinyl_read_threads":        true,
+	"vinyl_write_threads":       true,
+	"vinyl_run_count_per_level": true,
+	"vinyl_run_size_ratio":      true,
+	"vinyl_range_size":          true,
+	"vinyl_page_size":           true,
+	"vinyl_bloom_fpr":           true,
+	"log":                       true,
+	"log_nonblock":              true,
+	"wal_mode":                  true,
+	"rows_per_wal":              true,
+	"wal_max_size":              true,
+	"wal_dir_rescan_delay":      true,
+	"instance_uuid":             true,
+	"replicaset_uuid":           true,
+	"pid_file":                  true,
+	"background":                true,
+	"username":                  true,
+	"coredump":                  true,
+	"hot_standby":               true,
+	"memtx_use_mvcc_engine":     true,
+}
+
+// applyBoxCfg is a Lua code that compares the passed box.cfg options with the
+// current ones and applies the changed dynamic options. The options are passed
+// as strings, the value is converted according to the type of the current one.
+// Note: the code is sent in one line, so Lua comments must not be used here.
+const applyBoxCfg = `local desired, dynamic = ...
+if type(box.cfg) == 'function' then
+	error('box.cfg is not configured yet')
+end
+local function convert(raw, current)
+	if type(current) == 'number' then
+		return tonumber(raw) or raw
+	elseif type(current) == 'boolean' then
+		if raw:lower() == 'true' then return true end
+		if raw:lower() == 'false' then return false end
+		return raw
+	elseif type(current) == 'table' or (current == nil and raw:find(',')) then
+		local res = {}
+		for i, v in ipairs(raw:split(',')) do
+			res[i] = tonumber(v) or v
+		end
+		return res
+	elseif current == nil then
+		return tonumber(raw) or raw
+	end
+	return raw
+end
+local function equal(a, b)
+	if type(a) == 'table' and type(b) == 'table' then
+		if #a ~= #b then return false end
+		for i = 1, #a do
+			if tostring(a[i]) ~= tostring(b[i]) then return false end
+		end
+		return true
+	end
+	return tostring(a) == tostring(b)
+end
+local applied, restart, failed = {}, {}, {}
+for option, raw in pairs(desired) do
+	local current = box.cfg[option]
+	local value = convert(raw, current)
+	if not equal(current, value) then
+		if dynamic[option] then
+			local ok, err = pcall(box.cfg, {[option] = value})
+			if ok then
+				table.insert(applied, option)
+			else
+				table.insert(failed, option .. ': ' .. tostring(err))
+			end
+		else
+			table.insert(restart, option)
+		end
+	end
+end
+return applied, restart, failed`
+
+// ReloadResult describes the result of the configuration reload.
+type ReloadResult struct {
+	// Applied contains box.cfg options applied to the running instance.
+	Applied []string
+	// RestartRequired contains changed box.cfg options that can be applied
+	// only on the instance restart.
+	RestartRequired []string
+	// Failed contains errors of applying box.cfg options.
+	Failed []string
+}
+
+// getBoxCfgFromEnv collects box.cfg options passed to the instance using the
+// TT_<OPTION> environment variables.
+func getBoxCfgFromEnv(env []string) map[string]string {
+	boxCfg := map[string]string{}
+	for _, variable := range env {
+		name, value, found := strings.Cut(variable, "=")
+		if !found || !strings.HasPrefix(name, boxCfgEnvPrefix) || value == "" {
+			continue
+		}
+		option := strings.ToLower(strings.TrimPrefix(name, boxCfgEnvPrefix))
+		if dynamicBoxCfgOptions[option] || staticBoxCfgOptions[option] {
+			boxCfg[option] = value
+		}
+	}
+	return boxCfg
+}
+
+// toStringSlice converts the Lua array returned from the console to a sorted
+// slice of strings. An empty Lua table is decoded as a map.
+func toStringSlice(value interface{}) ([]string, error) {
+	result := []string{}
+	switch array := value.(type) {
+	case []interface{}:
+		for _, item := range array {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value: %v", item)
+			}
+			result = append(result, str)
+		}
+	case map[interface{}]interface{}:
+		if len(array) != 0 {
+			return nil, fmt.Errorf("unexpected value: %v", array)
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("unexpected value: %v", array)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// applyInstanceBoxCfg pushes the changed box.cfg options to the instance over
+// the console socket.
+func applyInstanceBoxCfg(consoleSocket string, boxCfg map[string]string) (ReloadResult,
+	error) {
+	result := ReloadResult{}
+	conn, err := connectConsole(consoleSocket)
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+
+	res, err := conn.Eval(applyBoxCfg, []interface{}{boxCfg, dynamicBoxCfgOptions},
+		connector.RequestOpts{ReadTimeout: readyRequestTimeout})
+	if err != nil {
+		return result, err
+	}
+	if len(res) != 3 {
+		return result, fmt.Errorf("unexpected response: %v", res)
+	}
+	for i, field := range []*[]string{&result.Applied, &result.RestartRequired,
+		&result.Failed} {
+		if *field, err = toStringSlice(res[i]); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Reload makes the watchdog re-read the configuration and apply the changed
+// logger settings. The changed box.cfg options passed with the TT_<OPTION>
+// variables of the instance environment are applied to the running instance
+// if possible.
+func Reload(run *InstanceCtx) (ReloadResult, error) {
+	if _, err := process_utils.GetPIDFromFile(run.PIDFile); err != nil {
+		return ReloadResult{}, fmt.Errorf(instStateStopped.String())
+	}
+	pid, err := process_utils.GetVerifiedPID(run.PIDFile)
+	if err != nil {
+		return ReloadResult{}, fmt.Errorf(instStateDead.String())
+	}
+	if err = syscall.Kill(pid, syscall.SIGUSR2); err != nil {
+		return ReloadResult{}, fmt.Errorf("can't send a reload signal to the watchdog: %s",
+			err)
+	}
+
+	env, err := buildEnv(run.Env, run.EnvFile)
+	if err != nil {
+		return ReloadResult{}, err
+	}
+	boxCfg := getBoxCfgFromEnv(env)
+	if len(boxCfg) == 0 {
+		return ReloadResult{}, nil
+	}
+	result, err := applyInstanceBoxCfg(run.ConsoleSocket, boxCfg)
+	if err != nil {
+		return result, fmt.Errorf("can't apply box.cfg options: %s", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package running
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBoxCfgFromEnv(t *testing.T) {
+	boxCfg := getBoxCfgFromEnv([]string{
+		"TT_LISTEN=3301",
+		"TT_MEMTX_MEMORY=1024",
+		"TT_WAL_DIR=wal",
+		"TT_LISTEN=3302",
+		"TT_UNKNOWN_OPTION=1",
+		"TT_READ_ONLY=",
+		"LISTEN=3303",
+		"TT_CLI_INSTANCE=init.lua",
+	})
+	assert.Equal(t, map[string]string{
+		"listen":       "3302",
+		"memtx_memory": "1024",
+		"wal_dir":      "wal",
+	}, boxCfg)
+}
+
+func TestToStringSlice(t *testing.T) {
+	res, err := toStringSlice([]interface{}{"listen", "election_mode"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"election_mode", "listen"}, res)
+
+	res, err = toStringSlice(map[interface{}]interface{}{})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = toStringSlice([]interface{}{1})
+	assert.Error(t, err)
+	_, err = toStringSlice(map[interface{}]interface{}{1: "listen"})
+	assert.Error(t, err)
+}
+
+func TestBoxCfgOptionsDisjoint(t *testing.T) {
+	for option := range dynamicBoxCfgOptions {
+		assert.False(t, staticBoxCfgOptions[option], option)
+	}
+}
@@ -0,0 +1,29 @@
+package running
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyRlimits sets the resource limits of the started process with the pid.
+// The limits of the current process are not changed. The hard limit is raised
+// only if it is less than the requested value.
+func applyRlimits(pid int, rlimits []Rlimit) error {
+	for _, rlimit := range rlimits {
+		resource := rlimitResources[rlimit.Resource]
+		var current unix.Rlimit
+		if err := unix.Prlimit(pid, resource, nil, &current); err != nil {
+			return fmt.Errorf("can't get %s limit: %s", rlimit.Resource, err)
+		}
+		limit := unix.Rlimit{Cur: rlimit.Value, Max: current.Max}
+		if current.Max != unix.RLIM_INFINITY &&
+			(rlimit.Value == unix.RLIM_INFINITY || rlimit.Value > current.Max) {
+			limit.Max = rlimit.Value
+		}
+		if err := unix.Prlimit(pid, resource, &limit, nil); err != nil {
+			return fmt.Errorf("can't set limit %s: %s", rlimit, err)
+		}
+	}
+	return nil
+}
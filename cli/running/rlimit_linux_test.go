@@ -0,0 +1,62 @@
+package running
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/ttlog"
+	"golang.org/x/sys/unix"
+)
+
+// readProcLimit returns the soft limit of the resource from /proc/<pid>/limits.
+func readProcLimit(t *testing.T, pid int, name string) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, name) {
+			return strings.Fields(strings.TrimPrefix(line, name))[0]
+		}
+	}
+	require.Failf(t, "limit is not found", "%q", name)
+	return ""
+}
+
+func TestInstanceRlimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	tarantoolBin := filepath.Join(tmpDir, "tarantool")
+	require.NoError(t, os.WriteFile(tarantoolBin, []byte("#!/bin/sh\nexec sleep 60\n"), 0755))
+	appPath := filepath.Join(tmpDir, "app.lua")
+	require.NoError(t, os.WriteFile(appPath, []byte{}, 0644))
+
+	var before unix.Rlimit
+	require.NoError(t, unix.Getrlimit(unix.RLIMIT_NOFILE, &before))
+	require.Greater(t, before.Cur, uint64(256))
+
+	logger := ttlog.NewCustomLogger(&bytes.Buffer{}, "", 0)
+	inst, err := NewInstance(tarantoolBin, &InstanceCtx{
+		AppPath: appPath,
+		WalDir:  tmpDir,
+		Rlimits: []Rlimit{{"as", 1 << 40}, {"nofile", 256}},
+	}, os.Environ(), logger)
+	require.NoError(t, err)
+	require.NoError(t, inst.Start())
+	t.Cleanup(func() {
+		inst.Cmd.Process.Kill()
+		inst.Wait()
+	})
+
+	pid := inst.Cmd.Process.Pid
+	assert.Equal(t, "256", readProcLimit(t, pid, "Max open files"))
+	assert.Equal(t, fmt.Sprint(uint64(1<<40)), readProcLimit(t, pid, "Max address space"))
+
+	// The limits of the watchdog process are not changed.
+	var after unix.Rlimit
+	require.NoError(t, unix.Getrlimit(unix.RLIMIT_NOFILE, &after))
+	assert.Equal(t, before, after)
+}
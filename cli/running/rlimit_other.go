@@ -0,0 +1,14 @@
+//go:build !linux
+
+package running
+
+import "fmt"
+
+// applyRlimits sets the resource limits of the started process with the pid.
+// It is supported on Linux only.
+func applyRlimits(pid int, rlimits []Rlimit) error {
+	if len(rlimits) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resource limits are supported on Linux only")
+}
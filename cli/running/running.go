@@ -3,11 +3,13 @@ package running
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -54,6 +56,8 @@ type InstanceCtx struct {
 	MemtxDir string `mapstructure:"memtx_dir" yaml:"memtx_dir"`
 	// VinylDir is a directory where vinyl files or subdirectories will be stored.
 	VinylDir string `mapstructure:"vinyl_dir" yaml:"vinyl_dir"`
+	// CrashDir is a directory where the instance crash artifacts are stored.
+	CrashDir string
 	// LogMaxSize is the maximum size in megabytes of the log file
 	// before it gets rotated. It defaults to 100 megabytes.
 	LogMaxSize int
@@ -67,6 +71,18 @@ type InstanceCtx struct {
 	// calendar days due to daylight savings, leap seconds, etc. The
 	// default is not to remove old log files based on age.
 	LogMaxAge int
+	// LogCompress is a compression of rotated log files: gzip or zstd.
+	LogCompress string
+	// LogRotation is a time-based log rotation period: hourly or daily.
+	LogRotation string
+	// LogFilenamePattern is a pattern of rotated log file names.
+	LogFilenamePattern string
+	// LogSink is a destination of logs: file, syslog or journald.
+	LogSink string
+	// LogSyslogFacility is a syslog facility for syslog and journald sinks.
+	LogSyslogFacility string
+	// LogTag is a syslog tag for syslog and journald sinks.
+	LogTag string
 	// The name of the file with the watchdog PID under which the
 	// instance was started.
 	PIDFile string
@@ -77,6 +93,18 @@ type InstanceCtx struct {
 	ConsoleSocket string
 	// True if this is a single instance application (no instances.yml).
 	SingleApp bool
+	// Env contains environment variables passed to the instance.
+	Env map[string]string
+	// EnvFile is a path to the file with environment variables passed to
+	// the instance.
+	EnvFile string
+	// Rlimits contains resource limits of the instance process.
+	Rlimits []Rlimit
+	// Tags contains the instance tags used to select instances.
+	Tags map[string]string
+	// StopTimeout is the time given to the instance to stop gracefully
+	// before it is killed.
+	StopTimeout time.Duration
 }
 
 // RunFlags contains flags for tt run.
@@ -138,8 +166,12 @@ func (provider *providerImpl) CreateInstance(logger *ttlog.Logger) (*Instance, e
 		return nil, err
 	}
 
+	instEnv, err := buildEnv(provider.instanceCtx.Env, provider.instanceCtx.EnvFile)
+	if err != nil {
+		return nil, err
+	}
 	inst, err := NewInstance(provider.cmdCtx.Cli.TarantoolExecutable,
-		provider.instanceCtx, os.Environ(), logger)
+		provider.instanceCtx, append(os.Environ(), instEnv...), logger)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +202,24 @@ func isLoggerChanged(logger *ttlog.Logger, runningCtx *InstanceCtx) (bool, error
 	if loggerOpts.MaxSize != runningCtx.LogMaxSize {
 		return true, nil
 	}
+	if loggerOpts.Compress != runningCtx.LogCompress {
+		return true, nil
+	}
+	if loggerOpts.RotationPeriod != runningCtx.LogRotation {
+		return true, nil
+	}
+	if loggerOpts.FilenamePattern != runningCtx.LogFilenamePattern {
+		return true, nil
+	}
+	if loggerOpts.Sink != runningCtx.LogSink {
+		return true, nil
+	}
+	if loggerOpts.SyslogFacility != runningCtx.LogSyslogFacility {
+		return true, nil
+	}
+	if loggerOpts.Tag != runningCtx.LogTag {
+		return true, nil
+	}
 	return false, nil
 }
 
@@ -186,6 +236,22 @@ func (provider *providerImpl) UpdateLogger(logger *ttlog.Logger) (*ttlog.Logger,
 	return logger, nil
 }
 
+// ReloadLogger re-reads the configuration and applies the changed logger
+// settings in place. It returns true if the logger has been reconfigured.
+func (provider *providerImpl) ReloadLogger(logger *ttlog.Logger) (bool, error) {
+	if err := provider.updateCtx(); err != nil {
+		return false, err
+	}
+	updateLogger, err := isLoggerChanged(logger, provider.instanceCtx)
+	if err != nil || !updateLogger {
+		return false, err
+	}
+	if err = logger.Reconfigure(createLoggerOpts(provider.instanceCtx)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // IsRestartable checks if the instance should be restarted in case of crash.
 func (provider *providerImpl) IsRestartable() (bool, error) {
 	if err := provider.updateCtx(); err != nil {
@@ -241,6 +307,21 @@ func getInstancesFromYML(dirPath string, selectedInstName string) ([]InstanceCtx
 			instance.AppPath = script
 		}
 
+		params, err := toStringMap(instParams[inst])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", inst, err)
+		}
+		instance.Env, instance.EnvFile, instance.Rlimits, err = parseEnvParams(params, dirPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", inst, err)
+		}
+		if instance.Tags, err = parseTags(params); err != nil {
+			return nil, fmt.Errorf("%s: %s", inst, err)
+		}
+		if instance.StopTimeout, err = parseStopTimeout(params); err != nil {
+			return nil, fmt.Errorf("%s: %s", inst, err)
+		}
+
 		instances = append(instances, instance)
 	}
 
@@ -311,7 +392,7 @@ func CollectInstances(appName string, appDir string) ([]InstanceCtx, error) {
 // cleanup removes runtime artifacts.
 func cleanup(run *InstanceCtx) {
 	if _, err := os.Stat(run.PIDFile); err == nil {
-		os.Remove(run.PIDFile)
+		process_utils.RemovePIDFile(run.PIDFile)
 	}
 
 	if _, err := os.Stat(run.ConsoleSocket); err == nil {
@@ -319,16 +400,28 @@ func cleanup(run *InstanceCtx) {
 	}
 }
 
-// createLogger prepares a logger for the watchdog and instance.
-func createLogger(run *InstanceCtx) *ttlog.Logger {
-	opts := ttlog.LoggerOpts{
-		Filename:   run.Log,
-		MaxSize:    run.LogMaxSize,
-		MaxBackups: run.LogMaxBackups,
-		MaxAge:     run.LogMaxAge,
+// createLoggerOpts prepares the logger options for the watchdog and instance.
+func createLoggerOpts(run *InstanceCtx) *ttlog.LoggerOpts {
+	return &ttlog.LoggerOpts{
+		Filename:        run.Log,
+		MaxSize:         run.LogMaxSize,
+		MaxBackups:      run.LogMaxBackups,
+		MaxAge:          run.LogMaxAge,
+		Compress:        run.LogCompress,
+		RotationPeriod:  run.LogRotation,
+		FilenamePattern: run.LogFilenamePattern,
+		Sink:            run.LogSink,
+		SyslogFacility:  run.LogSyslogFacility,
+		Tag:             run.LogTag,
+		App:             run.AppName,
+		Instance:        run.InstName,
+		Source:          "Watchdog",
 	}
+}
 
-	return ttlog.NewLogger(&opts)
+// createLogger prepares a logger for the watchdog and instance.
+func createLogger(run *InstanceCtx) *ttlog.Logger {
+	return ttlog.NewLogger(createLoggerOpts(run))
 }
 
 // FillCtx fills the RunningCtx context.
@@ -351,8 +444,18 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 		instEnabledPath = cmdCtx.Cli.ConfigDir
 	}
 
+	// The application and instance names can be selected with shell patterns.
+	// Example: `tt stop 'shop:storage-*'`.
+	appPattern, instPattern := "", ""
+	isGlob := len(args) != 0 && isGlobSelector(args[0])
+	if isGlob {
+		if appPattern, instPattern, err = splitSelector(args[0]); err != nil {
+			return util.NewArgError(err.Error())
+		}
+	}
+
 	var appList []util.AppListEntry
-	if len(args) == 0 {
+	if len(args) == 0 || isGlob {
 		appList, err = util.CollectAppList(cmdCtx.Cli.ConfigDir, cliOpts.App.InstancesEnabled,
 			true)
 		if err != nil {
@@ -367,12 +470,18 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 	runningCtx.Instances = nil
 	for _, appInfo := range appList {
 		appName := strings.TrimSuffix(appInfo.Name, ".lua")
+		if !matchName(appPattern, appName) {
+			continue
+		}
 		instances, err := CollectInstances(appName, instEnabledPath)
 		if err != nil {
 			return fmt.Errorf("%s: can't find an application init file: %s", appName, err)
 		}
 
 		for _, inst := range instances {
+			if !matchName(instPattern, inst.InstName) {
+				continue
+			}
 			var instance InstanceCtx
 			var runDir string
 			var logDir string
@@ -392,6 +501,12 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 				instance.LogMaxSize = cliOpts.App.LogMaxSize
 				instance.LogMaxAge = cliOpts.App.LogMaxAge
 				instance.LogMaxBackups = cliOpts.App.LogMaxBackups
+				instance.LogCompress = cliOpts.App.LogCompress
+				instance.LogRotation = cliOpts.App.LogRotation
+				instance.LogFilenamePattern = cliOpts.App.LogFilenamePattern
+				instance.LogSink = cliOpts.App.LogSink
+				instance.LogSyslogFacility = cliOpts.App.LogSyslogFacility
+				instance.LogTag = cliOpts.App.LogTag
 				instance.Restartable = cliOpts.App.Restartable
 			}
 
@@ -404,7 +519,18 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 			instance.WalDir = pathBuilder.WithPath(cliOpts.App.WalDir).Make()
 			instance.VinylDir = pathBuilder.WithPath(cliOpts.App.VinylDir).Make()
 			instance.MemtxDir = pathBuilder.WithPath(cliOpts.App.MemtxDir).Make()
+			instance.CrashDir = pathBuilder.WithPath(cliOpts.App.CrashDir).Make()
 			instance.SingleApp = inst.SingleApp
+			instance.Tags = inst.Tags
+			if cliOpts.App.StopTimeout > 0 {
+				instance.StopTimeout = time.Duration(cliOpts.App.StopTimeout) * time.Second
+			}
+			if inst.StopTimeout > 0 {
+				instance.StopTimeout = inst.StopTimeout
+			}
+			if err = fillEnvParams(cliOpts.App, &instance, inst); err != nil {
+				return fmt.Errorf("%s: %s", GetAppInstanceName(instance), err)
+			}
 
 			if cmdCtx.CommandName == "start" || cmdCtx.CommandName == "restart" {
 				for _, dataDir := range [...]string{instance.WalDir, instance.VinylDir,
@@ -419,6 +545,10 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 		}
 	}
 
+	if isGlob && len(runningCtx.Instances) == 0 {
+		return fmt.Errorf("no instances match %q", args[0])
+	}
+
 	if cmdCtx.CommandName != "connect" {
 		if cmdCtx.Cli.TarantoolExecutable == "" {
 			return fmt.Errorf("tarantool binary not found")
@@ -428,43 +558,153 @@ func FillCtx(cliOpts *config.CliOpts, cmdCtx *cmdcontext.CmdCtx,
 	return nil
 }
 
-// Start an Instance.
-func Start(cmdCtx *cmdcontext.CmdCtx, run *InstanceCtx) error {
+// fillEnvParams fills the environment and resource limits of the instance from
+// the application options overridden by the instance parameters.
+func fillEnvParams(appOpts *config.AppOpts, instance *InstanceCtx, inst InstanceCtx) error {
+	env, err := parseEnv(appOpts.Env)
+	if err != nil {
+		return err
+	}
+	rlimits, err := parseRlimits(appOpts.Rlimits)
+	if err != nil {
+		return err
+	}
+	for name, value := range inst.Env {
+		env[name] = value
+	}
+	instance.Env = env
+	instance.EnvFile = appOpts.EnvFile
+	if inst.EnvFile != "" {
+		instance.EnvFile = inst.EnvFile
+	}
+	instance.Rlimits = mergeRlimits(rlimits, inst.Rlimits)
+	return nil
+}
+
+// StartOpts contains options of the instance start.
+type StartOpts struct {
+	// Notifier is used to notify systemd about the instance state if set.
+	Notifier *SdNotifier
+	// Watch enables restart of the instance on the application files changes.
+	Watch bool
+	// ExitNotify receives a byte each time the instance process exits if set.
+	ExitNotify io.Writer
+}
+
+// watchInstance restarts the instance on changes in the application directory
+// until stop is closed.
+func watchInstance(run *InstanceCtx, wd *Watchdog, stop <-chan struct{}) error {
+	watcher, err := NewChangeWatcher(filepath.Dir(run.AppPath), []string{run.RunDir,
+		run.LogDir, run.WalDir, run.MemtxDir, run.VinylDir, run.CrashDir})
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer watcher.Close()
+		watcher.Run(stop, func(reason string) {
+			log.Infof("Reloading %s: %s.", GetAppInstanceName(*run), reason)
+			wd.Reload(reason)
+		})
+	}()
+	return nil
+}
+
+// saveInstanceCrash saves the crash artifacts of the instance terminated by
+// a signal.
+func saveInstanceCrash(cmdCtx *cmdcontext.CmdCtx, run *InstanceCtx, inst *Instance,
+	status syscall.WaitStatus, logger *ttlog.Logger) {
+	record := newCrashRecord(run, inst.Cmd.Process.Pid, status, inst.startTime)
+	record.TarantoolExecutable = cmdCtx.Cli.TarantoolExecutable
+	if version, err := util.GetTarantoolVersion(&cmdCtx.Cli); err == nil {
+		record.TarantoolVersion = version
+	}
+
+	// A relative core pattern is resolved against the working directory of
+	// the instance.
+	searchDirs := []string{run.WalDir}
+	if workDir, err := os.Getwd(); err == nil {
+		searchDirs = append([]string{workDir}, searchDirs...)
+	}
+	crashDir, err := saveCrash(run, &record, searchDirs)
+	if crashDir == "" {
+		logger.Errorf("the Instance crashed with %s, can't save the crash artifacts: %v.",
+			record.Signal, err)
+		return
+	}
+	if err != nil {
+		logger.Warnf("%v.", err)
+	}
+	logger.Warnf("the Instance crashed with %s, the crash artifacts are saved to %s.",
+		record.Signal, crashDir)
+}
+
+// Start an Instance. The watchdog runs in the current process.
+func Start(cmdCtx *cmdcontext.CmdCtx, run *InstanceCtx, opts StartOpts) error {
 	logger := createLogger(run)
 	provider := providerImpl{cmdCtx: cmdCtx, instanceCtx: run}
+	// The PID file is locked while the watchdog is running.
+	var pidFile *os.File
 	preStartAction := func() error {
-		if err := process_utils.CreatePIDFile(run.PIDFile); err != nil {
+		var err error
+		if pidFile, err = process_utils.CreatePIDFile(run.PIDFile); err != nil {
 			return err
 		}
 		return nil
 	}
 	wd := NewWatchdog(run.Restartable, 5*time.Second, logger, &provider, preStartAction)
+	wd.stopTimeout = getStopTimeout(run)
+	if opts.Notifier != nil {
+		wd.postStartAction = opts.Notifier.instanceStarted
+	}
+	if opts.ExitNotify != nil {
+		notifyStarted := wd.postStartAction
+		wd.postStartAction = func(inst *Instance, exited <-chan struct{}) {
+			if notifyStarted != nil {
+				notifyStarted(inst, exited)
+			}
+			go func() {
+				<-exited
+				// The error is ignored: nobody may wait for the notification.
+				opts.ExitNotify.Write([]byte{0})
+			}()
+		}
+	}
+	// The signal that terminated the instance is recorded for tt stop.
+	wd.exitAction = func(inst *Instance) {
+		if err := saveExitSignal(run, inst); err != nil {
+			wd.logger.Warnf("can't record the instance exit signal: %v.", err)
+		}
+	}
+	// The crash artifacts are saved in background to not delay the restart.
+	var crashes sync.WaitGroup
+	wd.crashAction = func(inst *Instance, status syscall.WaitStatus) {
+		// The context and the logger are copied: they are updated on restart.
+		crashCtx := *provider.instanceCtx
+		logger := wd.logger
+		crashes.Add(1)
+		go func() {
+			defer crashes.Done()
+			saveInstanceCrash(cmdCtx, &crashCtx, inst, status, logger)
+		}()
+	}
 
 	defer func() {
+		crashes.Wait()
 		cleanup(run)
+		if pidFile != nil {
+			pidFile.Close()
+		}
 	}()
 
-	wd.Start()
-	return nil
-}
-
-// Stop the Instance.
-func Stop(run *InstanceCtx) error {
-	pid, err := process_utils.StopProcess(run.PIDFile)
-	if err != nil {
-		return err
-	}
-
-	// tarantool 1.10 does not have a trigger on terminate a process.
-	// So the socket will be closed automatically on termination and
-	// we need to delete the file.
-	if _, err := os.Stat(run.ConsoleSocket); err == nil {
-		os.Remove(run.ConsoleSocket)
+	if opts.Watch {
+		stopWatch := make(chan struct{})
+		defer close(stopWatch)
+		if err := watchInstance(run, wd, stopWatch); err != nil {
+			return err
+		}
 	}
 
-	fullInstanceName := GetAppInstanceName(*run)
-	log.Infof("The Instance %s (PID = %v) has been terminated.", fullInstanceName, pid)
-
+	wd.Start()
 	return nil
 }
 
@@ -484,13 +724,12 @@ func Status(run *InstanceCtx) process_utils.ProcessState {
 
 // Logrotate rotates logs of a started tarantool instance.
 func Logrotate(run *InstanceCtx) (string, error) {
-	pid, err := process_utils.GetPIDFromFile(run.PIDFile)
-	if err != nil {
+	if _, err := process_utils.GetPIDFromFile(run.PIDFile); err != nil {
 		return "", fmt.Errorf(instStateStopped.String())
 	}
 
-	alive, err := process_utils.IsProcessAlive(pid)
-	if !alive {
+	pid, err := process_utils.GetVerifiedPID(run.PIDFile)
+	if err != nil {
 		return "", fmt.Errorf(instStateDead.String())
 	}
 
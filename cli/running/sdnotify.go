@@ -0,0 +1,115 @@
+package running
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// notifySocketEnv is an environment variable with the systemd notification
+	// socket path.
+	notifySocketEnv = "NOTIFY_SOCKET"
+	// watchdogUsecEnv is an environment variable with the systemd watchdog
+	// timeout in microseconds.
+	watchdogUsecEnv = "WATCHDOG_USEC"
+	// watchdogPidEnv is an environment variable with the PID of the process
+	// expected to send the watchdog keep-alive notifications.
+	watchdogPidEnv = "WATCHDOG_PID"
+)
+
+// SdNotifier sends service state notifications to systemd. All methods are
+// no-op if the process is not started by systemd with notifications enabled.
+type SdNotifier struct {
+	// socket is a path to the notification socket.
+	socket string
+	// watchdogInterval is a systemd watchdog timeout. Zero value means the
+	// watchdog is disabled.
+	watchdogInterval time.Duration
+}
+
+// NewSdNotifier creates a notifier from the environment. The notification
+// environment variables are removed so that they are not inherited by the
+// instance processes.
+func NewSdNotifier() *SdNotifier {
+	notifier := SdNotifier{socket: os.Getenv(notifySocketEnv)}
+
+	usec, err := strconv.ParseUint(os.Getenv(watchdogUsecEnv), 10, 64)
+	if err == nil && usec > 0 {
+		watchdogPid := os.Getenv(watchdogPidEnv)
+		if watchdogPid == "" || watchdogPid == strconv.Itoa(os.Getpid()) {
+			notifier.watchdogInterval = time.Duration(usec) * time.Microsecond
+		}
+	}
+
+	os.Unsetenv(notifySocketEnv)
+	os.Unsetenv(watchdogUsecEnv)
+	os.Unsetenv(watchdogPidEnv)
+	return &notifier
+}
+
+// Notify sends the state notification, for example "READY=1".
+func (notifier *SdNotifier) Notify(state string) error {
+	if notifier.socket == "" {
+		return nil
+	}
+	socket := notifier.socket
+	// An abstract socket address starts with @.
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("can't connect to the systemd notification socket: %s", err)
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("can't send systemd notification: %s", err)
+	}
+	return nil
+}
+
+// KeepAlive sends the watchdog keep-alive notifications with the half of the
+// watchdog timeout period until stop is closed.
+func (notifier *SdNotifier) KeepAlive(stop <-chan struct{}) {
+	if notifier.socket == "" || notifier.watchdogInterval == 0 {
+		<-stop
+		return
+	}
+	ticker := time.NewTicker(notifier.watchdogInterval / 2)
+	defer ticker.Stop()
+	for {
+		notifier.Notify("WATCHDOG=1")
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// instanceStarted sends READY=1 notification once the instance is bootstrapped
+// and keep-alive notifications while it is running. exited must be closed when
+// the instance process exits.
+func (notifier *SdNotifier) instanceStarted(inst *Instance, exited <-chan struct{}) {
+	go func() {
+		notifier.Notify("STATUS=bootstrapping")
+		for {
+			status, err := getInstanceStatus(inst.consoleSocket)
+			if err == nil && status == statusRunning {
+				break
+			}
+			select {
+			case <-exited:
+				notifier.Notify("STATUS=the instance has exited")
+				return
+			case <-time.After(readyPollPeriod):
+			}
+		}
+		notifier.Notify("READY=1\nSTATUS=running")
+		notifier.KeepAlive(exited)
+		notifier.Notify("STATUS=the instance has exited")
+	}()
+}
@@ -0,0 +1,63 @@
+package running
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSdNotifier(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Setenv(notifySocketEnv, sockPath)
+	t.Setenv(watchdogUsecEnv, "200000")
+	notifier := NewSdNotifier()
+	for _, env := range []string{notifySocketEnv, watchdogUsecEnv, watchdogPidEnv} {
+		_, found := os.LookupEnv(env)
+		assert.False(t, found, env)
+	}
+	assert.Equal(t, 200*time.Millisecond, notifier.watchdogInterval)
+
+	receive := func() string {
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return string(buf[:n])
+	}
+
+	require.NoError(t, notifier.Notify("READY=1\nSTATUS=running"))
+	assert.Equal(t, "READY=1\nSTATUS=running", receive())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		notifier.KeepAlive(stop)
+		close(done)
+	}()
+	assert.Equal(t, "WATCHDOG=1", receive())
+	assert.Equal(t, "WATCHDOG=1", receive())
+	close(stop)
+	<-done
+}
+
+func TestSdNotifierDisabled(t *testing.T) {
+	t.Setenv(notifySocketEnv, "")
+	t.Setenv(watchdogUsecEnv, "200000")
+	t.Setenv(watchdogPidEnv, "1")
+	notifier := NewSdNotifier()
+	assert.Zero(t, notifier.watchdogInterval)
+	assert.NoError(t, notifier.Notify("READY=1"))
+
+	stop := make(chan struct{})
+	close(stop)
+	notifier.KeepAlive(stop)
+}
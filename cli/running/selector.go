@@ -0,0 +1,124 @@
+package running
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tagsKey is the instances.yml key of the instance tags.
+const tagsKey = "tags"
+
+// InstanceSelector describes additional criteria of the instances selection.
+type InstanceSelector struct {
+	// Tags contains tags the selected instances must have.
+	Tags map[string]string
+}
+
+// ParseTagSelectors parses tag selectors in the key=value form.
+func ParseTagSelectors(selectors []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, selector := range selectors {
+		key, value, found := strings.Cut(selector, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid tag selector %q: key=value is expected", selector)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
+
+// parseTags parses the instance tags from the instances.yml parameters.
+func parseTags(params map[string]interface{}) (map[string]string, error) {
+	rawTags, found := params[tagsKey]
+	if !found || rawTags == nil {
+		return nil, nil
+	}
+	tagsMap, err := toStringMap(rawTags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", tagsKey, err)
+	}
+	tags := map[string]string{}
+	for key, value := range tagsMap {
+		switch value.(type) {
+		case map[interface{}]interface{}, map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("%s: %s: a scalar value is expected", tagsKey, key)
+		}
+		tags[key] = fmt.Sprint(value)
+	}
+	return tags, nil
+}
+
+// isGlobSelector checks if the application or instance name selector is a
+// shell pattern.
+func isGlobSelector(selector string) bool {
+	return strings.ContainsAny(selector, "*?[")
+}
+
+// splitSelector splits the selector into the application and instance
+// name patterns.
+func splitSelector(selector string) (string, string, error) {
+	appPattern, instPattern, _ := strings.Cut(selector, string(InstanceDelimiter))
+	for _, pattern := range []string{appPattern, instPattern} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return "", "", fmt.Errorf("invalid selector %q: %s", selector, err)
+		}
+	}
+	return appPattern, instPattern, nil
+}
+
+// matchName checks if the name matches the pattern. An empty pattern matches
+// any name.
+func matchName(pattern string, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := filepath.Match(pattern, name)
+	return matched
+}
+
+// Match checks if the instance has all the selector tags.
+func (selector InstanceSelector) Match(instance *InstanceCtx) bool {
+	for key, value := range selector.Tags {
+		if instTag, found := instance.Tags[key]; !found || instTag != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectInstances filters the instances of the running context by the selector.
+// An error is returned if no instances are selected.
+func SelectInstances(runningCtx *RunningCtx, selector InstanceSelector) error {
+	if len(selector.Tags) == 0 {
+		return nil
+	}
+	selected := []InstanceCtx{}
+	for _, instance := range runningCtx.Instances {
+		if selector.Match(&instance) {
+			selected = append(selected, instance)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no instances match the tags: %s", formatTags(selector.Tags))
+	}
+	runningCtx.Instances = selected
+	return nil
+}
+
+// formatTags returns the comma-separated list of tags.
+func formatTags(tags map[string]string) string {
+	return strings.Join(TagsList(tags), ", ")
+}
+
+// TagsList returns the sorted list of tags in the key=value form.
+func TagsList(tags map[string]string) []string {
+	list := make([]string, 0, len(tags))
+	for key, value := range tags {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,122 @@
+package running
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tarantool/tt/cli/cmdcontext"
+	"github.com/tarantool/tt/cli/configure"
+)
+
+// createSelectorTestEnv creates an environment with the "shop" multi-instance
+// application and the "billing" single instance application.
+func createSelectorTestEnv(t *testing.T) *cmdcontext.CmdCtx {
+	baseDir := t.TempDir()
+	appsDir := filepath.Join(baseDir, "instances.enabled")
+	shopDir := filepath.Join(appsDir, "shop")
+	require.NoError(t, os.MkdirAll(shopDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(shopDir, "init.lua"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(shopDir, "instances.yml"), []byte(`
+shop.router:
+  tags:
+    role: router
+shop.storage-1:
+  tags:
+    role: storage
+    zone: 1
+shop.storage-2:
+  tags:
+    role: storage
+    zone: 2
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(appsDir, "billing.lua"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "tt.yaml"), []byte(""), 0644))
+
+	return &cmdcontext.CmdCtx{Cli: cmdcontext.CliCtx{
+		ConfigDir:           baseDir,
+		ConfigPath:          filepath.Join(baseDir, "tt.yaml"),
+		TarantoolExecutable: "tarantool",
+	}}
+}
+
+// selectedNames returns the sorted names of the selected instances.
+func selectedNames(runningCtx RunningCtx) []string {
+	names := ExtractInstanceNames(runningCtx.Instances)
+	sort.Strings(names)
+	return names
+}
+
+func TestFillCtxGlobSelectors(t *testing.T) {
+	cmdCtx := createSelectorTestEnv(t)
+	cliOpts := configure.GetDefaultCliOpts()
+	cliOpts.App.InstancesEnabled = filepath.Join(cmdCtx.Cli.ConfigDir, "instances.enabled")
+
+	for _, tc := range []struct {
+		selector string
+		expected []string
+	}{
+		{"shop:storage-*", []string{"shop:storage-1", "shop:storage-2"}},
+		{"shop:*", []string{"shop:router", "shop:storage-1", "shop:storage-2"}},
+		{"*:router", []string{"shop:router"}},
+		{"bill*", []string{"billing"}},
+		{"*", []string{"billing", "shop:router", "shop:storage-1", "shop:storage-2"}},
+		{"shop:storage-[2-9]", []string{"shop:storage-2"}},
+	} {
+		var runningCtx RunningCtx
+		require.NoError(t, FillCtx(cliOpts, cmdCtx, &runningCtx, []string{tc.selector}),
+			tc.selector)
+		assert.Equal(t, tc.expected, selectedNames(runningCtx), tc.selector)
+	}
+
+	var runningCtx RunningCtx
+	assert.EqualError(t, FillCtx(cliOpts, cmdCtx, &runningCtx, []string{"shop:replica-*"}),
+		`no instances match "shop:replica-*"`)
+	assert.Error(t, FillCtx(cliOpts, cmdCtx, &runningCtx, []string{"shop:[storage"}))
+}
+
+func TestSelectInstancesByTags(t *testing.T) {
+	cmdCtx := createSelectorTestEnv(t)
+	cliOpts := configure.GetDefaultCliOpts()
+	cliOpts.App.InstancesEnabled = filepath.Join(cmdCtx.Cli.ConfigDir, "instances.enabled")
+
+	var runningCtx RunningCtx
+	require.NoError(t, FillCtx(cliOpts, cmdCtx, &runningCtx, nil))
+	assert.Equal(t, []string{"role=router", "role=storage", "zone=1", "zone=2"},
+		ExtractTags(runningCtx.Instances))
+
+	tags, err := ParseTagSelectors([]string{"role=storage", " zone = 2"})
+	require.NoError(t, err)
+	require.NoError(t, SelectInstances(&runningCtx, InstanceSelector{Tags: tags}))
+	assert.Equal(t, []string{"shop:storage-2"}, selectedNames(runningCtx))
+
+	require.NoError(t, FillCtx(cliOpts, cmdCtx, &runningCtx, nil))
+	assert.EqualError(t, SelectInstances(&runningCtx,
+		InstanceSelector{Tags: map[string]string{"role": "proxy"}}),
+		"no instances match the tags: role=proxy")
+
+	for _, invalid := range []string{"role", "=router"} {
+		_, err = ParseTagSelectors([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags(map[string]interface{}{
+		tagsKey: map[interface{}]interface{}{"role": "router", "weight": 10},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"role": "router", "weight": "10"}, tags)
+
+	tags, err = parseTags(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+
+	_, err = parseTags(map[string]interface{}{
+		tagsKey: map[interface{}]interface{}{"role": []interface{}{"router"}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package running
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/tarantool/tt/cli/process_utils"
+)
+
+const (
+	// stopTimeoutKey is the instances.yml key of the graceful stop timeout.
+	stopTimeoutKey = "stop_timeout"
+	// defaultStopTimeout is the default time given to an instance to stop
+	// gracefully before it is killed.
+	defaultStopTimeout = 30 * time.Second
+	// killTimeout is the time given to the watchdog to finish after the
+	// instance is killed.
+	killTimeout = 5 * time.Second
+	// stopCheckPeriod is a period of the process termination checks.
+	stopCheckPeriod = 100 * time.Millisecond
+)
+
+// StopPhase is a phase of the instance stopping.
+type StopPhase string
+
+const (
+	// StopPhaseStopping means the instance stop is started.
+	StopPhaseStopping StopPhase = "stopping"
+	// StopPhaseWaitingSigint means SIGINT is sent and the instance is given
+	// time to stop gracefully.
+	StopPhaseWaitingSigint StopPhase = "waiting SIGINT"
+	// StopPhaseKilling means the graceful stop timeout is exceeded and the
+	// watchdog kills the instance with SIGKILL.
+	StopPhaseKilling StopPhase = "killing"
+	// StopPhaseStopped means the instance is stopped.
+	StopPhaseStopped StopPhase = "stopped"
+	// StopPhaseNotRunning means the instance is not running.
+	StopPhaseNotRunning StopPhase = "not running"
+	// StopPhaseFailed means the instance can't be stopped.
+	StopPhaseFailed StopPhase = "failed"
+)
+
+// StopResult describes the result of the instance stopping.
+type StopResult struct {
+	// Phase is the final phase: stopped, not running or failed.
+	Phase StopPhase
+	// PID is the watchdog PID.
+	PID int
+	// Signal is the signal the instance ended with.
+	Signal syscall.Signal
+	// Err is the stop error.
+	Err error
+}
+
+// parseStopTimeout parses the graceful stop timeout in seconds from the
+// instances.yml parameters.
+func parseStopTimeout(params map[string]interface{}) (time.Duration, error) {
+	raw, found := params[stopTimeoutKey]
+	if !found || raw == nil {
+		return 0, nil
+	}
+	seconds, ok := raw.(int)
+	if !ok || seconds <= 0 {
+		return 0, fmt.Errorf("%s: a positive number of seconds is expected, got %v",
+			stopTimeoutKey, raw)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// signalNames contains names of the signals an instance may end with.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGSYS:  "SIGSYS",
+}
+
+// signalName returns the name of the signal the instance ended with.
+func signalName(signal syscall.Signal) string {
+	if name, found := signalNames[signal]; found {
+		return name
+	}
+	return signal.String()
+}
+
+// getStopTimeout returns the graceful stop timeout of the instance.
+func getStopTimeout(run *InstanceCtx) time.Duration {
+	if run.StopTimeout > 0 {
+		return run.StopTimeout
+	}
+	return defaultStopTimeout
+}
+
+// exitSignalFile returns the path of the file the watchdog records the signal
+// that terminated the instance to.
+func exitSignalFile(pidFile string) string {
+	return pidFile + ".exit"
+}
+
+// saveExitSignal records the signal that terminated the instance process. Zero
+// is written if the instance has exited by itself.
+func saveExitSignal(run *InstanceCtx, inst *Instance) error {
+	signal := syscall.Signal(0)
+	if status, killed := inst.killedBy(); killed {
+		signal = status.Signal()
+	}
+	return os.WriteFile(exitSignalFile(run.PIDFile), []byte(strconv.Itoa(int(signal))),
+		0644)
+}
+
+// readExitSignal reads and removes the signal recorded by the watchdog. False
+// is returned if the watchdog has not recorded it.
+func readExitSignal(pidFile string) (syscall.Signal, bool) {
+	data, err := os.ReadFile(exitSignalFile(pidFile))
+	if err != nil {
+		return 0, false
+	}
+	os.Remove(exitSignalFile(pidFile))
+	signal, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	return syscall.Signal(signal), true
+}
+
+// waitTermination waits for the process termination until the timeout is
+// exceeded. Returns true if the process is terminated.
+func waitTermination(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if alive, _ := process_utils.IsProcessAlive(pid); !alive {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(stopCheckPeriod)
+	}
+}
+
+// Stop stops the instance watchdog with SIGINT. The watchdog stops the
+// instance gracefully and kills it with SIGKILL if the stop timeout is exceeded.
+// The watchdog itself is killed if it doesn't finish after that. onPhase is
+// called on every phase change.
+func Stop(run *InstanceCtx, onPhase func(StopPhase)) StopResult {
+	finish := func(result StopResult, phase StopPhase) StopResult {
+		result.Phase = phase
+		onPhase(phase)
+		return result
+	}
+	fail := func(result StopResult) StopResult {
+		return finish(result, StopPhaseFailed)
+	}
+
+	onPhase(StopPhaseStopping)
+	if _, err := process_utils.GetPIDFromFile(run.PIDFile); err != nil {
+		return finish(StopResult{}, StopPhaseNotRunning)
+	}
+	// The PID is not signalled if it belongs to another process.
+	pid, err := process_utils.GetVerifiedPID(run.PIDFile)
+	if err != nil {
+		return finish(StopResult{}, StopPhaseNotRunning)
+	}
+
+	if err = syscall.Kill(pid, syscall.SIGINT); err != nil {
+		return fail(StopResult{PID: pid,
+			Err: fmt.Errorf(`can't terminate the process. Error: "%v"`, err)})
+	}
+	onPhase(StopPhaseWaitingSigint)
+
+	result := StopResult{PID: pid}
+	watchdogKilled := false
+	if !waitTermination(pid, getStopTimeout(run)) {
+		// The watchdog kills the instance after the same timeout.
+		onPhase(StopPhaseKilling)
+		if !waitTermination(pid, killTimeout) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			if !waitTermination(pid, killTimeout) {
+				result.Err = fmt.Errorf("can't terminate the process")
+				return fail(result)
+			}
+			watchdogKilled = true
+		}
+	}
+
+	signal, recorded := readExitSignal(run.PIDFile)
+	switch {
+	case recorded && signal != 0:
+		result.Signal = signal
+	case !recorded && watchdogKilled:
+		result.Signal = syscall.SIGKILL
+	default:
+		// The instance has exited by itself on SIGINT.
+		result.Signal = syscall.SIGINT
+	}
+
+	// tarantool 1.10 does not have a trigger on terminate a process.
+	// So the socket will be closed automatically on termination and
+	// we need to delete the file.
+	if _, err := os.Stat(run.ConsoleSocket); err == nil {
+		os.Remove(run.ConsoleSocket)
+	}
+	return finish(result, StopPhaseStopped)
+}
+
+// ForEachInstance calls the action for each instance running at most parallel
+// actions at the same time. Zero parallel value means no limit.
+func ForEachInstance(instances []InstanceCtx, parallel int, action func(i int)) {
+	if parallel < 1 || parallel > len(instances) {
+		parallel = len(instances)
+	}
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallel)
+	for i := range instances {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			action(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,173 @@
+package running
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStopTestProcess starts the shell script and writes its PID to the PID
+// file of the instance.
+func startStopTestProcess(t *testing.T, script string) *InstanceCtx {
+	dir := t.TempDir()
+	cmd := exec.Command("sh", "-c", script)
+	require.NoError(t, cmd.Start())
+	// Reap the process, otherwise it stays alive as a zombie.
+	go cmd.Wait()
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	run := InstanceCtx{AppName: "app", InstName: "inst",
+		PIDFile:       filepath.Join(dir, "inst.pid"),
+		ConsoleSocket: filepath.Join(dir, "inst.control"),
+		StopTimeout:   time.Second}
+	require.NoError(t, os.WriteFile(run.PIDFile,
+		[]byte(strconv.Itoa(cmd.Process.Pid)), 0644))
+	require.NoError(t, os.WriteFile(run.ConsoleSocket, []byte{}, 0644))
+	return &run
+}
+
+func TestStop(t *testing.T) {
+	run := startStopTestProcess(t, "exec sleep 60")
+	phases := []StopPhase{}
+	result := Stop(run, func(phase StopPhase) {
+		phases = append(phases, phase)
+	})
+	require.NoError(t, result.Err)
+	assert.Equal(t, StopPhaseStopped, result.Phase)
+	assert.Equal(t, syscall.SIGINT, result.Signal)
+	assert.Equal(t, []StopPhase{StopPhaseStopping, StopPhaseWaitingSigint, StopPhaseStopped},
+		phases)
+	assert.NoFileExists(t, run.ConsoleSocket)
+
+	// The instance is not running anymore.
+	result = Stop(run, func(phase StopPhase) {})
+	require.NoError(t, result.Err)
+	assert.Equal(t, StopPhaseNotRunning, result.Phase)
+}
+
+func TestStopKill(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the test waits for the stop timeouts")
+	}
+	run := startStopTestProcess(t, "trap '' INT; while true; do sleep 0.1; done")
+	// Let the shell set up the trap.
+	time.Sleep(200 * time.Millisecond)
+	phases := []StopPhase{}
+	result := Stop(run, func(phase StopPhase) {
+		phases = append(phases, phase)
+	})
+	require.NoError(t, result.Err)
+	assert.Equal(t, syscall.SIGKILL, result.Signal)
+	assert.Equal(t, []StopPhase{StopPhaseStopping, StopPhaseWaitingSigint,
+		StopPhaseKilling, StopPhaseStopped}, phases)
+}
+
+func TestStopRecordedSignal(t *testing.T) {
+	run := startStopTestProcess(t, "exec sleep 60")
+	// The watchdog records the signal that has terminated the instance.
+	require.NoError(t, os.WriteFile(exitSignalFile(run.PIDFile),
+		[]byte(strconv.Itoa(int(syscall.SIGKILL))), 0644))
+	result := Stop(run, func(phase StopPhase) {})
+	require.NoError(t, result.Err)
+	assert.Equal(t, syscall.SIGKILL, result.Signal)
+	assert.NoFileExists(t, exitSignalFile(run.PIDFile))
+}
+
+func TestSaveExitSignal(t *testing.T) {
+	run := InstanceCtx{PIDFile: filepath.Join(t.TempDir(), "inst.pid")}
+	cmd := exec.Command("sleep", "60")
+	require.NoError(t, cmd.Start())
+	cmd.Process.Signal(syscall.SIGTERM)
+	cmd.Wait()
+	require.NoError(t, saveExitSignal(&run, &Instance{Cmd: cmd}))
+	signal, recorded := readExitSignal(run.PIDFile)
+	assert.True(t, recorded)
+	assert.Equal(t, syscall.SIGTERM, signal)
+
+	cmd = exec.Command("true")
+	require.NoError(t, cmd.Run())
+	require.NoError(t, saveExitSignal(&run, &Instance{Cmd: cmd}))
+	signal, recorded = readExitSignal(run.PIDFile)
+	assert.True(t, recorded)
+	assert.Zero(t, signal)
+
+	_, recorded = readExitSignal(run.PIDFile)
+	assert.False(t, recorded)
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	timeout, err := parseStopTimeout(map[string]interface{}{stopTimeoutKey: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, timeout)
+
+	timeout, err = parseStopTimeout(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Zero(t, timeout)
+	assert.Equal(t, defaultStopTimeout, getStopTimeout(&InstanceCtx{}))
+
+	for _, invalid := range []interface{}{0, -1, "10s", 1.5} {
+		_, err = parseStopTimeout(map[string]interface{}{stopTimeoutKey: invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestForEachInstance(t *testing.T) {
+	instances := make([]InstanceCtx, 10)
+	for _, parallel := range []int{0, 1, 3} {
+		var running, maxRunning int32
+		var mutex sync.Mutex
+		processed := map[int]bool{}
+		ForEachInstance(instances, parallel, func(i int) {
+			current := atomic.AddInt32(&running, 1)
+			mutex.Lock()
+			processed[i] = true
+			if current > maxRunning {
+				maxRunning = current
+			}
+			mutex.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+		assert.Len(t, processed, len(instances))
+		if parallel > 0 {
+			assert.LessOrEqual(t, maxRunning, int32(parallel))
+		}
+	}
+}
+
+func TestStopProgress(t *testing.T) {
+	out, err := os.CreateTemp(t.TempDir(), "progress")
+	require.NoError(t, err)
+	defer out.Close()
+
+	progress := NewStopProgress(out, []InstanceCtx{
+		{AppName: "app", InstName: "router"},
+		{AppName: "app", InstName: "storage"},
+	})
+	progress.SetPhase(0, StopPhaseWaitingSigint)
+	progress.SetPhase(1, StopPhaseNotRunning)
+	progress.SetPhase(0, StopPhaseStopped)
+	assert.False(t, progress.live)
+	failures := progress.PrintSummary([]StopResult{
+		{Phase: StopPhaseStopped, PID: 100, Signal: syscall.SIGKILL},
+		{Phase: StopPhaseNotRunning},
+	})
+	assert.Zero(t, failures)
+
+	data, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, `
+INSTANCE     RESULT       SIGNAL   PID
+app:router   stopped      SIGKILL  100
+app:storage  not running  -        -
+`, string(data))
+}
@@ -0,0 +1,123 @@
+package running
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tarantool/tt/cli/connector"
+	"github.com/tarantool/tt/cli/ttlog"
+	"github.com/tarantool/tt/cli/util"
+)
+
+const (
+	// readyPollPeriod is a period of the instance readiness checks.
+	readyPollPeriod = 100 * time.Millisecond
+	// readyRequestTimeout is a timeout of the box.info.status request.
+	readyRequestTimeout = 3 * time.Second
+	// failureLogLines is the number of log lines printed if the instance fails
+	// to become ready.
+	failureLogLines = 15
+	// statusRunning is the box.info.status value of the bootstrapped instance.
+	statusRunning = "running"
+)
+
+// getBoxStatus is a Lua code that returns box.info.status. It does not fail
+// if box.cfg has not been called yet.
+const getBoxStatus = `if type(box.cfg) == 'function' then return 'unconfigured' end
+return box.info.status`
+
+// connectConsole connects to the instance console socket. The socket is dialed
+// directly without changing the working directory (unlike connector.Connect)
+// because the check can be performed concurrently with the instance start.
+func connectConsole(consoleSocket string) (connector.Connector, error) {
+	if _, err := os.Stat(consoleSocket); err != nil {
+		return nil, fmt.Errorf("console socket is not created yet")
+	}
+	conn, err := net.DialTimeout(connector.UnixNetwork, consoleSocket, readyRequestTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial: %s", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(readyRequestTimeout))
+	protocol, err := connector.GetProtocol(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get protocol: %s", err)
+	}
+	if protocol != connector.TextProtocol {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected console protocol: %s", protocol)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return connector.NewTextConnector(conn), nil
+}
+
+// getInstanceStatus connects to the instance console socket and returns
+// box.info.status.
+func getInstanceStatus(consoleSocket string) (string, error) {
+	conn, err := connectConsole(consoleSocket)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	res, err := conn.Eval(getBoxStatus, []interface{}{},
+		connector.RequestOpts{ReadTimeout: readyRequestTimeout})
+	if err != nil {
+		return "", err
+	}
+	if len(res) != 1 {
+		return "", fmt.Errorf("unexpected response: %v", res)
+	}
+	status, ok := res[0].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected response: %v", res)
+	}
+	return status, nil
+}
+
+// newNotReadyError creates an error with the last lines of the instance log.
+func newNotReadyError(run *InstanceCtx, reason string) error {
+	msg := fmt.Sprintf("the instance %s is not ready: %s", GetAppInstanceName(*run), reason)
+	if run.LogSink != "" && run.LogSink != ttlog.SinkFile {
+		return errors.New(msg)
+	}
+	if _, err := os.Stat(run.Log); err != nil {
+		return errors.New(msg)
+	}
+	lines, err := util.GetLastNLines(run.Log, failureLogLines)
+	if err != nil || len(lines) == 0 {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%s\nThe last lines of %s:\n%s", msg, run.Log, strings.Join(lines, "\n"))
+}
+
+// WaitReady waits until the instance console socket accepts connections and
+// box.info.status is "running". exited must be closed if the watchdog of the
+// instance exits. The deadline is the time to stop waiting.
+func WaitReady(run *InstanceCtx, deadline time.Time, exited <-chan struct{}) error {
+	lastState := "console socket is not created yet"
+	for {
+		status, err := getInstanceStatus(run.ConsoleSocket)
+		if err == nil && status == statusRunning {
+			return nil
+		}
+		if err != nil {
+			lastState = err.Error()
+		} else {
+			lastState = fmt.Sprintf("box.info.status is %q", status)
+		}
+
+		select {
+		case <-exited:
+			return newNotReadyError(run, "the instance has exited during bootstrap")
+		case <-time.After(readyPollPeriod):
+		}
+		if time.Now().After(deadline) {
+			return newNotReadyError(run, "timeout exceeded, "+lastState)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package running
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitReadyExited(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := InstanceCtx{
+		AppName:       "app",
+		InstName:      "master",
+		ConsoleSocket: filepath.Join(tmpDir, "master.control"),
+		Log:           filepath.Join(tmpDir, "master.log"),
+	}
+	require.NoError(t, os.WriteFile(run.Log, []byte("line1\nline2\nfatal error\n"), 0644))
+
+	exited := make(chan struct{})
+	close(exited)
+	err := WaitReady(&run, time.Now().Add(time.Minute), exited)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"the instance app:master is not ready: the instance has exited during bootstrap")
+	assert.Contains(t, err.Error(), "line1\nline2\nfatal error")
+}
+
+func TestWaitReadyTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	run := InstanceCtx{
+		AppName:       "app",
+		InstName:      "app",
+		SingleApp:     true,
+		ConsoleSocket: filepath.Join(tmpDir, "app.control"),
+		Log:           filepath.Join(tmpDir, "app.log"),
+	}
+
+	err := WaitReady(&run, time.Now().Add(300*time.Millisecond), make(chan struct{}))
+	require.Error(t, err)
+	assert.Equal(t, "the instance app is not ready: timeout exceeded, "+
+		"console socket is not created yet", err.Error())
+}
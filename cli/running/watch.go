@@ -0,0 +1,306 @@
+package running
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// WatchIgnoreFile is a name of the file with patterns of files and
+	// directories ignored in watch mode. It is searched in the watched directory.
+	WatchIgnoreFile = ".ttwatchignore"
+	// watchDebounce is a quiet period after the last change before the reload.
+	watchDebounce = 300 * time.Millisecond
+	// watchStopTimeout is the time given to the script to terminate before
+	// it is killed.
+	watchStopTimeout = 30 * time.Second
+)
+
+// defaultWatchIgnore contains patterns that are always ignored: VCS directories
+// and editors temporary files.
+var defaultWatchIgnore = []string{".git/", "*.swp", "*.swx", "*~", ".#*", "4913",
+	WatchIgnoreFile}
+
+// ChangeWatcher watches a directory tree for changes.
+type ChangeWatcher struct {
+	// watcher is the file system events watcher.
+	watcher *fsnotify.Watcher
+	// root is the watched directory.
+	root string
+	// patterns contains patterns of the ignored files and directories.
+	patterns []string
+	// ignoredDirs contains absolute paths of the ignored directories.
+	ignoredDirs []string
+}
+
+// readWatchIgnore reads patterns from the ignore file. Empty lines and lines
+// starting with # are skipped.
+func readWatchIgnore(ignoreFile string) ([]string, error) {
+	file, err := os.Open(ignoreFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// NewChangeWatcher creates a watcher of the root directory tree. Files and
+// directories matching the patterns from the ignore file in the root directory
+// are skipped as well as the ignoredDirs.
+func NewChangeWatcher(root string, ignoredDirs []string) (*ChangeWatcher, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := readWatchIgnore(filepath.Join(root, WatchIgnoreFile))
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %s", WatchIgnoreFile, err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("can't create a file system watcher: %s", err)
+	}
+	changeWatcher := &ChangeWatcher{
+		watcher:     watcher,
+		root:        root,
+		patterns:    append(append([]string{}, defaultWatchIgnore...), patterns...),
+		ignoredDirs: ignoredDirs,
+	}
+	if err = changeWatcher.addTree(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return changeWatcher, nil
+}
+
+// isIgnored checks if the path matches any of the ignore patterns. A pattern
+// ending with / matches directories only. A pattern starting with / is matched
+// against the path relative to the root only, other patterns are also matched
+// against the base name.
+func (cw *ChangeWatcher) isIgnored(path string, isDir bool) bool {
+	if isDir {
+		for _, dir := range cw.ignoredDirs {
+			if path == dir {
+				return true
+			}
+		}
+	}
+	relPath, err := filepath.Rel(cw.root, path)
+	if err != nil || relPath == "." {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range cw.patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if strings.HasPrefix(pattern, "/") {
+			if matched, _ := filepath.Match(pattern[1:], relPath); matched {
+				return true
+			}
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// addTree adds the directory and all its not ignored subdirectories to the watcher.
+func (cw *ChangeWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			// The directory could be removed already.
+			if path != dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if cw.isIgnored(path, true) {
+			return filepath.SkipDir
+		}
+		if err := cw.watcher.Add(path); err != nil {
+			return fmt.Errorf("can't watch %s: %s", path, err)
+		}
+		return nil
+	})
+}
+
+// handleEvent processes the file system event. It returns the path relative
+// to the root if the event is a change to be reported.
+func (cw *ChangeWatcher) handleEvent(event fsnotify.Event) (string, bool) {
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+	isDir := false
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			isDir = true
+		}
+	}
+	if cw.isIgnored(event.Name, isDir) {
+		return "", false
+	}
+	if isDir {
+		// New directories are not watched automatically.
+		cw.addTree(event.Name)
+	}
+	relPath, err := filepath.Rel(cw.root, event.Name)
+	if err != nil {
+		relPath = event.Name
+	}
+	return relPath, true
+}
+
+// formatReason returns a short description of the changes.
+func formatReason(changed []string) string {
+	if len(changed) == 1 {
+		return fmt.Sprintf("%s changed", changed[0])
+	}
+	return fmt.Sprintf("%s and %d more file(s) changed", changed[0], len(changed)-1)
+}
+
+// Run watches for changes until stop is closed. onChange is called with the
+// description of the changes after a burst of changes is finished.
+func (cw *ChangeWatcher) Run(stop <-chan struct{}, onChange func(reason string)) {
+	changed := []string{}
+	seen := map[string]bool{}
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-cw.watcher.Events:
+			if !ok {
+				return
+			}
+			if path, isChange := cw.handleEvent(event); isChange {
+				if !seen[path] {
+					seen[path] = true
+					changed = append(changed, path)
+				}
+				debounce.Reset(watchDebounce)
+			}
+		case _, ok := <-cw.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce.C:
+			if len(changed) > 0 {
+				onChange(formatReason(changed))
+				changed = []string{}
+				seen = map[string]bool{}
+			}
+		}
+	}
+}
+
+// Close stops watching.
+func (cw *ChangeWatcher) Close() error {
+	return cw.watcher.Close()
+}
+
+// stopChild interrupts the script process and waits for its termination.
+func stopChild(inst *Instance, exited <-chan struct{}) {
+	inst.SendSignal(os.Interrupt)
+	select {
+	case <-exited:
+	case <-time.After(watchStopTimeout):
+		inst.Cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// RunWatch runs the script in a child process and restarts it on changes in
+// the script directory.
+func RunWatch(runOpts *RunOpts, scriptPath string) error {
+	if scriptPath == "" {
+		return fmt.Errorf("watch mode requires a script to run")
+	}
+	watcher, err := NewChangeWatcher(filepath.Dir(scriptPath), nil)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	changes := make(chan string, 1)
+	go watcher.Run(stop, func(reason string) {
+		select {
+		case changes <- reason:
+		default:
+			// A reload is already pending.
+		}
+	})
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	inst := Instance{tarantoolPath: runOpts.CmdCtx.Cli.TarantoolExecutable,
+		appPath: scriptPath,
+		env:     os.Environ()}
+	for {
+		if err := inst.startChild(runOpts.RunFlags); err != nil {
+			return err
+		}
+		exited := make(chan struct{})
+		go func() {
+			inst.Wait()
+			close(exited)
+		}()
+
+		select {
+		case reason := <-changes:
+			log.Infof("Reloading %s: %s.", scriptPath, reason)
+			stopChild(&inst, exited)
+		case <-exited:
+			log.Infof("%s has exited, waiting for changes...", scriptPath)
+			select {
+			case reason := <-changes:
+				log.Infof("Reloading %s: %s.", scriptPath, reason)
+			case <-sigChan:
+				return nil
+			}
+		case <-sigChan:
+			stopChild(&inst, exited)
+			return nil
+		}
+	}
+}
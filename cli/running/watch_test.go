@@ -0,0 +1,93 @@
+package running
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeWatcherIgnore(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, WatchIgnoreFile),
+		[]byte("# Comment.\n*.log\ntmp/\n/build\n"), 0644))
+	varDir := filepath.Join(root, "var")
+	watcher, err := NewChangeWatcher(root, []string{varDir})
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	for _, tc := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"init.lua", false, false},
+		{"app/roles/router.lua", false, false},
+		{"inst.log", false, true},
+		{"app/inst.log", false, true},
+		{"tmp", true, true},
+		{"app/tmp", true, true},
+		{"tmp", false, false},
+		{"build", true, true},
+		{"app/build", true, false},
+		{".git", true, true},
+		{"init.lua.swp", false, true},
+		{"init.lua~", false, true},
+		{"var", true, true},
+		{WatchIgnoreFile, false, true},
+	} {
+		assert.Equal(t, tc.ignored,
+			watcher.isIgnored(filepath.Join(root, tc.path), tc.isDir), tc.path)
+	}
+}
+
+func TestChangeWatcherRun(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, WatchIgnoreFile),
+		[]byte("*.log\n"), 0644))
+	watcher, err := NewChangeWatcher(root, nil)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	reasons := make(chan string, 10)
+	go watcher.Run(stop, func(reason string) {
+		reasons <- reason
+	})
+
+	receive := func() string {
+		select {
+		case reason := <-reasons:
+			return reason
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no change detected")
+		}
+		return ""
+	}
+
+	// Ignored files do not trigger reload.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "inst.log"), []byte("log"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "init.lua"), []byte("x = 1"), 0644))
+	assert.Equal(t, "init.lua changed", receive())
+
+	// A burst of changes is reported once.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "init.lua"), []byte("x = 2"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "mod.lua"), []byte("y = 1"), 0644))
+	assert.Equal(t, "init.lua and 1 more file(s) changed", receive())
+
+	// New directories are watched.
+	require.NoError(t, os.Mkdir(filepath.Join(root, "roles"), 0755))
+	assert.Equal(t, "roles changed", receive())
+	require.NoError(t, os.WriteFile(filepath.Join(root, "roles", "r.lua"), []byte(""), 0644))
+	assert.Equal(t, filepath.Join("roles", "r.lua")+" changed", receive())
+
+	select {
+	case reason := <-reasons:
+		assert.Fail(t, "unexpected change", reason)
+	case <-time.After(2 * watchDebounce):
+	}
+}
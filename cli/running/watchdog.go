@@ -19,6 +19,9 @@ type Provider interface {
 	// UpdateLogger updates the logger settings or creates a new logger,
 	// if passed nil.
 	UpdateLogger(logger *ttlog.Logger) (*ttlog.Logger, error)
+	// ReloadLogger re-reads the configuration and applies the changed logger
+	// settings in place. It returns true if the logger has been reconfigured.
+	ReloadLogger(logger *ttlog.Logger) (bool, error)
 	// IsRestartable checks
 	IsRestartable() (bool, error)
 }
@@ -46,15 +49,31 @@ type Watchdog struct {
 	stopMutex sync.Mutex
 	// shouldStop indicates whether the Watchdog should be stopped.
 	shouldStop bool
+	// reloadRequested indicates whether the Instance is stopped to be
+	// restarted immediately.
+	reloadRequested bool
+	// stopTimeout is the time given to the Instance to stop gracefully
+	// before it is killed.
+	stopTimeout time.Duration
 	// preStartAction is a hook that is to be run before the start of a new Instance.
 	preStartAction func() error
+	// postStartAction is a hook that is to be run after the start of a new
+	// Instance. The exited channel is closed when the Instance process exits.
+	postStartAction func(inst *Instance, exited <-chan struct{})
+	// crashAction is a hook that is to be run if the Instance is terminated
+	// by a signal not sent by the Watchdog. It is run before the restart, so
+	// long operations must be performed in background.
+	crashAction func(inst *Instance, status syscall.WaitStatus)
+	// exitAction is a hook that is to be run after the Instance process
+	// is finished.
+	exitAction func(inst *Instance)
 }
 
 // NewWatchdog creates a new instance of Watchdog.
 func NewWatchdog(restartable bool, restartTimeout time.Duration, logger *ttlog.Logger,
 	provider Provider, preStartAction func() error) *Watchdog {
 	wd := Watchdog{instance: nil, logger: logger, restartTimeout: restartTimeout,
-		provider: provider, preStartAction: preStartAction}
+		provider: provider, preStartAction: preStartAction, stopTimeout: defaultStopTimeout}
 
 	wd.done = make(chan bool, 1)
 
@@ -66,7 +85,7 @@ func (wd *Watchdog) Start() error {
 	var err error
 	// Create Instance.
 	if wd.instance, err = wd.provider.CreateInstance(wd.logger); err != nil {
-		wd.logger.Printf(`Watchdog(ERROR): "%v".`, err)
+		wd.logger.Errorf(`"%v".`, err)
 		return err
 	}
 	wd.logger = wd.instance.logger
@@ -77,7 +96,7 @@ func (wd *Watchdog) Start() error {
 	wd.startSignalHandling()
 
 	if err = wd.preStartAction(); err != nil {
-		wd.logger.Printf(`Pre-start action error: %v`, err)
+		wd.logger.Errorf(`pre-start action error: %v`, err)
 		// Finish the signal handling goroutine.
 		wd.done <- true
 		return err
@@ -90,52 +109,71 @@ func (wd *Watchdog) Start() error {
 
 		wd.stopMutex.Lock()
 		if wd.shouldStop {
-			wd.logger.Printf(`Watchdog(ERROR): terminated before instance start.`)
+			wd.logger.Errorf(`terminated before instance start.`)
 			wd.stopMutex.Unlock()
 			return nil
 		}
+		wd.reloadRequested = false
 		// Start the Instance.
 		if err := wd.instance.Start(); err != nil {
-			wd.logger.Printf(`Watchdog(ERROR): "%v".`, err)
+			wd.logger.Errorf(`"%v".`, err)
 			wd.stopMutex.Unlock()
 			break
 		}
 		wd.stopMutex.Unlock()
 
+		instanceExited := make(chan struct{})
+		if wd.postStartAction != nil {
+			wd.postStartAction(wd.instance, instanceExited)
+		}
+
 		// Wait while the Instance will be terminated.
 		if err := wd.instance.Wait(); err != nil {
-			wd.logger.Printf(`Watchdog(WARN): "%v".`, err)
+			wd.logger.Warnf(`"%v".`, err)
 		}
+		close(instanceExited)
+		if wd.exitAction != nil {
+			wd.exitAction(wd.instance)
+		}
+		wd.checkCrash()
 
 		// Set Instance process completion indication.
 		wd.done <- true
 		// Wait for the signal processing goroutine to complete.
 		wd.doneBarrier.Wait()
 
-		// Stop the process if the Instance is not restartable.
-		restartable, err := wd.provider.IsRestartable()
-		if err != nil {
-			wd.logger.Println("Watchdog(ERROR): can't check if the instance is restartable.")
-			break
-		}
-		if wd.shouldStop || !restartable {
-			wd.logger.Println("Watchdog(INFO): the Instance has shutdown.")
-			break
+		wd.stopMutex.Lock()
+		reloadRequested := wd.reloadRequested && !wd.shouldStop
+		wd.stopMutex.Unlock()
+
+		if !reloadRequested {
+			// Stop the process if the Instance is not restartable.
+			restartable, err := wd.provider.IsRestartable()
+			if err != nil {
+				wd.logger.Errorf("can't check if the instance is restartable.")
+				break
+			}
+			if wd.shouldStop || !restartable {
+				wd.logger.Infof("the Instance has shutdown.")
+				break
+			}
 		}
 
 		if logger, err := wd.provider.UpdateLogger(wd.logger); err != nil {
-			wd.logger.Println("Watchdog(ERROR): can't update logger parameters.")
+			wd.logger.Errorf("can't update logger parameters.")
 			break
 		} else {
 			wd.logger = logger
 		}
-		time.Sleep(wd.restartTimeout)
+		if !reloadRequested {
+			time.Sleep(wd.restartTimeout)
+		}
 
 		wd.shouldStop = false
 
 		// Recreate Instance.
 		if wd.instance, err = wd.provider.CreateInstance(wd.logger); err != nil {
-			wd.logger.Printf(`Watchdog(ERROR): "%v".`, err)
+			wd.logger.Errorf(`"%v".`, err)
 			return err
 		}
 		wd.logger = wd.instance.logger
@@ -145,6 +183,51 @@ func (wd *Watchdog) Start() error {
 	return nil
 }
 
+// Reload gracefully stops the Instance to start it again immediately. It is
+// used to apply the application code changes.
+func (wd *Watchdog) Reload(reason string) {
+	wd.stopMutex.Lock()
+	if wd.shouldStop || wd.instance == nil {
+		wd.stopMutex.Unlock()
+		return
+	}
+	wd.reloadRequested = true
+	inst := wd.instance
+	wd.stopMutex.Unlock()
+
+	wd.logger.Infof("reloading the Instance: %s.", reason)
+	if inst.IsAlive() {
+		inst.Stop(wd.stopTimeout)
+	}
+}
+
+// reloadConfig re-reads the configuration and applies the changed logger
+// settings without the Instance restart.
+func (wd *Watchdog) reloadConfig() {
+	reloaded, err := wd.provider.ReloadLogger(wd.logger)
+	if err != nil {
+		wd.logger.Errorf("can't reload the configuration: %v.", err)
+		return
+	}
+	if reloaded {
+		wd.logger.Infof("the logger settings have been reloaded.")
+	}
+}
+
+// checkCrash runs the crash action if the Instance has been terminated by a
+// signal while the Watchdog has not been stopping it.
+func (wd *Watchdog) checkCrash() {
+	wd.stopMutex.Lock()
+	stopping := wd.shouldStop || wd.reloadRequested
+	wd.stopMutex.Unlock()
+	if stopping || wd.crashAction == nil {
+		return
+	}
+	if status, killed := wd.instance.killedBy(); killed {
+		wd.crashAction(wd.instance, status)
+	}
+}
+
 // startSignalHandling starts signal handling in a separate goroutine.
 func (wd *Watchdog) startSignalHandling() {
 	sigChan := make(chan os.Signal, 1)
@@ -178,11 +261,14 @@ func (wd *Watchdog) startSignalHandling() {
 					wd.shouldStop = true
 					wd.stopMutex.Unlock()
 					if wd.instance.IsAlive() {
-						wd.instance.Stop(30 * time.Second)
+						wd.instance.Stop(wd.stopTimeout)
 					}
 				case syscall.SIGHUP:
 					// Rotate the log files.
 					wd.logger.Rotate()
+				case syscall.SIGUSR2:
+					// Re-read the configuration.
+					wd.reloadConfig()
 				default:
 					if wd.instance.IsAlive() {
 						wd.instance.SendSignal(sig)
@@ -48,6 +48,11 @@ func (provider *providerTestImpl) UpdateLogger(logger *ttlog.Logger) (*ttlog.Log
 	return logger, nil
 }
 
+// ReloadLogger applies the changed logger settings in place.
+func (provider *providerTestImpl) ReloadLogger(logger *ttlog.Logger) (bool, error) {
+	return false, nil
+}
+
 // IsRestartable checks if the instance should be restarted in case of crash.
 func (provider *providerTestImpl) IsRestartable() (bool, error) {
 	return provider.restartable, nil
@@ -171,3 +176,36 @@ func TestWatchdogNotRestartable(t *testing.T) {
 	case <-wdDoneChan:
 	}
 }
+
+func TestWatchdogReload(t *testing.T) {
+	assert := assert.New(t)
+
+	binPath, err := os.Executable()
+	require.NoErrorf(t, err, `Can't get the path to the executable. Error: "%v".`, err)
+	os.Setenv("started_flag_file", filepath.Join(filepath.Dir(binPath), t.Name()))
+
+	// The reloaded instance is restarted even if it is not restartable.
+	wd := createTestWatchdog(t, false)
+	t.Cleanup(func() { cleanupWatchdog(wd) })
+
+	wdDoneChan := make(chan bool, 1)
+	go func() {
+		wd.Start()
+		wdDoneChan <- true
+	}()
+	require.NotZero(t, waitForFile(os.Getenv("started_flag_file")), "Instance is not started")
+
+	os.Remove(os.Getenv("started_flag_file"))
+	oldPid := wd.instance.Cmd.Process.Pid
+	wd.Reload("init.lua changed")
+	require.NotZero(t, waitForFile(os.Getenv("started_flag_file")), "Instance is not reloaded")
+	assert.True(wd.instance.IsAlive(), "Instance doesn't restart.")
+	assert.NotEqual(oldPid, wd.instance.Cmd.Process.Pid, "The old Instance is alive.")
+
+	wd.instance.SendSignal(syscall.SIGINT)
+	select {
+	case <-time.After(wdTestStopTimeout):
+		assert.Fail("Can't stop the watchdog.")
+	case <-wdDoneChan:
+	}
+}
@@ -2,9 +2,11 @@ package status
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/apex/log"
 	"github.com/fatih/color"
 	"github.com/tarantool/tt/cli/process_utils"
 	"github.com/tarantool/tt/cli/running"
@@ -20,14 +22,83 @@ const (
 
 var header = []string{"INSTANCE", "STATUS", "PID"}
 
-// Status writes the status as a table.
-func Status(runningCtx running.RunningCtx) error {
+// lastCrashHeader is the header of the column with the last crash time. The
+// column is printed only if any of the instances has crashed.
+const lastCrashHeader = "LAST CRASH"
+
+// crashTimeLayout is the layout of the last crash time.
+const crashTimeLayout = "2006-01-02 15:04:05"
+
+// formatCrash returns the last crash time and signal.
+func formatCrash(crash *running.CrashRecord) string {
+	if crash == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", crash.CrashTime.Local().Format(crashTimeLayout),
+		crash.Signal)
+}
+
+// printDetails prints the tags, environment and resource limits of the instances.
+func printDetails(runningCtx running.RunningCtx) {
+	for _, run := range runningCtx.Instances {
+		if len(run.Env) == 0 && run.EnvFile == "" && len(run.Rlimits) == 0 &&
+			len(run.Tags) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s:\n", running.GetAppInstanceName(run))
+		if len(run.Tags) > 0 {
+			fmt.Printf("  tags: %s\n", strings.Join(running.TagsList(run.Tags), ", "))
+		}
+		if run.EnvFile != "" {
+			fmt.Printf("  env_file: %s\n", run.EnvFile)
+		}
+		if len(run.Env) > 0 {
+			names := make([]string, 0, len(run.Env))
+			for name := range run.Env {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Println("  env:")
+			for _, name := range names {
+				fmt.Printf("    %s=%s\n", name, run.Env[name])
+			}
+		}
+		if len(run.Rlimits) > 0 {
+			fmt.Println("  rlimits:")
+			for _, rlimit := range run.Rlimits {
+				fmt.Printf("    %s\n", rlimit)
+			}
+		}
+	}
+}
+
+// Status writes the status as a table. The last crash time is added if any of
+// the instances has crashed. If details is set, the environment and
+// tags, environment and resource limits of the instances are printed after the table.
+func Status(runningCtx running.RunningCtx, details bool) error {
 	instColWidth := len(header[0])
 	sb := strings.Builder{}
 	tw := tabwriter.NewWriter(&sb, 0, 1, padding, ' ', 0)
 
-	fmt.Fprintln(tw, strings.Join(header, "\t"))
-	for _, run := range runningCtx.Instances {
+	crashes := make([]*running.CrashRecord, len(runningCtx.Instances))
+	hasCrashes := false
+	for i := range runningCtx.Instances {
+		crash, err := running.LastCrash(&runningCtx.Instances[i])
+		if err != nil {
+			// The status is shown even if the crash records can't be read.
+			log.Warnf("Can't get the last crash of %s: %s.",
+				running.GetAppInstanceName(runningCtx.Instances[i]), err)
+		}
+		crashes[i] = crash
+		hasCrashes = hasCrashes || crash != nil
+	}
+
+	columns := header
+	if hasCrashes {
+		columns = append(columns[:len(columns):len(columns)], lastCrashHeader)
+	}
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for i, run := range runningCtx.Instances {
 		fullInstanceName := running.GetAppInstanceName(run)
 		procStatus := running.Status(&run)
 		if len(fullInstanceName) > instColWidth {
@@ -39,6 +110,9 @@ func Status(runningCtx running.RunningCtx) error {
 		if procStatus.Code == process_utils.ProcessRunningCode {
 			fmt.Fprintf(tw, "%d", procStatus.PID)
 		}
+		if hasCrashes {
+			fmt.Fprintf(tw, "\t%s", formatCrash(crashes[i]))
+		}
 		fmt.Fprintf(tw, "\n")
 	}
 
@@ -61,5 +135,9 @@ func Status(runningCtx running.RunningCtx) error {
 	}
 	fmt.Println(rawHeader[statusOffset+toSkip:])
 	fmt.Print(rest)
+
+	if details {
+		printDetails(runningCtx)
+	}
 	return nil
 }
@@ -0,0 +1,118 @@
+package tail
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Level is a tarantool log level.
+type Level int
+
+const (
+	// LevelNone means that the level is unknown or not set.
+	LevelNone Level = iota
+	LevelFatal
+	LevelSysError
+	LevelError
+	LevelCrit
+	LevelWarn
+	LevelInfo
+	LevelVerbose
+	LevelDebug
+)
+
+// levelNames maps level names used in tarantool configuration and JSON logs to levels.
+var levelNames = map[string]Level{
+	"fatal":    LevelFatal,
+	"syserror": LevelSysError,
+	"error":    LevelError,
+	"crit":     LevelCrit,
+	"warn":     LevelWarn,
+	"info":     LevelInfo,
+	"verbose":  LevelVerbose,
+	"debug":    LevelDebug,
+}
+
+// levelLetters contains level letters used in the plain log format.
+var levelLetters = [...]string{
+	LevelNone:     "?",
+	LevelFatal:    "F",
+	LevelSysError: "!",
+	LevelError:    "E",
+	LevelCrit:     "C",
+	LevelWarn:     "W",
+	LevelInfo:     "I",
+	LevelVerbose:  "V",
+	LevelDebug:    "D",
+}
+
+// plainLevelRe matches the level letter in the plain log format line:
+// 2023-06-20 12:00:00.000 [12345] main/103/init.lua I> message.
+var plainLevelRe = regexp.MustCompile(`\s([FECWIVD!])>\s`)
+
+// ParseLevel parses the level name or its number as in box.cfg.log_level.
+func ParseLevel(name string) (Level, error) {
+	if level, found := levelNames[strings.ToLower(name)]; found {
+		return level, nil
+	}
+	if num, err := strconv.Atoi(name); err == nil && num >= 0 && num < int(LevelDebug) {
+		return Level(num + 1), nil
+	}
+	return LevelNone, fmt.Errorf("unknown log level: %q", name)
+}
+
+// jsonLogLine is a line of the log in the JSON format (box.cfg.log_format = 'json').
+type jsonLogLine struct {
+	Time      string `json:"time"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Pid       int    `json:"pid"`
+	FiberName string `json:"fiber_name"`
+}
+
+// ParseLine returns the level of the log line and the text to display. Lines in the
+// JSON format are converted to the plain format.
+func ParseLine(line string) (Level, string) {
+	if strings.HasPrefix(line, "{") {
+		var jsonLine jsonLogLine
+		if err := json.Unmarshal([]byte(line), &jsonLine); err == nil {
+			level := levelNames[strings.ToLower(jsonLine.Level)]
+			text := fmt.Sprintf("%s [%d] %s %s> %s", jsonLine.Time, jsonLine.Pid,
+				jsonLine.FiberName, levelLetters[level], jsonLine.Message)
+			return level, text
+		}
+	}
+
+	if matches := plainLevelRe.FindStringSubmatch(line); matches != nil {
+		for level, letter := range levelLetters {
+			if letter == matches[1] {
+				return Level(level), line
+			}
+		}
+	}
+	return LevelNone, line
+}
+
+// Filter describes the log lines filtering options.
+type Filter struct {
+	// Level is the maximum level of lines to show. LevelNone disables filtering.
+	Level Level
+	// Match is a regular expression the line must match. Nil disables filtering.
+	Match *regexp.Regexp
+}
+
+// Apply parses the line and checks it against the filter. Returns the text to
+// display and true if the line passes the filter.
+func (filter Filter) Apply(line string) (string, bool) {
+	level, text := ParseLine(line)
+	if filter.Level != LevelNone && (level == LevelNone || level > filter.Level) {
+		return "", false
+	}
+	if filter.Match != nil && !filter.Match.MatchString(text) {
+		return "", false
+	}
+	return text, true
+}
@@ -0,0 +1,174 @@
+package tail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tarantool/tt/cli/util"
+)
+
+// Follower reads the log file line by line. It handles the log file rotation
+// transparently: if the file is renamed and a new one is created, or the file is
+// truncated, reading continues from the beginning of the new file.
+type Follower struct {
+	// path is a path to the log file.
+	path string
+	// file is the currently opened log file. It is nil if the file does not exist yet.
+	file *os.File
+	// reader is a buffered reader of the opened file.
+	reader *bufio.Reader
+	// offset is a position in the opened file up to which the data has been read.
+	offset int64
+	// partial is the incomplete last line of the file.
+	partial string
+}
+
+// NewFollower creates a log file follower. The first read returns up to lastLines
+// last lines of the file. A missing file is not an error: it is opened as soon as
+// it appears.
+func NewFollower(path string, lastLines int) (*Follower, error) {
+	follower := &Follower{path: path}
+	if err := follower.open(); err != nil {
+		return nil, err
+	}
+	if follower.file == nil {
+		return follower, nil
+	}
+
+	var err error
+	if lastLines > 0 {
+		follower.offset, err = util.GetLastNLinesBegin(path, lastLines)
+	} else {
+		follower.offset, err = follower.file.Seek(0, io.SeekEnd)
+	}
+	if err != nil {
+		follower.Close()
+		return nil, err
+	}
+	if _, err = follower.file.Seek(follower.offset, io.SeekStart); err != nil {
+		follower.Close()
+		return nil, fmt.Errorf("failed to seek in file: %s", err)
+	}
+	return follower, nil
+}
+
+// open opens the log file from the beginning. It does nothing if the file does
+// not exist.
+func (follower *Follower) open() error {
+	file, err := os.Open(follower.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open file: %s", err)
+	}
+	follower.file = file
+	follower.reader = bufio.NewReader(file)
+	follower.offset = 0
+	follower.partial = ""
+	return nil
+}
+
+// readAvailable reads all complete lines available in the opened file.
+func (follower *Follower) readAvailable() ([]string, error) {
+	lines := []string{}
+	for {
+		chunk, err := follower.reader.ReadString('\n')
+		follower.offset += int64(len(chunk))
+		if err != nil {
+			follower.partial += chunk
+			if err == io.EOF {
+				return lines, nil
+			}
+			return lines, err
+		}
+		line := follower.partial + chunk[:len(chunk)-1]
+		follower.partial = ""
+		lines = append(lines, line)
+	}
+}
+
+// isRotated checks if the log file has been replaced or truncated.
+func (follower *Follower) isRotated() (bool, error) {
+	pathInfo, err := os.Stat(follower.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// The file is being rotated right now, wait for a new one.
+			return false, nil
+		}
+		return false, err
+	}
+	fileInfo, err := follower.file.Stat()
+	if err != nil {
+		return false, err
+	}
+	return !os.SameFile(pathInfo, fileInfo) || pathInfo.Size() < follower.offset, nil
+}
+
+// ReadLines returns new complete lines of the log file.
+func (follower *Follower) ReadLines() ([]string, error) {
+	if follower.file == nil {
+		if err := follower.open(); err != nil || follower.file == nil {
+			return nil, err
+		}
+	}
+
+	lines, err := follower.readAvailable()
+	if err != nil {
+		return lines, err
+	}
+
+	rotated, err := follower.isRotated()
+	if err != nil || !rotated {
+		return lines, err
+	}
+
+	// Flush the last line of the old file and continue with the new one.
+	if follower.partial != "" {
+		lines = append(lines, follower.partial)
+	}
+	follower.Close()
+	if err = follower.open(); err != nil || follower.file == nil {
+		return lines, err
+	}
+	newLines, err := follower.readAvailable()
+	return append(lines, newLines...), err
+}
+
+// Close closes the log file.
+func (follower *Follower) Close() {
+	if follower.file != nil {
+		follower.file.Close()
+		follower.file = nil
+		follower.reader = nil
+	}
+}
+
+// Follow polls the followers with the specified period and passes new lines to the
+// handler until the context is done. The handler receives the index of the follower
+// the line belongs to.
+func Follow(ctx context.Context, followers []*Follower, period time.Duration,
+	handler func(index int, line string)) error {
+	for {
+		for i, follower := range followers {
+			lines, err := follower.ReadLines()
+			for _, line := range lines {
+				handler(i, line)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(period):
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendToFile(t *testing.T, path string, data string) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString(data)
+	require.NoError(t, err)
+}
+
+func TestFollower(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "inst.log")
+	appendToFile(t, logPath, "line1\nline2\nline3\n")
+
+	follower, err := NewFollower(logPath, 2)
+	require.NoError(t, err)
+	defer follower.Close()
+
+	lines, err := follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line2", "line3"}, lines)
+
+	// Incomplete line is not returned until it is finished.
+	appendToFile(t, logPath, "line4\nline")
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line4"}, lines)
+
+	appendToFile(t, logPath, "5\n")
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line5"}, lines)
+
+	// Rotation: the file is renamed and a new one is created.
+	appendToFile(t, logPath, "line6\n")
+	require.NoError(t, os.Rename(logPath, logPath+".1"))
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line6"}, lines)
+
+	appendToFile(t, logPath, "new1\nnew2\n")
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new1", "new2"}, lines)
+
+	// Truncation.
+	require.NoError(t, os.Truncate(logPath, 0))
+	appendToFile(t, logPath, "trunc\n")
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trunc"}, lines)
+}
+
+func TestFollowerMissingFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "inst.log")
+
+	follower, err := NewFollower(logPath, 10)
+	require.NoError(t, err)
+	defer follower.Close()
+
+	lines, err := follower.ReadLines()
+	require.NoError(t, err)
+	assert.Empty(t, lines)
+
+	appendToFile(t, logPath, "line1\n")
+	lines, err = follower.ReadLines()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"line1"}, lines)
+}
+
+func TestFilter(t *testing.T) {
+	plainInfo := "2023-06-20 12:00:00.000 [123] main/103/init.lua I> started"
+	plainWarn := "2023-06-20 12:00:00.000 [123] main/103/init.lua W> slow request"
+	jsonErr := `{"time": "2023-06-20T12:00:00.000+0300", "level": "ERROR", ` +
+		`"message": "failed", "pid": 123, "fiber_name": "main"}`
+
+	level, text := ParseLine(jsonErr)
+	assert.Equal(t, LevelError, level)
+	assert.Equal(t, "2023-06-20T12:00:00.000+0300 [123] main E> failed", text)
+
+	warnLevel, err := ParseLevel("warn")
+	require.NoError(t, err)
+	filter := Filter{Level: warnLevel}
+	_, ok := filter.Apply(plainInfo)
+	assert.False(t, ok)
+	text, ok = filter.Apply(plainWarn)
+	assert.True(t, ok)
+	assert.Equal(t, plainWarn, text)
+	_, ok = filter.Apply(jsonErr)
+	assert.True(t, ok)
+	_, ok = filter.Apply("not a log line")
+	assert.False(t, ok)
+
+	_, err = ParseLevel("unknown")
+	assert.Error(t, err)
+	level, err = ParseLevel("5")
+	require.NoError(t, err)
+	assert.Equal(t, LevelInfo, level)
+}
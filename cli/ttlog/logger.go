@@ -1,10 +1,10 @@
 package ttlog
 
 import (
+	"fmt"
 	"io"
 	"log"
-
-	"gopkg.in/natefinch/lumberjack.v2"
+	"sync"
 )
 
 // LoggerOpts describes the logger options.
@@ -19,6 +19,33 @@ type LoggerOpts struct {
 	// MaxAge is the maximum number of days to retain old log files
 	// based on the timestamp encoded in their filename.
 	MaxAge int
+	// Compress is a compression of rotated log files: gzip, zstd or
+	// none if empty.
+	Compress string
+	// RotationPeriod enables time-based rotation: hourly or daily.
+	RotationPeriod string
+	// FilenamePattern is a pattern of rotated log file names. {name}, {ext}
+	// and {time} are replaced with the log file name without extension,
+	// its extension and the rotation time. DefaultFilenamePattern is used
+	// if empty.
+	FilenamePattern string
+	// Sink is a destination of logs: file, syslog or journald. The file is
+	// used if empty.
+	Sink string
+	// SyslogFacility is a syslog facility name used by syslog and journald
+	// sinks.
+	SyslogFacility string
+	// Tag is a syslog tag (identifier) used by syslog and journald sinks.
+	Tag string
+	// App is an application name attached to messages by syslog and journald
+	// sinks.
+	App string
+	// Instance is an instance name attached to messages by syslog and journald
+	// sinks.
+	Instance string
+	// Source is a name of the logger owner. It prefixes messages written with
+	// a severity to the file: "Source(SEVERITY): message".
+	Source string
 }
 
 // Logger represents an active logging object.
@@ -28,54 +55,181 @@ type LoggerOpts struct {
 type Logger struct {
 	// Embedded logger, the functionality of which will be extended.
 	*log.Logger
-	// ljLogger is an io.WriteCloser that writes to the specified filename.
+	// output allows to replace the logger destination on reconfiguration.
+	// It is nil for custom loggers.
+	output *switchWriter
+	// mutex protects the logger destination fields below.
+	mutex sync.RWMutex
+	// rotatingFile is an io.WriteCloser that writes to the specified filename.
 	// Used to add logrotate functionality to log.Logger.
-	ljLogger *lumberjack.Logger
+	rotatingFile *rotatingFile
+	// sink sends messages to syslog or journald. It is nil for the file sink.
+	sink *messageSink
+	// fields are the structured fields attached to messages sent to the sink.
+	fields *sinkFields
 	// opts describes the parameters that were used to create the logger.
 	opts *LoggerOpts
 }
 
+// switchWriter is an io.Writer that allows to replace the destination while
+// it is in use, for example, as an output of the instance process.
+type switchWriter struct {
+	// mutex protects writer.
+	mutex sync.RWMutex
+	// writer is the current destination.
+	writer io.Writer
+}
+
+// Write implements io.Writer.
+func (sw *switchWriter) Write(p []byte) (int, error) {
+	sw.mutex.RLock()
+	defer sw.mutex.RUnlock()
+	return sw.writer.Write(p)
+}
+
+// set replaces the destination.
+func (sw *switchWriter) set(writer io.Writer) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+	sw.writer = writer
+}
+
+// setDestination creates the logger destination according to the options.
+func (logger *Logger) setDestination(opts *LoggerOpts) {
+	logger.opts = opts
+	if opts.Sink == SinkSyslog || opts.Sink == SinkJournald {
+		logger.fields = newSinkFields(opts)
+		logger.sink = newMessageSink(opts, logger.fields)
+		logger.rotatingFile = nil
+		logger.output.set(&lineWriter{sink: logger.sink})
+		// Timestamps are added by the logging daemon.
+		logger.SetFlags(0)
+		return
+	}
+
+	logger.rotatingFile = newRotatingFile(opts)
+	logger.sink = nil
+	logger.fields = nil
+	logger.output.set(logger.rotatingFile)
+	logger.SetFlags(log.Flags())
+}
+
 // NewLogger creates a new object of Logger.
 func NewLogger(opts *LoggerOpts) *Logger {
-	ljLogger := &lumberjack.Logger{
-		Filename:   opts.Filename,
-		MaxSize:    opts.MaxSize,
-		MaxBackups: opts.MaxBackups,
-		MaxAge:     opts.MaxAge,
-		Compress:   false,
-		LocalTime:  true,
-	}
-	return &Logger{Logger: log.New(ljLogger, "", log.Flags()), ljLogger: ljLogger, opts: opts}
+	logger := &Logger{output: &switchWriter{}}
+	logger.Logger = log.New(logger.output, "", log.Flags())
+	logger.setDestination(opts)
+	return logger
 }
 
 // NewCustomLogger creates a new logger object with custom `writer`, `prefix`
 // and `flags`. Rotation does not work in this case. Such logger is widely
 // used in tests.
 func NewCustomLogger(writer io.Writer, prefix string, flags int) *Logger {
-	return &Logger{Logger: log.New(writer, "", flags), ljLogger: nil}
+	return &Logger{Logger: log.New(writer, "", flags), rotatingFile: nil}
+}
+
+// Reconfigure applies new options to the logger in place: the writer returned
+// by Writer() stays valid and writes to the new destination.
+func (logger *Logger) Reconfigure(opts *LoggerOpts) error {
+	if logger.output == nil {
+		return fmt.Errorf("a custom logger can't be reconfigured")
+	}
+	logger.mutex.Lock()
+	oldFile, oldSink := logger.rotatingFile, logger.sink
+	pid := 0
+	if logger.fields != nil {
+		pid = logger.fields.getPid()
+	}
+	logger.setDestination(opts)
+	if pid != 0 {
+		logger.fields.setPid(pid)
+	}
+	logger.mutex.Unlock()
+
+	if oldSink != nil {
+		return oldSink.Close()
+	}
+	if oldFile != nil {
+		return oldFile.Close()
+	}
+	return nil
 }
 
 // Rotate causes Logger to close the existing log file and immediately create a
 // new one. After rotating, this initiates compression and removal of old log
 // files according to the configuration.
 func (logger *Logger) Rotate() error {
-	if logger.ljLogger == nil {
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	if logger.rotatingFile == nil {
 		return nil
 	}
 
-	return logger.ljLogger.Rotate()
+	return logger.rotatingFile.Rotate()
+}
+
+// SetPID sets the PID attached to messages sent to syslog or journald. It is
+// used to report the PID of the instance instead of the watchdog one.
+func (logger *Logger) SetPID(pid int) {
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	if logger.fields == nil {
+		return
+	}
+	logger.fields.setPid(pid)
+}
+
+// logWithSeverity sends the message with the severity to syslog or journald, or
+// writes it to the file prefixed with the source and the severity name.
+func (logger *Logger) logWithSeverity(severity Severity, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	if logger.sink != nil {
+		logger.sink.send(severity, msg)
+		return
+	}
+
+	if logger.opts == nil || logger.opts.Source == "" {
+		logger.Printf("%s: %s", severityNames[severity], msg)
+		return
+	}
+	logger.Printf("%s(%s): %s", logger.opts.Source, severityNames[severity], msg)
+}
+
+// Errorf logs the message with the error severity.
+func (logger *Logger) Errorf(format string, v ...interface{}) {
+	logger.logWithSeverity(SeverityErr, format, v...)
+}
+
+// Warnf logs the message with the warning severity.
+func (logger *Logger) Warnf(format string, v ...interface{}) {
+	logger.logWithSeverity(SeverityWarning, format, v...)
+}
+
+// Infof logs the message with the info severity.
+func (logger *Logger) Infof(format string, v ...interface{}) {
+	logger.logWithSeverity(SeverityInfo, format, v...)
 }
 
 // GetOpts returns the parameters that were used to create the logger.
 func (logger *Logger) GetOpts() *LoggerOpts {
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
 	return logger.opts
 }
 
 // Close implements io.Closer, and closes the current logfile.
 func (logger *Logger) Close() error {
-	if logger.ljLogger == nil {
+	logger.mutex.RLock()
+	defer logger.mutex.RUnlock()
+	if logger.sink != nil {
+		return logger.sink.Close()
+	}
+	if logger.rotatingFile == nil {
 		return nil
 	}
 
-	return logger.ljLogger.Close()
+	return logger.rotatingFile.Close()
 }
@@ -1,11 +1,15 @@
 package ttlog
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // cleanupLog clean all log files with the temporary directory.
@@ -50,3 +54,158 @@ func TestLoggerBase(t *testing.T) {
 	files, err = os.ReadDir(dir)
 	assert.Equal(len(files), 2)
 }
+
+func TestLoggerCompressAndRetention(t *testing.T) {
+	for _, tc := range []struct {
+		compress string
+		ext      string
+	}{
+		{CompressGzip, ".gz"},
+		{CompressZstd, ".zst"},
+	} {
+		t.Run(tc.compress, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := LoggerOpts{
+				Filename:        filepath.Join(dir, "inst.log"),
+				MaxBackups:      2,
+				Compress:        tc.compress,
+				FilenamePattern: "{name}.{time}{ext}",
+			}
+			logger := NewLogger(&opts)
+
+			for i := 0; i < 4; i++ {
+				logger.Printf("Test msg %d", i)
+				require.NoError(t, logger.Rotate())
+				// Rotated file names have millisecond precision.
+				time.Sleep(2 * time.Millisecond)
+			}
+			require.NoError(t, logger.Close())
+
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			backups := 0
+			for _, file := range files {
+				if file.Name() == "inst.log" {
+					continue
+				}
+				_, ok := ParseBackupName(opts.Filename, opts.FilenamePattern, file.Name())
+				assert.True(t, ok, file.Name())
+				assert.True(t, strings.HasSuffix(file.Name(), ".log"+tc.ext), file.Name())
+				backups++
+			}
+			assert.Equal(t, 2, backups)
+		})
+	}
+}
+
+func TestLoggerSizeRotationCompress(t *testing.T) {
+	dir := t.TempDir()
+	opts := LoggerOpts{
+		Filename:   filepath.Join(dir, "inst.log"),
+		MaxSize:    1,
+		MaxBackups: 1,
+		Compress:   CompressZstd,
+	}
+	logger := NewLogger(&opts)
+
+	// The log file is rotated by lumberjack twice.
+	line := strings.Repeat("x", 1023)
+	for i := 0; i < 2100; i++ {
+		logger.Writer().Write([]byte(line + "\n"))
+	}
+	require.NoError(t, logger.Close())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	require.Len(t, names, 2, names)
+	assert.Equal(t, "inst.log", names[1])
+	_, ok := ParseBackupName(opts.Filename, "", names[0])
+	assert.True(t, ok, names[0])
+	assert.True(t, strings.HasSuffix(names[0], ".log.zst"), names[0])
+}
+
+func TestLoggerTimeRotation(t *testing.T) {
+	dir := t.TempDir()
+	opts := LoggerOpts{
+		Filename:       filepath.Join(dir, "inst.log"),
+		RotationPeriod: RotateHourly,
+	}
+	logger := NewLogger(&opts)
+	defer logger.Close()
+
+	logger.Printf("first message")
+	// The time of the rotation has come.
+	logger.rotatingFile.mutex.Lock()
+	logger.rotatingFile.nextRotation = time.Now().Add(-time.Second)
+	logger.rotatingFile.mutex.Unlock()
+	logger.Printf("second message")
+
+	data, err := os.ReadFile(opts.Filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "second message")
+	assert.NotContains(t, string(data), "first message")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	_, ok := ParseBackupName(opts.Filename, "", files[0].Name())
+	assert.True(t, ok, files[0].Name())
+	data, err = os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "first message")
+}
+
+func TestNextRotationTime(t *testing.T) {
+	now := time.Date(2023, 6, 20, 23, 15, 30, 0, time.Local)
+	assert.True(t, nextRotationTime(RotateNone, now).IsZero())
+	assert.Equal(t, time.Date(2023, 6, 21, 0, 0, 0, 0, time.Local),
+		nextRotationTime(RotateHourly, now))
+	assert.Equal(t, time.Date(2023, 6, 21, 0, 0, 0, 0, time.Local),
+		nextRotationTime(RotateDaily, now))
+	now = time.Date(2023, 6, 20, 10, 15, 30, 0, time.Local)
+	assert.Equal(t, time.Date(2023, 6, 20, 11, 0, 0, 0, time.Local),
+		nextRotationTime(RotateHourly, now))
+}
+
+func TestValidateRotationOpts(t *testing.T) {
+	assert.NoError(t, ValidateRotationOpts("", "", ""))
+	assert.NoError(t, ValidateRotationOpts(CompressZstd, RotateDaily, "{name}.{time}{ext}"))
+	assert.Error(t, ValidateRotationOpts("bzip2", "", ""))
+	assert.Error(t, ValidateRotationOpts("", "weekly", ""))
+	assert.Error(t, ValidateRotationOpts("", "", "{name}{ext}"))
+	assert.Error(t, ValidateRotationOpts("", "", "old/{name}-{time}{ext}"))
+}
+
+func TestLoggerReconfigure(t *testing.T) {
+	dir := t.TempDir()
+	firstLog := filepath.Join(dir, "first.log")
+	secondLog := filepath.Join(dir, "second.log")
+	logger := NewLogger(&LoggerOpts{Filename: firstLog})
+	defer logger.Close()
+	writer := logger.Writer()
+
+	logger.Printf("first message")
+	require.NoError(t, logger.Reconfigure(&LoggerOpts{Filename: secondLog}))
+	assert.Equal(t, secondLog, logger.GetOpts().Filename)
+	logger.Printf("second message")
+	// The writer obtained before the reconfiguration writes to the new file.
+	writer.Write([]byte("instance output\n"))
+
+	data, err := os.ReadFile(firstLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "first message")
+	assert.NotContains(t, string(data), "second message")
+
+	data, err = os.ReadFile(secondLog)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "second message")
+	assert.Contains(t, string(data), "instance output")
+	assert.NotContains(t, string(data), "first message")
+
+	custom := NewCustomLogger(io.Discard, "", 0)
+	assert.Error(t, custom.Reconfigure(&LoggerOpts{Filename: secondLog}))
+}
@@ -0,0 +1,413 @@
+package ttlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// CompressNone disables compression of rotated log files.
+	CompressNone = ""
+	// CompressGzip enables gzip compression of rotated log files.
+	CompressGzip = "gzip"
+	// CompressZstd enables zstd compression of rotated log files.
+	CompressZstd = "zstd"
+)
+
+const (
+	// RotateNone disables time-based rotation.
+	RotateNone = ""
+	// RotateHourly enables rotation at the beginning of every hour.
+	RotateHourly = "hourly"
+	// RotateDaily enables rotation at the local midnight.
+	RotateDaily = "daily"
+)
+
+const (
+	// DefaultFilenamePattern is a default pattern of rotated log file names.
+	DefaultFilenamePattern = "{name}-{time}{ext}"
+	// backupTimeFormat is a format of the rotation time in rotated log file names.
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	// defaultFilePerms are permissions of the compressed log files.
+	defaultFilePerms = 0644
+)
+
+// compressExts maps compression types to the rotated file name suffixes.
+var compressExts = map[string]string{
+	CompressGzip: ".gz",
+	CompressZstd: ".zst",
+}
+
+// ValidateRotationOpts checks the compression type, the time-based rotation
+// period and the rotated file name pattern.
+func ValidateRotationOpts(compress string, period string, pattern string) error {
+	if _, found := compressExts[compress]; !found && compress != CompressNone {
+		return fmt.Errorf("unknown log compression %q, supported: %s, %s", compress,
+			CompressGzip, CompressZstd)
+	}
+	if period != RotateNone && period != RotateHourly && period != RotateDaily {
+		return fmt.Errorf("unknown log rotation period %q, supported: %s, %s", period,
+			RotateHourly, RotateDaily)
+	}
+	if pattern != "" {
+		if !strings.Contains(pattern, "{time}") {
+			return fmt.Errorf("log file name pattern %q must contain {time}", pattern)
+		}
+		if strings.ContainsRune(pattern, os.PathSeparator) {
+			return fmt.Errorf("log file name pattern %q must not contain a path separator",
+				pattern)
+		}
+	}
+	return nil
+}
+
+// splitLogName returns the log file name without extension and the extension.
+func splitLogName(logFile string) (string, string) {
+	base := filepath.Base(logFile)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext), ext
+}
+
+// expandPattern substitutes the log name, its extension and the time pattern fields.
+func expandPattern(pattern string, logFile string, timeField string) string {
+	if pattern == "" {
+		pattern = DefaultFilenamePattern
+	}
+	name, ext := splitLogName(logFile)
+	return strings.NewReplacer("{name}", name, "{ext}", ext, "{time}", timeField).
+		Replace(pattern)
+}
+
+// backupRegexp returns a regular expression that matches rotated log file names.
+func backupRegexp(logFile string, pattern string) *regexp.Regexp {
+	const timePlaceholder = "\x00"
+	quoted := regexp.QuoteMeta(expandPattern(pattern, logFile, timePlaceholder))
+	quoted = strings.Replace(quoted, timePlaceholder,
+		`(\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3})`, 1)
+	return regexp.MustCompile("^" + quoted + `(\.gz|\.zst)?$`)
+}
+
+// ParseBackupName checks that the file name is a rotated log file name built with
+// the pattern and returns its rotation time.
+func ParseBackupName(logFile string, pattern string, name string) (time.Time, bool) {
+	matches := backupRegexp(logFile, pattern).FindStringSubmatch(name)
+	if matches == nil {
+		return time.Time{}, false
+	}
+	rotationTime, err := time.ParseInLocation(backupTimeFormat, matches[1], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return rotationTime, true
+}
+
+// nextRotationTime returns the time of the next time-based rotation.
+func nextRotationTime(period string, now time.Time) time.Time {
+	switch period {
+	case RotateHourly:
+		return time.Date(now.Year(), now.Month(), now.Day(), now.Hour()+1, 0, 0, 0,
+			now.Location())
+	case RotateDaily:
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// rotatingFile is an io.WriteCloser that writes to the log file using lumberjack,
+// which rotates it by size. It adds the time-based rotation and, if the zstd
+// compression or a custom file name pattern is used, renames, compresses and
+// removes the rotated files instead of lumberjack in background.
+type rotatingFile struct {
+	// opts describes the rotation parameters.
+	opts *LoggerOpts
+	// ljLogger writes to the log file and rotates it.
+	ljLogger *lumberjack.Logger
+	// milling is true if the rotated files are processed by rotatingFile.
+	milling bool
+	// mutex serializes writes and rotations.
+	mutex sync.Mutex
+	// logFile describes the log file written by lumberjack. It is used to detect
+	// the size-based rotation performed by lumberjack if milling is true.
+	logFile os.FileInfo
+	// written is true if the log file has been written since the last rotation.
+	written bool
+	// nextRotation is the time of the next time-based rotation.
+	nextRotation time.Time
+	// rotationTimer performs the time-based rotation if the log is not written.
+	rotationTimer *time.Timer
+	// millMutex serializes post-rotation processing.
+	millMutex sync.Mutex
+	// millWait is used to wait for the post-rotation processing on close.
+	millWait sync.WaitGroup
+}
+
+// newRotatingFile creates a new rotating log file writer.
+func newRotatingFile(opts *LoggerOpts) *rotatingFile {
+	rf := &rotatingFile{opts: opts}
+	rf.milling = opts.Compress == CompressZstd ||
+		(opts.FilenamePattern != "" && opts.FilenamePattern != DefaultFilenamePattern)
+	rf.ljLogger = &lumberjack.Logger{
+		Filename:  opts.Filename,
+		MaxSize:   opts.MaxSize,
+		LocalTime: true,
+	}
+	if !rf.milling {
+		// Lumberjack names the rotated files with the default pattern and
+		// supports gzip compression only.
+		rf.ljLogger.MaxBackups = opts.MaxBackups
+		rf.ljLogger.MaxAge = opts.MaxAge
+		rf.ljLogger.Compress = opts.Compress == CompressGzip
+	}
+	return rf
+}
+
+// Write implements io.Writer.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	now := time.Now()
+	if rf.nextRotation.IsZero() {
+		rf.scheduleRotation(now)
+	} else if !now.Before(rf.nextRotation) && rf.written {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	if rf.milling && rf.logFile == nil {
+		rf.logFileReplaced()
+	}
+	n, err := rf.ljLogger.Write(p)
+	rf.written = rf.written || n > 0
+	if rf.milling && rf.logFileReplaced() {
+		// Lumberjack has rotated the log file by size.
+		rf.startMill()
+	}
+	return n, err
+}
+
+// logFileReplaced reports whether the log file is not the one seen last time,
+// i.e. lumberjack has moved it away and created a new one.
+func (rf *rotatingFile) logFileReplaced() bool {
+	info, err := os.Stat(rf.opts.Filename)
+	if err != nil {
+		return false
+	}
+	replaced := rf.logFile != nil && !os.SameFile(rf.logFile, info)
+	rf.logFile = info
+	return replaced
+}
+
+// scheduleRotation sets the time of the next time-based rotation and starts the
+// timer that performs it.
+func (rf *rotatingFile) scheduleRotation(now time.Time) {
+	rf.nextRotation = nextRotationTime(rf.opts.RotationPeriod, now)
+	if rf.nextRotation.IsZero() {
+		return
+	}
+	if rf.rotationTimer != nil {
+		rf.rotationTimer.Stop()
+	}
+	rf.rotationTimer = time.AfterFunc(rf.nextRotation.Sub(now), func() {
+		rf.mutex.Lock()
+		defer rf.mutex.Unlock()
+		if rf.rotationTimer == nil {
+			// The file is closed.
+			return
+		}
+		if !rf.written {
+			// Nothing has been written since the last rotation.
+			rf.scheduleRotation(time.Now())
+			return
+		}
+		rf.rotate()
+	})
+}
+
+// rotate rotates the log file with lumberjack and starts the post-rotation
+// processing.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.ljLogger.Rotate(); err != nil {
+		return err
+	}
+	if rf.milling {
+		rf.logFileReplaced()
+	}
+	rf.written = false
+	if !rf.nextRotation.IsZero() {
+		rf.scheduleRotation(time.Now())
+	}
+	rf.startMill()
+	return nil
+}
+
+// startMill processes the rotated files in background if lumberjack does not.
+func (rf *rotatingFile) startMill() {
+	if !rf.milling {
+		return
+	}
+	rf.millWait.Add(1)
+	go func() {
+		defer rf.millWait.Done()
+		rf.mill(time.Now())
+	}()
+}
+
+// Rotate closes the current log file, renames it and opens a new one.
+func (rf *rotatingFile) Rotate() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+	return rf.rotate()
+}
+
+// Close implements io.Closer. It waits for the post-rotation processing to finish.
+func (rf *rotatingFile) Close() error {
+	rf.mutex.Lock()
+	if rf.rotationTimer != nil {
+		rf.rotationTimer.Stop()
+		rf.rotationTimer = nil
+	}
+	err := rf.ljLogger.Close()
+	rf.mutex.Unlock()
+
+	rf.millWait.Wait()
+	return err
+}
+
+// backupFile describes a rotated log file.
+type backupFile struct {
+	// path is a path to the file.
+	path string
+	// rotationTime is a time of the rotation encoded in the file name.
+	rotationTime time.Time
+}
+
+// collectBackups returns rotated log files sorted from the newest to the oldest.
+func (rf *rotatingFile) collectBackups() ([]backupFile, error) {
+	dir := filepath.Dir(rf.opts.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []backupFile{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		rotationTime, ok := ParseBackupName(rf.opts.Filename, rf.opts.FilenamePattern,
+			entry.Name())
+		if ok {
+			backups = append(backups, backupFile{filepath.Join(dir, entry.Name()),
+				rotationTime})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotationTime.After(backups[j].rotationTime)
+	})
+	return backups, nil
+}
+
+// renameBackups renames the files rotated by lumberjack according to the file
+// name pattern.
+func (rf *rotatingFile) renameBackups() {
+	if rf.opts.FilenamePattern == "" || rf.opts.FilenamePattern == DefaultFilenamePattern {
+		return
+	}
+	dir := filepath.Dir(rf.opts.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		rotationTime, ok := ParseBackupName(rf.opts.Filename, DefaultFilenamePattern,
+			entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+		backupName := expandPattern(rf.opts.FilenamePattern, rf.opts.Filename,
+			rotationTime.Format(backupTimeFormat))
+		for _, ext := range compressExts {
+			if strings.HasSuffix(entry.Name(), ext) {
+				backupName += ext
+			}
+		}
+		os.Rename(filepath.Join(dir, entry.Name()), filepath.Join(dir, backupName))
+	}
+}
+
+// mill compresses rotated log files and removes the old ones.
+func (rf *rotatingFile) mill(now time.Time) {
+	rf.millMutex.Lock()
+	defer rf.millMutex.Unlock()
+
+	rf.renameBackups()
+	backups, err := rf.collectBackups()
+	if err != nil {
+		return
+	}
+
+	cutoff := now.Add(-time.Duration(rf.opts.MaxAge) * 24 * time.Hour)
+	for i, backup := range backups {
+		if (rf.opts.MaxBackups > 0 && i >= rf.opts.MaxBackups) ||
+			(rf.opts.MaxAge > 0 && backup.rotationTime.Before(cutoff)) {
+			os.Remove(backup.path)
+			continue
+		}
+		compressExt, found := compressExts[rf.opts.Compress]
+		if found && !strings.HasSuffix(backup.path, ".gz") &&
+			!strings.HasSuffix(backup.path, ".zst") {
+			compressFile(backup.path, backup.path+compressExt, rf.opts.Compress)
+		}
+	}
+}
+
+// compressFile compresses the source file into the destination file and removes
+// the source.
+func compressFile(src string, dst string, compress string) (err error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, defaultFilePerms)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		dstFile.Close()
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+
+	var writer io.WriteCloser
+	if compress == CompressZstd {
+		if writer, err = zstd.NewWriter(dstFile); err != nil {
+			return err
+		}
+	} else {
+		writer = gzip.NewWriter(dstFile)
+	}
+	if _, err = io.Copy(writer, srcFile); err != nil {
+		writer.Close()
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
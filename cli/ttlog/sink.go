@@ -0,0 +1,309 @@
+package ttlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tarantool/tt/cli/tail"
+)
+
+const (
+	// SinkFile writes logs to a file. It is the default sink.
+	SinkFile = "file"
+	// SinkSyslog sends logs to the local syslog daemon.
+	SinkSyslog = "syslog"
+	// SinkJournald sends logs to the systemd journal.
+	SinkJournald = "journald"
+)
+
+// Severity is a syslog message severity.
+type Severity int
+
+const (
+	SeverityCrit    Severity = 2
+	SeverityErr     Severity = 3
+	SeverityWarning Severity = 4
+	SeverityInfo    Severity = 6
+	SeverityDebug   Severity = 7
+)
+
+// severityNames contains severity names used in messages written to a file.
+var severityNames = map[Severity]string{
+	SeverityCrit:    "CRIT",
+	SeverityErr:     "ERROR",
+	SeverityWarning: "WARN",
+	SeverityInfo:    "INFO",
+	SeverityDebug:   "DEBUG",
+}
+
+// tarantoolSeverities maps tarantool log levels to syslog severities.
+var tarantoolSeverities = map[tail.Level]Severity{
+	tail.LevelFatal:    SeverityCrit,
+	tail.LevelSysError: SeverityErr,
+	tail.LevelError:    SeverityErr,
+	tail.LevelCrit:     SeverityCrit,
+	tail.LevelWarn:     SeverityWarning,
+	tail.LevelInfo:     SeverityInfo,
+	tail.LevelVerbose:  SeverityDebug,
+	tail.LevelDebug:    SeverityDebug,
+}
+
+// syslogFacilities maps syslog facility names to their codes.
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	// defaultSyslogFacility is used if the facility is not set.
+	defaultSyslogFacility = "user"
+	// defaultTag is used as a syslog tag if it is not set.
+	defaultTag = "tarantool"
+)
+
+// syslogAddrs are the local syslog socket paths.
+var syslogAddrs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// journaldAddrs are the systemd journal socket paths.
+var journaldAddrs = []string{"/run/systemd/journal/socket"}
+
+// ValidateSinkOpts checks the log sink and the syslog facility.
+func ValidateSinkOpts(sink string, facility string) error {
+	if sink != "" && sink != SinkFile && sink != SinkSyslog && sink != SinkJournald {
+		return fmt.Errorf("unknown log sink %q, supported: %s, %s, %s", sink, SinkFile,
+			SinkSyslog, SinkJournald)
+	}
+	if _, found := syslogFacilities[facility]; facility != "" && !found {
+		return fmt.Errorf("unknown syslog facility %q", facility)
+	}
+	return nil
+}
+
+// messageSink sends messages with severity to a logging daemon.
+type messageSink struct {
+	// addrs are the socket paths to try.
+	addrs []string
+	// format builds a datagram from the message.
+	format func(severity Severity, msg string) []byte
+	// mutex serializes access to the connection.
+	mutex sync.Mutex
+	// conn is a connection to the logging daemon. It is nil until the first
+	// message is sent.
+	conn net.Conn
+}
+
+// connect connects to the first available socket.
+func (sink *messageSink) connect() error {
+	var err error
+	for _, addr := range sink.addrs {
+		for _, network := range []string{"unixgram", "unix"} {
+			if sink.conn, err = net.Dial(network, addr); err == nil {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("can't connect to %s: %s", strings.Join(sink.addrs, ", "), err)
+}
+
+// send sends the message. It reconnects once if the connection is broken.
+func (sink *messageSink) send(severity Severity, msg string) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	data := sink.format(severity, msg)
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if sink.conn == nil {
+			if err = sink.connect(); err != nil {
+				return err
+			}
+		}
+		if _, err = sink.conn.Write(data); err == nil {
+			return nil
+		}
+		sink.conn.Close()
+		sink.conn = nil
+	}
+	return err
+}
+
+// Close closes the connection.
+func (sink *messageSink) Close() error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if sink.conn == nil {
+		return nil
+	}
+	err := sink.conn.Close()
+	sink.conn = nil
+	return err
+}
+
+// sinkFields describes the structured fields attached to each message.
+type sinkFields struct {
+	// tag is a syslog tag (identifier).
+	tag string
+	// facility is a syslog facility code.
+	facility int
+	// app is an application name.
+	app string
+	// instance is an instance name.
+	instance string
+	// pidMutex protects pid.
+	pidMutex sync.Mutex
+	// pid is a PID of the instance process or of the current process if the
+	// instance is not started.
+	pid int
+}
+
+// getPid returns the PID to attach to messages.
+func (fields *sinkFields) getPid() int {
+	fields.pidMutex.Lock()
+	defer fields.pidMutex.Unlock()
+	return fields.pid
+}
+
+// setPid sets the PID to attach to messages.
+func (fields *sinkFields) setPid(pid int) {
+	fields.pidMutex.Lock()
+	defer fields.pidMutex.Unlock()
+	fields.pid = pid
+}
+
+// formatSyslog builds a message for the local syslog socket:
+// <PRI>Mmm dd hh:mm:ss TAG[PID]: [app="APP" instance="INSTANCE"] MSG.
+func (fields *sinkFields) formatSyslog(severity Severity, msg string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>%s %s[%d]: ", fields.facility*8+int(severity),
+		time.Now().Format(time.Stamp), fields.tag, fields.getPid())
+	if fields.app != "" {
+		fmt.Fprintf(&buf, "[app=%q", fields.app)
+		if fields.instance != "" {
+			fmt.Fprintf(&buf, " instance=%q", fields.instance)
+		}
+		buf.WriteString("] ")
+	}
+	buf.WriteString(msg)
+	return buf.Bytes()
+}
+
+// writeJournaldField writes a field in the journal native protocol format.
+func writeJournaldField(buf *bytes.Buffer, name string, value string) {
+	if !strings.ContainsRune(value, '\n') {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	// Multi-line values are written as: NAME\n<64-bit LE size><value>\n.
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// formatJournald builds a message in the journal native protocol format.
+func (fields *sinkFields) formatJournald(severity Severity, msg string) []byte {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", msg)
+	writeJournaldField(&buf, "PRIORITY", strconv.Itoa(int(severity)))
+	writeJournaldField(&buf, "SYSLOG_FACILITY", strconv.Itoa(fields.facility))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", fields.tag)
+	writeJournaldField(&buf, "SYSLOG_PID", strconv.Itoa(fields.getPid()))
+	if fields.app != "" {
+		writeJournaldField(&buf, "TT_APP", fields.app)
+	}
+	if fields.instance != "" {
+		writeJournaldField(&buf, "TT_INSTANCE", fields.instance)
+	}
+	return buf.Bytes()
+}
+
+// newMessageSink creates a sink for the syslog or journald.
+func newMessageSink(opts *LoggerOpts, fields *sinkFields) *messageSink {
+	if opts.Sink == SinkJournald {
+		return &messageSink{addrs: journaldAddrs, format: fields.formatJournald}
+	}
+	return &messageSink{addrs: syslogAddrs, format: fields.formatSyslog}
+}
+
+// newSinkFields creates the message fields from the logger options.
+func newSinkFields(opts *LoggerOpts) *sinkFields {
+	facility := opts.SyslogFacility
+	if facility == "" {
+		facility = defaultSyslogFacility
+	}
+	tag := opts.Tag
+	if tag == "" {
+		tag = defaultTag
+	}
+	return &sinkFields{
+		tag:      tag,
+		facility: syslogFacilities[facility],
+		app:      opts.App,
+		instance: opts.Instance,
+		pid:      os.Getpid(),
+	}
+}
+
+// lineWriter splits the written data into lines and sends each line to the sink
+// with the severity of the tarantool log level found in the line.
+type lineWriter struct {
+	// sink is a destination of the lines.
+	sink *messageSink
+	// mutex protects partial.
+	mutex sync.Mutex
+	// partial is an incomplete last line.
+	partial []byte
+}
+
+// Write implements io.Writer.
+func (writer *lineWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.partial = append(writer.partial, p...)
+	for {
+		pos := bytes.IndexByte(writer.partial, '\n')
+		if pos == -1 {
+			break
+		}
+		line := string(writer.partial[:pos])
+		writer.partial = writer.partial[pos+1:]
+		if line == "" {
+			continue
+		}
+		severity := SeverityInfo
+		if level, _ := tail.ParseLine(line); level != tail.LevelNone {
+			severity = tarantoolSeverities[level]
+		}
+		// Delivery errors are ignored: the instance output must not be blocked
+		// if the logging daemon is not available.
+		writer.sink.send(severity, line)
+	}
+	return len(p), nil
+}
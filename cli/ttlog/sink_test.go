@@ -0,0 +1,152 @@
+package ttlog
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenUnixgram starts a datagram socket server and returns a channel of the
+// received messages.
+func listenUnixgram(t *testing.T) (string, chan string) {
+	sockPath := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sockPath, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	messages := make(chan string, 10)
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			messages <- string(buf[:n])
+		}
+	}()
+	return sockPath, messages
+}
+
+func receive(t *testing.T, messages chan string) string {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no message received")
+	}
+	return ""
+}
+
+func TestSyslogSink(t *testing.T) {
+	sockPath, messages := listenUnixgram(t)
+	savedAddrs := syslogAddrs
+	syslogAddrs = []string{sockPath}
+	t.Cleanup(func() { syslogAddrs = savedAddrs })
+
+	logger := NewLogger(&LoggerOpts{
+		Sink:           SinkSyslog,
+		SyslogFacility: "local0",
+		Tag:            "myapp",
+		App:            "app",
+		Instance:       "inst",
+		Source:         "Watchdog",
+	})
+	defer logger.Close()
+	logger.SetPID(4242)
+
+	logger.Errorf("can't start: %s", "reason")
+	msg := receive(t, messages)
+	// local0 (16) * 8 + err (3) = 131.
+	assert.True(t, strings.HasPrefix(msg, "<131>"), msg)
+	assert.Contains(t, msg, ` myapp[4242]: [app="app" instance="inst"] can't start: reason`)
+
+	// Instance output is split into lines with the severity of tarantool level.
+	logger.Writer().Write([]byte("2023-06-20 12:00:00.000 [1] main W> slow\nplain"))
+	msg = receive(t, messages)
+	assert.True(t, strings.HasPrefix(msg, "<132>"), msg)
+	assert.True(t, strings.HasSuffix(msg, "main W> slow"), msg)
+	logger.Writer().Write([]byte(" line\n"))
+	msg = receive(t, messages)
+	assert.True(t, strings.HasPrefix(msg, "<134>"), msg)
+	assert.True(t, strings.HasSuffix(msg, "] plain line"), msg)
+}
+
+func TestSyslogSinkReconfigure(t *testing.T) {
+	sockPath, messages := listenUnixgram(t)
+	savedAddrs := syslogAddrs
+	syslogAddrs = []string{sockPath}
+	t.Cleanup(func() { syslogAddrs = savedAddrs })
+
+	logger := NewLogger(&LoggerOpts{Sink: SinkSyslog, Tag: "first"})
+	defer logger.Close()
+	logger.SetPID(4242)
+
+	// The instance output is written concurrently with the reconfiguration.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			logger.Writer().Write([]byte("output\n"))
+		}
+	}()
+	require.NoError(t, logger.Reconfigure(&LoggerOpts{Sink: SinkSyslog, Tag: "second"}))
+	<-done
+	for i := 0; i < 5; i++ {
+		assert.Contains(t, receive(t, messages), "[4242]: output")
+	}
+
+	// The PID of the instance is kept after the reconfiguration.
+	logger.Infof("reconfigured")
+	assert.Contains(t, receive(t, messages), " second[4242]: reconfigured")
+}
+
+func TestJournaldSink(t *testing.T) {
+	sockPath, messages := listenUnixgram(t)
+	savedAddrs := journaldAddrs
+	journaldAddrs = []string{sockPath}
+	t.Cleanup(func() { journaldAddrs = savedAddrs })
+
+	logger := NewLogger(&LoggerOpts{
+		Sink:     SinkJournald,
+		App:      "app",
+		Instance: "inst",
+	})
+	defer logger.Close()
+
+	logger.Warnf("multi\nline")
+	msg := receive(t, messages)
+	assert.Contains(t, msg, "MESSAGE\n\x0a\x00\x00\x00\x00\x00\x00\x00multi\nline\n")
+	assert.Contains(t, msg, "PRIORITY=4\n")
+	assert.Contains(t, msg, "SYSLOG_FACILITY=1\n")
+	assert.Contains(t, msg, "SYSLOG_IDENTIFIER=tarantool\n")
+	assert.Contains(t, msg, "TT_APP=app\n")
+	assert.Contains(t, msg, "TT_INSTANCE=inst\n")
+	assert.Contains(t, msg, "SYSLOG_PID=")
+}
+
+func TestFileSinkSeverity(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "inst.log")
+	logger := NewLogger(&LoggerOpts{Filename: logPath, Source: "Watchdog"})
+	logger.Errorf(`"%v".`, "failure")
+	logger.Infof("the Instance has shutdown.")
+	require.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `Watchdog(ERROR): "failure".`)
+	assert.Contains(t, string(content), `Watchdog(INFO): the Instance has shutdown.`)
+}
+
+func TestValidateSinkOpts(t *testing.T) {
+	assert.NoError(t, ValidateSinkOpts("", ""))
+	assert.NoError(t, ValidateSinkOpts(SinkJournald, "daemon"))
+	assert.Error(t, ValidateSinkOpts("kafka", ""))
+	assert.Error(t, ValidateSinkOpts(SinkSyslog, "local9"))
+}
@@ -2,16 +2,153 @@ package util
 
 import (
 	"archive/tar"
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+const (
+	// CompressionGzip is the gzip compression of archives.
+	CompressionGzip = "gzip"
+	// CompressionXz is the xz compression of archives.
+	CompressionXz = "xz"
+	// CompressionZstd is the zstd compression of archives.
+	CompressionZstd = "zstd"
+)
+
+// CompressionOpts describes the compression of archives.
+type CompressionOpts struct {
+	// Type is the compression type: gzip, xz or zstd. gzip is used if empty.
+	Type string
+	// Level is the compression level. The default level of the compression
+	// type is used if zero.
+	Level int
+}
+
+var (
+	// compressionExts maps compression types to the archive name suffixes.
+	compressionExts = map[string]string{
+		CompressionGzip: ".gz",
+		CompressionXz:   ".xz",
+		CompressionZstd: ".zst",
+	}
+	// compressionLevels maps compression types to the ranges of the levels.
+	compressionLevels = map[string][2]int{
+		CompressionGzip: {gzip.BestSpeed, gzip.BestCompression},
+		CompressionXz:   {1, len(xzDictCaps) - 1},
+		CompressionZstd: {1, 22},
+	}
+	// compressionMagics maps compression types to the magic numbers of the
+	// compressed streams.
+	compressionMagics = map[string][]byte{
+		CompressionGzip: {0x1f, 0x8b},
+		CompressionXz:   {0xfd, '7', 'z', 'X', 'Z', 0x00},
+		CompressionZstd: {0x28, 0xb5, 0x2f, 0xfd},
+	}
+	// xzDictCaps are the dictionary sizes of the xz compression levels, as in
+	// the xz utility presets. Level 0 is not used.
+	xzDictCaps = []int{256 << 10, 1 << 20, 2 << 20, 4 << 20, 4 << 20, 8 << 20, 8 << 20,
+		16 << 20, 32 << 20, 64 << 20}
 )
 
-// makeTarGzReader makes reader for tar.gz file.
+// GetType returns the compression type, gzip if it is not set.
+func (opts CompressionOpts) GetType() string {
+	if opts.Type == "" {
+		return CompressionGzip
+	}
+	return opts.Type
+}
+
+// Ext returns the suffix of the compressed archive name: .gz, .xz or .zst.
+func (opts CompressionOpts) Ext() string {
+	return compressionExts[opts.GetType()]
+}
+
+// ValidateCompression checks the compression type and level.
+func ValidateCompression(opts CompressionOpts) error {
+	levels, found := compressionLevels[opts.GetType()]
+	if !found {
+		return fmt.Errorf("unknown compression %q, supported: %s, %s, %s", opts.Type,
+			CompressionGzip, CompressionXz, CompressionZstd)
+	}
+	if opts.Level != 0 && (opts.Level < levels[0] || opts.Level > levels[1]) {
+		return fmt.Errorf("%s compression level must be in range [%d, %d], got %d",
+			opts.GetType(), levels[0], levels[1], opts.Level)
+	}
+	return nil
+}
+
+// NewCompressWriter returns the writer that compresses the data written to
+// the passed writer. The returned writer must be closed to flush the data.
+func NewCompressWriter(writer io.Writer, opts CompressionOpts) (io.WriteCloser, error) {
+	if err := ValidateCompression(opts); err != nil {
+		return nil, err
+	}
+	switch opts.GetType() {
+	case CompressionXz:
+		config := xz.WriterConfig{}
+		if opts.Level != 0 {
+			config.DictCap = xzDictCaps[opts.Level]
+		}
+		return config.NewWriter(writer)
+	case CompressionZstd:
+		options := []zstd.EOption{}
+		if opts.Level != 0 {
+			options = append(options,
+				zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.Level)))
+		}
+		return zstd.NewWriter(writer, options...)
+	}
+	if opts.Level != 0 {
+		return gzip.NewWriterLevel(writer, opts.Level)
+	}
+	return gzip.NewWriter(writer), nil
+}
+
+// DetectCompression returns the compression type of the stream by its
+// magic number. An empty string is returned for unknown streams.
+func DetectCompression(header []byte) string {
+	for compression, magic := range compressionMagics {
+		if bytes.HasPrefix(header, magic) {
+			return compression
+		}
+	}
+	return ""
+}
+
+// NewDecompressReader returns the reader that decompresses the gzip, xz or
+// zstd stream. The compression is detected by the magic number.
+func NewDecompressReader(reader io.Reader) (io.ReadCloser, error) {
+	bufReader := bufio.NewReader(reader)
+	// Errors are checked by the decompressor reading the header.
+	header, _ := bufReader.Peek(len(compressionMagics[CompressionXz]))
+	switch DetectCompression(header) {
+	case CompressionXz:
+		xzReader, err := xz.NewReader(bufReader)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xzReader), nil
+	case CompressionZstd:
+		zstdReader, err := zstd.NewReader(bufReader)
+		if err != nil {
+			return nil, err
+		}
+		return zstdReader.IOReadCloser(), nil
+	}
+	return gzip.NewReader(bufReader)
+}
+
+// makeTarGzReader makes reader for compressed tar file.
 func makeTarGzReader(archive *os.File) (*tar.Reader, error) {
-	uncompressedStream, err := gzip.NewReader(archive)
+	uncompressedStream, err := NewDecompressReader(archive)
 	if err != nil {
 		return nil, err
 	}
@@ -20,7 +157,8 @@ func makeTarGzReader(archive *os.File) (*tar.Reader, error) {
 	return tarReader, err
 }
 
-// ExtractTarGz extracts tar.gz archive.
+// ExtractTarGz extracts tar.gz archive. The tar.xz and tar.zst archives are
+// supported too.
 func ExtractTarGz(tarName string, dstDir string) error {
 	archive, err := os.Open(tarName)
 	if err != nil {
@@ -1,6 +1,9 @@
 package util
 
 import (
+	"archive/tar"
+	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -36,3 +39,94 @@ func TestExtractTgzErrors(t *testing.T) {
 		os.FileMode(0664)))
 	require.Error(t, ExtractTarGz(filepath.Join(tempDir, "text_file.tgz"), tempDir))
 }
+
+func TestValidateCompression(t *testing.T) {
+	testCases := []struct {
+		opts        CompressionOpts
+		expectedErr string
+	}{
+		{CompressionOpts{}, ""},
+		{CompressionOpts{Type: CompressionGzip, Level: 9}, ""},
+		{CompressionOpts{Type: CompressionXz, Level: 1}, ""},
+		{CompressionOpts{Type: CompressionZstd, Level: 22}, ""},
+		{CompressionOpts{Type: "bzip2"},
+			`unknown compression "bzip2", supported: gzip, xz, zstd`},
+		{CompressionOpts{Type: CompressionGzip, Level: 10},
+			"gzip compression level must be in range [1, 9], got 10"},
+		{CompressionOpts{Type: CompressionZstd, Level: -1},
+			"zstd compression level must be in range [1, 22], got -1"},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateCompression(tc.opts)
+		if tc.expectedErr == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tc.expectedErr)
+		}
+	}
+}
+
+// compressData compresses the data with the passed options.
+func compressData(t *testing.T, data []byte, opts CompressionOpts) []byte {
+	buf := bytes.Buffer{}
+	writer, err := NewCompressWriter(&buf, opts)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestCompressDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte("tarantool "), 100000)
+	for _, opts := range []CompressionOpts{
+		{},
+		{Type: CompressionGzip, Level: 1},
+		{Type: CompressionXz},
+		{Type: CompressionXz, Level: 9},
+		{Type: CompressionZstd},
+		{Type: CompressionZstd, Level: 19},
+	} {
+		compressed := compressData(t, data, opts)
+		assert.Less(t, len(compressed), len(data))
+		assert.Equal(t, opts.GetType(), DetectCompression(compressed))
+		// The compression is deterministic for the reproducible packages.
+		assert.Equal(t, compressed, compressData(t, data, opts))
+
+		reader, err := NewDecompressReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, data, decompressed)
+	}
+
+	assert.Equal(t, "", DetectCompression([]byte("text")))
+	_, err := NewCompressWriter(io.Discard, CompressionOpts{Type: "bzip2"})
+	assert.Error(t, err)
+}
+
+func TestExtractCompressedTar(t *testing.T) {
+	for _, compression := range []string{CompressionXz, CompressionZstd} {
+		opts := CompressionOpts{Type: compression}
+		tempDir := t.TempDir()
+		archivePath := filepath.Join(tempDir, "arch.tar"+opts.Ext())
+
+		buf := bytes.Buffer{}
+		tarWriter := tar.NewWriter(&buf)
+		content := []byte("return {}\n")
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "app/init.lua",
+			Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tarWriter.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, os.WriteFile(archivePath, compressData(t, buf.Bytes(), opts),
+			0644))
+
+		require.NoError(t, ExtractTarGz(archivePath, tempDir))
+		extracted, err := os.ReadFile(filepath.Join(tempDir, "app", "init.lua"))
+		require.NoError(t, err)
+		assert.Equal(t, content, extracted)
+	}
+}
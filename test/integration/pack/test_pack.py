@@ -1,4 +1,6 @@
 import glob
+import hashlib
+import json
 import os
 import re
 import shutil
@@ -770,6 +772,298 @@ def test_pack_tgz_links_to_binaries(tt_cmd, tmpdir):
     os.remove(package_file)
 
 
+@pytest.mark.slow
+@pytest.mark.parametrize("pack_type,suffix", [
+    ("tgz", ".tar.gz"),
+    ("deb", ".deb"),
+    ("rpm", ".rpm"),
+    ("selfextract", ".run"),
+])
+def test_pack_reproducible(tt_cmd, tmpdir, pack_type, suffix):
+    if pack_type == "deb" and shutil.which("ar") is None:
+        pytest.skip("ar is not installed in this system")
+
+    checksums = []
+    for i in range(2):
+        build_dir = os.path.join(tmpdir, f"build{i}")
+        shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                        build_dir, symlinks=True, ignore=None,
+                        copy_function=shutil.copy, ignore_dangling_symlinks=True)
+        base_dir = os.path.join(build_dir, "bundle4")
+        # Modification times of the sources must not affect the package.
+        for root, _, files in os.walk(base_dir):
+            for name in files:
+                path = os.path.join(root, name)
+                if not os.path.islink(path):
+                    os.utime(path, (i * 3600, i * 3600))
+
+        rc, output = run_command_and_get_output(
+            [tt_cmd, "pack", pack_type, "--reproducible"],
+            cwd=base_dir, env=dict(os.environ, PWD=base_dir, SOURCE_DATE_EPOCH="1700000000"))
+        assert rc == 0, output
+
+        packages = glob.glob(os.path.join(base_dir, "bundle4*" + suffix))
+        assert len(packages) == 1
+        with open(packages[0], "rb") as package:
+            checksums.append(hashlib.sha256(package.read()).hexdigest())
+
+    assert checksums[0] == checksums[1]
+
+
+def test_pack_selfextract(tt_cmd, tmpdir):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "selfextract", "--version", "1.0.0"],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    installer = os.path.join(base_dir, "bundle1-1.0.0." + get_arch() + ".run")
+    assert os.access(installer, os.X_OK)
+
+    prefix = os.path.join(tmpdir, "install")
+    units_dir = os.path.join(tmpdir, "units")
+    rc, output = run_command_and_get_output(
+        [installer, "--prefix", prefix, "--with-systemd", "--systemd-dir", units_dir])
+    assert rc == 0, output
+    assert os.path.isfile(os.path.join(prefix, "tt.yaml"))
+    assert os.path.isdir(os.path.join(prefix, "var", "lib"))
+    with open(os.path.join(units_dir, "bundle1.service")) as unit:
+        assert f"-L {prefix} start --foreground" in unit.read()
+
+    data_file = os.path.join(prefix, "var", "lib", "data.snap")
+    with open(data_file, "w") as data:
+        data.write("data")
+    rc, output = run_command_and_get_output([installer, "--prefix", prefix, "--upgrade"])
+    assert rc == 0, output
+    assert os.path.isfile(data_file)
+
+    rc, output = run_command_and_get_output(
+        [installer, "--prefix", prefix, "--uninstall", "--purge",
+         "--with-systemd", "--systemd-dir", units_dir])
+    assert rc == 0, output
+    assert not os.path.exists(prefix)
+    assert not os.path.exists(os.path.join(units_dir, "bundle1.service"))
+
+
+def test_pack_dry_run_exclude(tt_cmd, tmpdir):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+    app_dir = os.path.join(base_dir, "app2")
+    os.makedirs(os.path.join(app_dir, "test", "fixtures"))
+    with open(os.path.join(app_dir, "test", "fixtures", "data.snap"), "w") as f:
+        f.write("data")
+    with open(os.path.join(app_dir, "local.snap"), "w") as f:
+        f.write("data")
+    with open(os.path.join(app_dir, ".packignore"), "w") as f:
+        f.write("*.snap\n")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "tgz", "--dry-run", "--exclude", "test/"],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    assert "app2/init.lua" in output
+    assert "app2/app2-scm-1.rockspec" in output
+    assert "app2/local.snap" not in output
+    assert "app2/.packignore" not in output
+    assert "app2/test" not in output
+    assert len(glob.glob(os.path.join(base_dir, "*.tar.gz"))) == 0
+
+
+@pytest.mark.parametrize("sbom_format,suffix", [
+    ("cyclonedx", ".cdx.json"),
+    ("spdx", ".spdx.json"),
+])
+def test_pack_sbom(tt_cmd, tmpdir, sbom_format, suffix):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "tgz", "--sbom", sbom_format],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+
+    package = os.path.join(base_dir, "bundle1-0.1.0.0." + get_arch() + ".tar.gz")
+    with open(package + suffix) as sbom_file:
+        sbom = json.load(sbom_file)
+    with tarfile.open(package) as tar:
+        embedded = json.load(tar.extractfile("sbom" + suffix))
+    assert sbom == embedded
+
+    if sbom_format == "cyclonedx":
+        assert sbom["bomFormat"] == "CycloneDX"
+        names = [component["name"] for component in sbom["components"]]
+    else:
+        assert sbom["spdxVersion"] == "SPDX-2.3"
+        names = [pkg["name"] for pkg in sbom["packages"]]
+    assert "app1" in names
+    assert "app2" in names
+
+
+@pytest.mark.parametrize("pack_type, suffix", [
+    ("tgz", ".tar.gz"),
+    ("deb", ".deb"),
+])
+def test_pack_inspect(tt_cmd, tmpdir, pack_type, suffix):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", pack_type],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+
+    packages = [name for name in os.listdir(base_dir) if name.endswith(suffix)]
+    assert len(packages) == 1
+    package = os.path.join(base_dir, packages[0])
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "inspect", "--verify", package],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    assert "Format: " + pack_type in output
+    assert "app1/init.lua" in output
+    assert "Verification:" in output
+    assert "failed" not in output
+
+    # Corrupt the end of the package.
+    with open(package, "r+b") as package_file:
+        package_file.seek(-4, os.SEEK_END)
+        package_file.write(b"\0\0\0\0")
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "inspect", "--verify", package],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 1
+    assert "package verification failed" in output
+
+
+@pytest.mark.parametrize("pack_type", ["deb", "rpm"])
+def test_pack_scripts(tt_cmd, tmpdir, pack_type):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+    postrm = os.path.join(tmpdir, "postrm.sh")
+    with open(postrm, "w") as postrm_file:
+        postrm_file.write("echo removed\n")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", pack_type, "--system-user", "app", "--postrm", postrm],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+
+    packages = [name for name in os.listdir(base_dir) if name.endswith("." + pack_type)]
+    assert len(packages) == 1
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "inspect", os.path.join(base_dir, packages[0])],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    for script in ["preinst", "postinst", "prerm", "postrm"]:
+        assert "  " + script + ":\n" in output
+    assert "useradd -M -N -g 'app'" in output
+    assert "echo removed" in output
+    assert "/usr/lib/tmpfiles.d/bundle1.conf" in output
+
+
+@pytest.mark.parametrize("compression,suffix", [
+    ("xz", ".tar.xz"),
+    ("zstd", ".tar.zst"),
+])
+def test_pack_tgz_compression(tt_cmd, tmpdir, compression, suffix):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "tgz", "--compression", compression],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+
+    packages = [name for name in os.listdir(base_dir) if name.endswith(suffix)]
+    assert len(packages) == 1
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "inspect", "--verify", os.path.join(base_dir, packages[0])],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    assert "Compression: " + compression in output
+    assert "app1.lua" in output
+
+
+def test_pack_invalid_compression(tt_cmd, tmpdir):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "tgz", "--compression", "zstd", "--compression-level", "23"],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 1
+    assert "zstd compression level must be in range [1, 22], got 23" in output
+
+
+@pytest.mark.parametrize("pack_type", ["deb", "rpm"])
+def test_pack_instance_units(tt_cmd, tmpdir, pack_type):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+    with open(os.path.join(base_dir, "app2", "instances.yml"), "w") as instances_file:
+        instances_file.write("router:\nstorage:\n")
+    unit_params = os.path.join(tmpdir, "unit-params.yml")
+    with open(unit_params, "w") as unit_params_file:
+        unit_params_file.write("ProtectSystem: strict\nNoNewPrivileges: true\n")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", pack_type, "--unit-params-file", unit_params],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+
+    packages = [name for name in os.listdir(base_dir) if name.endswith("." + pack_type)]
+    assert len(packages) == 1
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "inspect", os.path.join(base_dir, packages[0])],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 0, output
+    assert "usr/lib/systemd/system/app2@.service" in output
+    assert "usr/lib/systemd/system/app2.target" in output
+    assert "systemctl disable --now 'app2.target'" in output
+
+
+def test_pack_invalid_unit_params(tt_cmd, tmpdir):
+    shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
+                    tmpdir, symlinks=True, ignore=None,
+                    copy_function=shutil.copy2, ignore_dangling_symlinks=True,
+                    dirs_exist_ok=True)
+    base_dir = os.path.join(tmpdir, "bundle1")
+    unit_params = os.path.join(tmpdir, "unit-params.yml")
+    with open(unit_params, "w") as unit_params_file:
+        unit_params_file.write("MemoryMax: lots\n")
+
+    rc, output = run_command_and_get_output(
+        [tt_cmd, "pack", "deb", "--unit-params-file", unit_params],
+        cwd=base_dir, env=dict(os.environ, PWD=base_dir))
+    assert rc == 1
+    assert "MemoryMax: expected a size, a percentage or infinity, got lots" in output
+
+
 def test_pack_incorrect_pack_type(tt_cmd, tmpdir):
     shutil.copytree(os.path.join(os.path.dirname(__file__), "test_bundles"),
                     tmpdir, symlinks=True, ignore=None,